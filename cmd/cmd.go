@@ -25,10 +25,13 @@ import (
 )
 
 var (
-	badDataFile = "dropped.txt"
-	reportFile  = "report.txt"
-	schemaFile  = "schema.txt"
-	sessionFile = "session.json"
+	badDataFile    = "dropped.txt"
+	checkpointFile = "checkpoint.json"
+	diffFile       = "diff.ddl.txt"
+	failedFkFile   = "failed_fks.ddl.txt"
+	reportFile     = "report.txt"
+	schemaFile     = "schema.txt"
+	sessionFile    = "session.json"
 )
 
 // CommandLine provides the core processing for HarbourBridge when run as a command-line tool.
@@ -41,7 +44,7 @@ func CommandLine(ctx context.Context, driver, targetDb, dbURI string, dataOnly,
 	var conv *internal.Conv
 	var err error
 	if !dataOnly {
-		conv, err = conversion.SchemaConv(driver, targetDb, ioHelper, schemaSampleSize)
+		conv, err = conversion.SchemaConv(driver, targetDb, ioHelper, schemaSampleSize, conversion.SchemaOptions{})
 		if err != nil {
 			return err
 		}
@@ -77,12 +80,12 @@ func CommandLine(ctx context.Context, driver, targetDb, dbURI string, dataOnly,
 		return fmt.Errorf("can't create client for db %s: %v", dbURI, err)
 	}
 
-	bw, err := conversion.DataConv(driver, ioHelper, client, conv, dataOnly)
+	bw, err := conversion.DataConv(driver, ioHelper, client, conv, dataOnly, conversion.DataOptions{})
 	if err != nil {
 		return fmt.Errorf("can't finish data conversion for db %s: %v", dbURI, err)
 	}
 	if !skipForeignKeys {
-		if err = conversion.UpdateDDLForeignKeys(ctx, adminClient, dbURI, conv, ioHelper.Out); err != nil {
+		if err = conversion.UpdateDDLForeignKeys(ctx, adminClient, dbURI, conv, conversion.ForeignKeyOptions{FailedFile: outputFilePrefix + failedFkFile}, ioHelper.Out); err != nil {
 			return fmt.Errorf("can't perform update schema on db %s with foreign keys: %v", dbURI, err)
 		}
 	}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/cloudspannerecosystem/harbourbridge/conversion"
+	"github.com/cloudspannerecosystem/harbourbridge/internal"
 )
 
 // Parses input string `s` as a map of key-value pairs. It's expected that the
@@ -77,3 +78,39 @@ func getResourceIds(ctx context.Context, targetProfile TargetProfile, now time.T
 	}
 	return project, instance, dbName, err
 }
+
+// readTransforms reads data transformation rules from file (see
+// internal.ParseTransforms). An empty file name means no rules.
+func readTransforms(file string) ([]internal.Transform, error) {
+	if file == "" {
+		return nil, nil
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("can't open transforms file %s: %v", file, err)
+	}
+	defer f.Close()
+	ts, err := internal.ParseTransforms(f)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse transforms file %s: %v", file, err)
+	}
+	return ts, nil
+}
+
+// readTypeOverrides reads type overrides from file (see
+// internal.ParseTypeOverrides). An empty file name means no overrides.
+func readTypeOverrides(file string) (internal.TypeOverrides, error) {
+	if file == "" {
+		return internal.TypeOverrides{}, nil
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return internal.TypeOverrides{}, fmt.Errorf("can't open type overrides file %s: %v", file, err)
+	}
+	defer f.Close()
+	to, err := internal.ParseTypeOverrides(f)
+	if err != nil {
+		return internal.TypeOverrides{}, fmt.Errorf("can't read type overrides file %s: %v", file, err)
+	}
+	return to, nil
+}
@@ -6,10 +6,12 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"strings"
 	"time"
 
 	"github.com/cloudspannerecosystem/harbourbridge/conversion"
 	"github.com/cloudspannerecosystem/harbourbridge/internal"
+	"github.com/cloudspannerecosystem/harbourbridge/spanner"
 	"github.com/google/subcommands"
 )
 
@@ -20,8 +22,20 @@ type DataCmd struct {
 	target          string
 	targetProfile   string
 	skipForeignKeys bool
+	deferIndexes    bool
+	fkBatchSize     int
 	sessionJSON     string
+	schemaFile      string
 	filePrefix      string // TODO: move filePrefix to global flags
+	transforms      string
+	enforceLimits   bool
+	resume          bool
+	checkpoint      bool
+	writeMode       string
+	readers         int
+	maxWriteRate    int64
+	readPageSize    int64
+	snapshot        bool
 }
 
 // Name returns the name of operation.
@@ -41,7 +55,9 @@ func (cmd *DataCmd) Usage() string {
 Migrate data from source db to target db. Source db dump file can be specified
 by either file param in source-profile or piped to stdin. Connection profile
 for source databases in direct connect mode can be specified by setting
-appropriate environment variables. The data flags are:
+appropriate environment variables. For MySQL, changes made after data was
+migrated can be replayed from the output of mysqlbinlog --verbose, using
+-source-profile="format=binlog". The data flags are:
 `, path.Base(os.Args[0]))
 }
 
@@ -50,10 +66,22 @@ func (cmd *DataCmd) SetFlags(f *flag.FlagSet) {
 	f.StringVar(&cmd.source, "source", "", "Flag for specifying source DB, (e.g., `PostgreSQL`, `MySQL`, `DynamoDB`)")
 	f.StringVar(&cmd.sourceProfile, "source-profile", "", "Flag for specifying connection profile for source database e.g., \"file=<path>,format=dump\"")
 	f.StringVar(&cmd.sessionJSON, "session", "", "Specifies the file we restore session state from")
+	f.StringVar(&cmd.schemaFile, "schema-file", "", "Specifies a Spanner DDL file e.g. an edited schema.ddl.txt, used instead of the session's Spanner schema")
 	f.StringVar(&cmd.target, "target", "Spanner", "Specifies the target DB, defaults to Spanner (accepted values: `Spanner`)")
 	f.StringVar(&cmd.targetProfile, "target-profile", "", "Flag for specifying connection profile for target database e.g., \"dialect=postgresql\"")
 	flag.BoolVar(&cmd.skipForeignKeys, "skip-foreign-keys", false, "Skip creating foreign keys after data migration is complete (ddl statements for foreign keys can still be found in the downloaded schema.ddl.txt file and the same can be applied separately)")
+	f.IntVar(&cmd.fkBatchSize, "fk-batch-size", 1, "Maximum number of foreign keys added by each schema update request after data migration is complete")
+	f.BoolVar(&cmd.deferIndexes, "defer-indexes", false, "Create tables without their secondary indexes, and build the indexes in parallel after data migration is complete (faster bulk loading)")
 	f.StringVar(&cmd.filePrefix, "prefix", "", "File prefix for generated files")
+	f.BoolVar(&cmd.enforceLimits, "enforce-limits", false, "Don't create the Spanner database if the schema violates Spanner limits (violations are always listed in the report)")
+	f.StringVar(&cmd.transforms, "transforms", "", "Specifies a file of data transformation rules e.g. \"users.email: hash_sha256\", applied to data before it is written")
+	f.StringVar(&cmd.writeMode, "write-mode", spanner.WriteModeInsert, "Specifies how rows are written to Spanner: insert (fails on existing rows), insert_or_update, replace or update (fails on missing rows)")
+	f.IntVar(&cmd.readers, "readers", 1, "Number of source tables (or key ranges of large tables) read in parallel, each over its own connection (direct connect mode only)")
+	f.Int64Var(&cmd.readPageSize, "read-page-size", 0, "Number of rows read by each query of a source table with a primary key, using keyset pagination (direct connect mode only; the default, 0, reads each table with a single query)")
+	f.BoolVar(&cmd.snapshot, "snapshot", false, "Read all source tables from one consistent snapshot of the source database, and record its binlog position (MySQL) or WAL location (PostgreSQL) in the checkpoint file for replaying later changes (direct connect mode only; with -readers > 1, MySQL needs the RELOAD privilege and briefly blocks writes with FLUSH TABLES WITH READ LOCK)")
+	f.Int64Var(&cmd.maxWriteRate, "max-write-rate", 0, "Maximum number of rows written to Spanner per second (0 means no limit)")
+	f.BoolVar(&cmd.checkpoint, "checkpoint", false, "Record the progress of the data migration in a checkpoint file next to the session file, so that it can be resumed with -resume if interrupted (in direct connect mode, tables with a primary key are then read in primary key order)")
+	f.BoolVar(&cmd.resume, "resume", false, "Resume an interrupted data migration from the checkpoint file next to the session file (written with -checkpoint), skipping the data already migrated")
 }
 
 func (cmd *DataCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
@@ -77,10 +105,26 @@ func (cmd *DataCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface
 	if err != nil {
 		return subcommands.ExitUsageError
 	}
+	transforms, err := readTransforms(cmd.transforms)
+	if err != nil {
+		return subcommands.ExitUsageError
+	}
+	if err = spanner.CheckWriteMode(cmd.writeMode); err != nil {
+		return subcommands.ExitUsageError
+	}
+	if cmd.readers < 1 || cmd.maxWriteRate < 0 || cmd.readPageSize < 0 {
+		err = fmt.Errorf("-readers must be at least 1, and -max-write-rate and -read-page-size can't be negative")
+		return subcommands.ExitUsageError
+	}
 	targetDb := targetProfile.ToLegacyTargetDb()
 
+	replay := sourceProfile.ty == SourceProfileTypeFile && sourceProfile.file.format == "binlog"
+	if replay && driverName != conversion.MYSQLDUMP {
+		err = fmt.Errorf("format=binlog is only supported for MySQL")
+		return subcommands.ExitUsageError
+	}
 	dumpFilePath := ""
-	if sourceProfile.ty == SourceProfileTypeFile && (sourceProfile.file.format == "" || sourceProfile.file.format == "dump") {
+	if sourceProfile.ty == SourceProfileTypeFile && (sourceProfile.file.format == "" || sourceProfile.file.format == "dump" || replay) {
 		dumpFilePath = sourceProfile.file.path
 	}
 	ioHelper := conversion.NewIOStreams(driverName, dumpFilePath)
@@ -105,10 +149,41 @@ func (cmd *DataCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface
 	if err != nil {
 		return subcommands.ExitUsageError
 	}
+	if cmd.schemaFile != "" {
+		err = conversion.ReadSchemaFile(conv, cmd.schemaFile)
+		if err != nil {
+			return subcommands.ExitUsageError
+		}
+	}
 	if targetDb != "" && conv.TargetDb != targetDb {
 		err = fmt.Errorf("running data migration for Spanner dialect: %v, whereas schema mapping was done for dialect: %v", targetDb, conv.TargetDb)
 		return subcommands.ExitUsageError
 	}
+	// Table filters in the source profile take precedence over those
+	// recorded in the session file. Column filters must match the session's
+	// Spanner schema, so they can't be changed for a data migration.
+	if len(sourceProfile.filters.Tables) > 0 || len(sourceProfile.filters.ExcludeTables) > 0 {
+		conv.Filters.Tables = sourceProfile.filters.Tables
+		conv.Filters.ExcludeTables = sourceProfile.filters.ExcludeTables
+	}
+	conv.SetTransforms(transforms)
+	conv.SetWriteMode(cmd.writeMode)
+	// Change streams and database options in the target profile take
+	// precedence over those recorded in the session file (or schema file).
+	if streams := targetProfile.ChangeStreams(); streams != nil {
+		if err = conv.SetChangeStreams(streams); err != nil {
+			return subcommands.ExitUsageError
+		}
+	}
+	if opts := targetProfile.DbOptions(dbName); !opts.Empty() {
+		conv.DbOptions = opts
+	}
+	// The schema may have been edited since it was generated, so we check
+	// limits again.
+	if limitErr := conv.CheckLimits(); limitErr != nil && cmd.enforceLimits {
+		err = limitErr
+		return subcommands.ExitFailure
+	}
 
 	adminClient, err := conversion.NewDatabaseAdminClient(ctx)
 	if err != nil {
@@ -123,25 +198,117 @@ func (cmd *DataCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface
 	}
 	defer client.Close()
 
-	err = conversion.CreateOrUpdateDatabase(ctx, adminClient, dbURI, conv, ioHelper.Out)
-	if err != nil {
-		err = fmt.Errorf("can't create/update database: %v", err)
-		return subcommands.ExitFailure
+	// Changes made to the source database after its data was migrated are
+	// replayed from the position recorded in the checkpoint file.
+	if replay {
+		checkpointName := checkpointPath(cmd.sessionJSON)
+		checkpoint, readErr := internal.ReadCheckpoint(checkpointName)
+		if readErr != nil {
+			err = fmt.Errorf("can't replay binlog (migrate data first): %v", readErr)
+			return subcommands.ExitUsageError
+		}
+		start, ok := checkpoint.ReplayStart()
+		if !ok || start.BinlogFile == "" {
+			err = fmt.Errorf("can't replay binlog: checkpoint file %s has no binlog position (migrate data in direct connect mode with -snapshot to record one)", checkpointName)
+			return subcommands.ExitUsageError
+		}
+		fmt.Fprintf(ioHelper.Out, "Replaying binlog from %s\n", start)
+		bw, pos, replayErr := conversion.ReplayBinlog(&ioHelper, client, conv, sourceProfile.file.dbName, start, conversion.DataOptions{MaxRowsPerSecond: cmd.maxWriteRate})
+		// Changes that weren't applied would be lost if the next replay
+		// started after them, so the replayed position only advances if
+		// all changes were applied.
+		complete := len(bw.DroppedRowsByTable()) == 0 && conv.BadRows() == 0
+		if complete {
+			checkpoint.SetReplayed(pos)
+			if err = checkpoint.Save(); err != nil {
+				return subcommands.ExitFailure
+			}
+		}
+		if replayErr != nil {
+			err = fmt.Errorf("can't finish replaying binlog for db %s (replayed up to %s): %v", dbURI, pos, replayErr)
+			return subcommands.ExitFailure
+		}
+		if complete {
+			fmt.Fprintf(ioHelper.Out, "Replayed changes up to %s\n", pos)
+		} else {
+			fmt.Fprintf(ioHelper.Out, "Some changes up to %s couldn't be applied (see the report and bad data file): the next replay starts again from %s\n", pos, start)
+		}
+		banner := conversion.GetBanner(now, dbURI)
+		conversion.Report(driverName, bw.DroppedRowsByTable(), ioHelper.BytesRead, banner, conv, cmd.filePrefix+reportFile, ioHelper.Out)
+		conversion.WriteBadData(bw, conv, banner, cmd.filePrefix+badDataFile, ioHelper.Out)
+		return subcommands.ExitSuccess
 	}
 
-	bw, err := conversion.DataConv(driverName, &ioHelper, client, conv, true)
+	conv.SetDeferIndexes(cmd.deferIndexes)
+	// With -checkpoint, progress is recorded in a checkpoint file next to
+	// the session file. Snapshots record their position there too. When
+	// resuming, the database was created by the interrupted run.
+	checkpointName := checkpointPath(cmd.sessionJSON)
+	if cmd.resume {
+		checkpoint, readErr := internal.ReadCheckpoint(checkpointName)
+		if readErr != nil {
+			err = fmt.Errorf("can't resume data migration (run with -checkpoint and without -resume to start over): %v", readErr)
+			return subcommands.ExitUsageError
+		}
+		conv.SetCheckpoint(checkpoint)
+		fmt.Fprintf(ioHelper.Out, "Resuming data migration from checkpoint file %s\n", checkpointName)
+		// Tables without a primary key are read again from the start, so
+		// the rows already written must be deleted to avoid duplicates.
+		// Dumps resume at the statement being processed instead.
+		if driverName == conversion.MYSQL || driverName == conversion.POSTGRES {
+			if err = conversion.TruncateTables(ctx, client, conv, conversion.RereadTables(conv), ioHelper.Out); err != nil {
+				err = fmt.Errorf("can't resume data migration: %v", err)
+				return subcommands.ExitFailure
+			}
+		}
+	} else {
+		err = conversion.CreateOrUpdateDatabase(ctx, adminClient, dbURI, conv, ioHelper.Out)
+		if err != nil {
+			err = fmt.Errorf("can't create/update database: %v", err)
+			return subcommands.ExitFailure
+		}
+		if cmd.checkpoint || cmd.snapshot {
+			conv.SetCheckpoint(internal.NewCheckpoint(checkpointName))
+			if err = conv.Checkpoint().Save(); err != nil {
+				return subcommands.ExitFailure
+			}
+		}
+	}
+
+	bw, err := conversion.DataConv(driverName, &ioHelper, client, conv, true, conversion.DataOptions{Readers: cmd.readers, MaxRowsPerSecond: cmd.maxWriteRate, ReadPageSize: cmd.readPageSize, Snapshot: cmd.snapshot})
+	if saveErr := conv.Checkpoint().Save(); saveErr != nil {
+		fmt.Fprintf(ioHelper.Out, "%v\n", saveErr)
+	}
 	if err != nil {
 		err = fmt.Errorf("can't finish data conversion for db %s: %v", dbURI, err)
 		return subcommands.ExitFailure
 	}
+	if err = conversion.CreateIndexes(ctx, adminClient, dbURI, conv, ioHelper.Out); err != nil {
+		err = fmt.Errorf("can't build secondary indexes on db %s: %v", dbURI, err)
+		return subcommands.ExitFailure
+	}
 	if !cmd.skipForeignKeys {
-		if err = conversion.UpdateDDLForeignKeys(ctx, adminClient, dbURI, conv, ioHelper.Out); err != nil {
+		if err = conversion.UpdateDDLForeignKeys(ctx, adminClient, dbURI, conv, conversion.ForeignKeyOptions{BatchSize: cmd.fkBatchSize, FailedFile: cmd.filePrefix + failedFkFile}, ioHelper.Out); err != nil {
 			err = fmt.Errorf("can't perform update schema on db %s with foreign keys: %v", dbURI, err)
 			return subcommands.ExitFailure
 		}
 	}
+	if err = conversion.CreateChangeStreams(ctx, adminClient, dbURI, conv, ioHelper.Out); err != nil {
+		err = fmt.Errorf("can't create change streams on db %s: %v", dbURI, err)
+		return subcommands.ExitFailure
+	}
 	banner := conversion.GetBanner(now, dbURI)
 	conversion.Report(driverName, bw.DroppedRowsByTable(), ioHelper.BytesRead, banner, conv, cmd.filePrefix+reportFile, ioHelper.Out)
 	conversion.WriteBadData(bw, conv, banner, cmd.filePrefix+badDataFile, ioHelper.Out)
 	return subcommands.ExitSuccess
 }
+
+// checkpointPath returns the path of the checkpoint file of a data
+// migration that uses session file sessionJSON. The checkpoint file sits
+// next to the session file.
+func checkpointPath(sessionJSON string) string {
+	if strings.HasSuffix(sessionJSON, sessionFile) {
+		return strings.TrimSuffix(sessionJSON, sessionFile) + checkpointFile
+	}
+	return sessionJSON + "." + checkpointFile
+}
@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckpointPath(t *testing.T) {
+	testCases := []struct {
+		session string
+		want    string
+	}{
+		{"mydb.session.json", "mydb.checkpoint.json"},
+		{"out/mydb.session.json", "out/mydb.checkpoint.json"},
+		{"session.json", "checkpoint.json"},
+		{"my-session.txt", "my-session.txt.checkpoint.json"},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.want, checkpointPath(tc.session), tc.session)
+	}
+}
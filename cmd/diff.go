@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"github.com/cloudspannerecosystem/harbourbridge/conversion"
+	"github.com/cloudspannerecosystem/harbourbridge/internal"
+	"github.com/google/subcommands"
+)
+
+// DiffCmd struct with flags.
+type DiffCmd struct {
+	sessionJSON   string
+	schemaFile    string
+	targetProfile string
+	filePrefix    string
+}
+
+// Name returns the name of operation.
+func (cmd *DiffCmd) Name() string {
+	return "diff"
+}
+
+// Synopsis returns summary of operation.
+func (cmd *DiffCmd) Synopsis() string {
+	return "compare generated schema with the schema of an existing target db"
+}
+
+// Usage returns usage info of the command.
+func (cmd *DiffCmd) Usage() string {
+	return fmt.Sprintf(`%v diff -session=[session_file] -target-profile="instance=my-instance,dbname=my-db"...
+
+Compare the Spanner schema recorded in a session file (or a Spanner DDL file)
+with the schema of an existing Spanner database. Lists missing and extra
+tables, columns, indexes and foreign keys, and writes the DDL statements that
+update the database to a file. The diff flags are:
+`, path.Base(os.Args[0]))
+}
+
+// SetFlags sets the flags.
+func (cmd *DiffCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&cmd.sessionJSON, "session", "", "Specifies the file we restore session state from")
+	f.StringVar(&cmd.schemaFile, "schema-file", "", "Specifies a Spanner DDL file e.g. an edited schema.ddl.txt, used instead of the session's Spanner schema")
+	f.StringVar(&cmd.targetProfile, "target-profile", "", "Flag for specifying connection profile for target database e.g., \"instance=my-instance,dbname=my-db\"")
+	f.StringVar(&cmd.filePrefix, "prefix", "", "File prefix for generated files")
+}
+
+func (cmd *DiffCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	var err error
+	defer func() {
+		if err != nil {
+			fmt.Printf("FATAL error: %v\n", err)
+		}
+	}()
+
+	targetProfile, err := NewTargetProfile(cmd.targetProfile)
+	if err != nil {
+		return subcommands.ExitUsageError
+	}
+	sp := targetProfile.conn.sp
+	if sp.dbname == "" {
+		err = fmt.Errorf("dbname of an existing database must be specified in the target profile")
+		return subcommands.ExitUsageError
+	}
+	if sp.project == "" {
+		sp.project, err = conversion.GetProject()
+		if err != nil {
+			err = fmt.Errorf("can't get project: %v", err)
+			return subcommands.ExitFailure
+		}
+	}
+	if sp.instance == "" {
+		sp.instance, err = conversion.GetInstance(ctx, sp.project, os.Stdout)
+		if err != nil {
+			err = fmt.Errorf("can't get instance: %v", err)
+			return subcommands.ExitFailure
+		}
+	}
+	dbURI := fmt.Sprintf("projects/%s/instances/%s/databases/%s", sp.project, sp.instance, sp.dbname)
+
+	// If filePrefix not explicitly set, use dbName as prefix.
+	if cmd.filePrefix == "" {
+		cmd.filePrefix = sp.dbname + "."
+	}
+
+	conv := internal.MakeConv()
+	err = conversion.ReadSessionFile(conv, cmd.sessionJSON)
+	if err != nil {
+		return subcommands.ExitUsageError
+	}
+	if cmd.schemaFile != "" {
+		err = conversion.ReadSchemaFile(conv, cmd.schemaFile)
+		if err != nil {
+			return subcommands.ExitUsageError
+		}
+	}
+
+	adminClient, err := conversion.NewDatabaseAdminClient(ctx)
+	if err != nil {
+		err = fmt.Errorf("can't create admin client: %w", conversion.AnalyzeError(err, dbURI))
+		return subcommands.ExitFailure
+	}
+	defer adminClient.Close()
+	d, err := conversion.DiffDatabase(ctx, adminClient, dbURI, conv)
+	if err != nil {
+		return subcommands.ExitFailure
+	}
+	for _, s := range d.Differences {
+		fmt.Println(s)
+	}
+	fmt.Printf("Found %d differences, %d DDL statements needed.\n", len(d.Differences), len(d.Statements))
+	conversion.WriteDiffFile(d, time.Now(), dbURI, cmd.filePrefix+diffFile, os.Stdout)
+	return subcommands.ExitSuccess
+}
@@ -20,7 +20,16 @@ type EvalCmd struct {
 	target          string
 	targetProfile   string
 	skipForeignKeys bool
+	deferIndexes    bool
+	fkBatchSize     int
 	filePrefix      string // TODO: move filePrefix to global flags
+	naming          string
+	typeOverrides   string
+	hotspotRemedy   string
+	syntheticPKey   string
+	transforms      string
+	enforceLimits   bool
+	rowDeletion     string
 }
 
 // Name returns the name of operation.
@@ -51,7 +60,16 @@ func (cmd *EvalCmd) SetFlags(f *flag.FlagSet) {
 	f.StringVar(&cmd.target, "target", "Spanner", "Specifies the target DB, defaults to Spanner (accepted values: `Spanner`)")
 	f.StringVar(&cmd.targetProfile, "target-profile", "", "Flag for specifying connection profile for target database e.g., \"dialect=postgresql\"")
 	flag.BoolVar(&cmd.skipForeignKeys, "skip-foreign-keys", false, "Skip creating foreign keys after data migration is complete (ddl statements for foreign keys can still be found in the downloaded schema.ddl.txt file and the same can be applied separately)")
+	f.IntVar(&cmd.fkBatchSize, "fk-batch-size", 1, "Maximum number of foreign keys added by each schema update request after data migration is complete")
+	f.BoolVar(&cmd.deferIndexes, "defer-indexes", false, "Create tables without their secondary indexes, and build the indexes in parallel after data migration is complete (faster bulk loading)")
 	f.StringVar(&cmd.filePrefix, "prefix", "", "File prefix for generated files")
+	f.StringVar(&cmd.typeOverrides, "type-overrides", "", "Specifies a JSON or YAML file of source type to Spanner type overrides")
+	f.StringVar(&cmd.naming, "naming", "", "Flag for specifying the naming policy for Spanner names e.g., \"case=snake,strip-prefix=tbl_\"")
+	f.StringVar(&cmd.hotspotRemedy, "hotspot-remedy", "", "Remedy applied to primary keys that cause hotspots (accepted values: `reorder`, `shard`)")
+	f.StringVar(&cmd.rowDeletion, "row-deletion-policy", "", "Flag for specifying row deletion policies (TTL) of source tables e.g., \"events=created_at:30\"")
+	f.StringVar(&cmd.syntheticPKey, "synthetic-pk", "", "Kind of synthetic primary key added to tables without one (accepted values: `sequence`, `uuid`), defaults to sequence")
+	f.BoolVar(&cmd.enforceLimits, "enforce-limits", false, "Don't create the Spanner database if the schema violates Spanner limits (violations are always listed in the report)")
+	f.StringVar(&cmd.transforms, "transforms", "", "Specifies a file of data transformation rules e.g. \"users.email: hash_sha256\", applied to data before it is written")
 }
 
 func (cmd *EvalCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
@@ -75,8 +93,31 @@ func (cmd *EvalCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface
 	if err != nil {
 		return subcommands.ExitUsageError
 	}
+	transforms, err := readTransforms(cmd.transforms)
+	if err != nil {
+		return subcommands.ExitUsageError
+	}
 	targetDb := targetProfile.ToLegacyTargetDb()
 
+	naming, err := NewNamingPolicy(cmd.naming)
+	if err != nil {
+		return subcommands.ExitUsageError
+	}
+	typeOverrides, err := readTypeOverrides(cmd.typeOverrides)
+	if err != nil {
+		return subcommands.ExitUsageError
+	}
+	if err = internal.CheckHotspotRemedy(cmd.hotspotRemedy); err != nil {
+		return subcommands.ExitUsageError
+	}
+	if err = internal.CheckSyntheticPKey(cmd.syntheticPKey); err != nil {
+		return subcommands.ExitUsageError
+	}
+	rowDeletion, err := NewRowDeletionPolicies(cmd.rowDeletion)
+	if err != nil {
+		return subcommands.ExitUsageError
+	}
+
 	dumpFilePath := ""
 	if sourceProfile.ty == SourceProfileTypeFile && (sourceProfile.file.format == "" || sourceProfile.file.format == "dump") {
 		dumpFilePath = sourceProfile.file.path
@@ -88,13 +129,18 @@ func (cmd *EvalCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface
 
 	now := time.Now()
 
-	// If filePrefix not explicitly set, use dbName as prefix.
-	if cmd.filePrefix == "" {
-		dbName, err := conversion.GetDatabaseName(driverName, now)
+	// The database name is generated up front (unless the target profile
+	// names the database), since the file prefix and database options use
+	// it. getResourceIds then uses the same name.
+	if targetProfile.conn.sp.dbname == "" {
+		targetProfile.conn.sp.dbname, err = conversion.GetDatabaseName(driverName, now)
 		if err != nil {
 			panic(fmt.Errorf("can't generate database name for prefix: %v", err))
 		}
-		cmd.filePrefix = dbName + "."
+	}
+	// If filePrefix not explicitly set, use dbName as prefix.
+	if cmd.filePrefix == "" {
+		cmd.filePrefix = targetProfile.conn.sp.dbname + "."
 	}
 
 	schemaSampleSize := int64(100000)
@@ -106,14 +152,20 @@ func (cmd *EvalCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface
 		}
 	}
 	var conv *internal.Conv
-	conv, err = conversion.SchemaConv(driverName, targetDb, &ioHelper, schemaSampleSize)
+	conv, err = conversion.SchemaConv(driverName, targetDb, &ioHelper, schemaSampleSize, conversion.SchemaOptions{Filters: sourceProfile.filters, NamingPolicy: naming, TypeOverrides: typeOverrides, HotspotRemedy: cmd.hotspotRemedy, SyntheticPKey: cmd.syntheticPKey, RowDeletion: rowDeletion, ChangeStreams: targetProfile.ChangeStreams(), DbOptions: targetProfile.DbOptions(targetProfile.conn.sp.dbname)})
 	if err != nil {
 		panic(err)
 	}
+	conv.SetTransforms(transforms)
 
 	conversion.WriteSchemaFile(conv, now, cmd.filePrefix+schemaFile, ioHelper.Out)
 	conversion.WriteSessionFile(conv, cmd.filePrefix+sessionFile, ioHelper.Out)
 	conversion.Report(driverName, nil, ioHelper.BytesRead, "", conv, cmd.filePrefix+reportFile, ioHelper.Out)
+	if cmd.enforceLimits {
+		if err = conv.CheckLimits(); err != nil {
+			return subcommands.ExitFailure
+		}
+	}
 
 	project, instance, dbName, err := getResourceIds(ctx, targetProfile, now, driverName, ioHelper.Out)
 	if err != nil {
@@ -134,23 +186,32 @@ func (cmd *EvalCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface
 	}
 	defer client.Close()
 
+	conv.SetDeferIndexes(cmd.deferIndexes)
 	err = conversion.CreateOrUpdateDatabase(ctx, adminClient, dbURI, conv, ioHelper.Out)
 	if err != nil {
 		err = fmt.Errorf("can't create/update database: %v", err)
 		return subcommands.ExitFailure
 	}
 
-	bw, err := conversion.DataConv(driverName, &ioHelper, client, conv, true)
+	bw, err := conversion.DataConv(driverName, &ioHelper, client, conv, true, conversion.DataOptions{})
 	if err != nil {
 		err = fmt.Errorf("can't finish data conversion for db %s: %v", dbURI, err)
 		return subcommands.ExitFailure
 	}
+	if err = conversion.CreateIndexes(ctx, adminClient, dbURI, conv, ioHelper.Out); err != nil {
+		err = fmt.Errorf("can't build secondary indexes on db %s: %v", dbURI, err)
+		return subcommands.ExitFailure
+	}
 	if !cmd.skipForeignKeys {
-		if err = conversion.UpdateDDLForeignKeys(ctx, adminClient, dbURI, conv, ioHelper.Out); err != nil {
+		if err = conversion.UpdateDDLForeignKeys(ctx, adminClient, dbURI, conv, conversion.ForeignKeyOptions{BatchSize: cmd.fkBatchSize, FailedFile: cmd.filePrefix + failedFkFile}, ioHelper.Out); err != nil {
 			err = fmt.Errorf("can't perform update schema on db %s with foreign keys: %v", dbURI, err)
 			return subcommands.ExitFailure
 		}
 	}
+	if err = conversion.CreateChangeStreams(ctx, adminClient, dbURI, conv, ioHelper.Out); err != nil {
+		err = fmt.Errorf("can't create change streams on db %s: %v", dbURI, err)
+		return subcommands.ExitFailure
+	}
 	banner := conversion.GetBanner(now, dbURI)
 	conversion.Report(driverName, bw.DroppedRowsByTable(), ioHelper.BytesRead, banner, conv, cmd.filePrefix+reportFile, ioHelper.Out)
 	conversion.WriteBadData(bw, conv, banner, cmd.filePrefix+badDataFile, ioHelper.Out)
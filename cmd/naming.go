@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudspannerecosystem/harbourbridge/internal"
+)
+
+// NewNamingPolicy parses the naming policy for Spanner names, which is
+// passed as a list of key value pairs on the command line. Supported keys
+// are:
+//
+//	case           Either "snake" (snake_case) or "lower" (lower case).
+//	strip-prefix   Comma separated list of prefixes removed from table names.
+//	table-prefix   Prefix added to all table names.
+//	schema-mapping How tables outside the default schema are named: "flatten"
+//	               ("schema.table" becomes "schema_table", the default),
+//	               "drop" (it becomes "table") or "named" (it becomes table
+//	               "table" in Spanner named schema "schema").
+//	reserved       How names that are Spanner reserved keywords are handled:
+//	               "quote" (they are only quoted, the default) or "rename"
+//	               ("_" is added e.g. "order" becomes "order_").
+//
+// Example: -naming='case=snake,"strip-prefix=tbl_,t_",table-prefix=sales_'
+func NewNamingPolicy(s string) (internal.NamingPolicy, error) {
+	naming := internal.NamingPolicy{}
+	params, err := parseProfile(s)
+	if err != nil {
+		return naming, fmt.Errorf("could not parse naming policy, error = %v", err)
+	}
+	for k, v := range params {
+		switch k {
+		case "case":
+			switch v {
+			case "snake":
+				naming.SnakeCase = true
+			case "lower":
+				naming.Lowercase = true
+			default:
+				return naming, fmt.Errorf("invalid case = %v, expected snake or lower", v)
+			}
+		case "strip-prefix":
+			for _, prefix := range strings.Split(v, ",") {
+				if prefix = strings.TrimSpace(prefix); prefix != "" {
+					naming.StripPrefixes = append(naming.StripPrefixes, prefix)
+				}
+			}
+		case "table-prefix":
+			naming.TablePrefix = v
+		case "schema-mapping":
+			switch v {
+			case internal.SchemasFlatten, internal.SchemasDrop, internal.SchemasNamed:
+				naming.Schemas = v
+			default:
+				return naming, fmt.Errorf("invalid schema-mapping = %v, expected %s, %s or %s", v, internal.SchemasFlatten, internal.SchemasDrop, internal.SchemasNamed)
+			}
+		case "reserved":
+			switch v {
+			case "quote":
+				naming.RenameReserved = false
+			case "rename":
+				naming.RenameReserved = true
+			default:
+				return naming, fmt.Errorf("invalid reserved = %v, expected quote or rename", v)
+			}
+		default:
+			return naming, fmt.Errorf("unknown naming policy key %v", k)
+		}
+	}
+	return naming, nil
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/cloudspannerecosystem/harbourbridge/internal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewNamingPolicy(t *testing.T) {
+	testCases := []struct {
+		name      string
+		naming    string
+		want      internal.NamingPolicy
+		errorWant bool
+	}{
+		{
+			name:   "no policy",
+			naming: "",
+			want:   internal.NamingPolicy{},
+		},
+		{
+			name:   "snake case with prefixes",
+			naming: `case=snake,"strip-prefix=tbl_, t_",table-prefix=sales_`,
+			want:   internal.NamingPolicy{SnakeCase: true, StripPrefixes: []string{"tbl_", "t_"}, TablePrefix: "sales_"},
+		},
+		{
+			name:   "lower case",
+			naming: "case=lower",
+			want:   internal.NamingPolicy{Lowercase: true},
+		},
+		{
+			name:   "named schemas",
+			naming: "schema-mapping=named",
+			want:   internal.NamingPolicy{Schemas: internal.SchemasNamed},
+		},
+		{
+			name:   "rename reserved keywords",
+			naming: "reserved=rename,case=lower",
+			want:   internal.NamingPolicy{Lowercase: true, RenameReserved: true},
+		},
+		{
+			name:      "bad reserved",
+			naming:    "reserved=drop",
+			errorWant: true,
+		},
+		{
+			name:      "bad schema mapping",
+			naming:    "schema-mapping=nested",
+			errorWant: true,
+		},
+		{
+			name:      "bad case",
+			naming:    "case=camel",
+			errorWant: true,
+		},
+		{
+			name:      "unknown key",
+			naming:    "suffix=_t",
+			errorWant: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		naming, err := NewNamingPolicy(tc.naming)
+		if tc.errorWant {
+			assert.NotNil(t, err, tc.name)
+			continue
+		}
+		assert.Nil(t, err, tc.name)
+		assert.Equal(t, tc.want, naming, tc.name)
+	}
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cloudspannerecosystem/harbourbridge/spanner/ddl"
+)
+
+// NewRowDeletionPolicies parses row deletion policies, which are passed as
+// a list of key value pairs on the command line. Keys are source table
+// names, and values have the form "column:days", where column is a source
+// timestamp column: rows are deleted once the column is older than days.
+//
+// Example: -row-deletion-policy='events=created_at:30,sessions=last_seen:7'
+func NewRowDeletionPolicies(s string) (map[string]ddl.RowDeletionPolicy, error) {
+	params, err := parseProfile(s)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse row deletion policies, error = %v", err)
+	}
+	policies := make(map[string]ddl.RowDeletionPolicy)
+	for table, v := range params {
+		i := strings.LastIndex(v, ":")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid row deletion policy %v for table %v, expected column:days", v, table)
+		}
+		days, err := strconv.ParseInt(v[i+1:], 10, 64)
+		if err != nil || days < 0 {
+			return nil, fmt.Errorf("invalid number of days %v for table %v", v[i+1:], table)
+		}
+		policies[table] = ddl.RowDeletionPolicy{Col: v[:i], Days: days}
+	}
+	return policies, nil
+}
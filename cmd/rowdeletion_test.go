@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/cloudspannerecosystem/harbourbridge/spanner/ddl"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRowDeletionPolicies(t *testing.T) {
+	testCases := []struct {
+		name      string
+		policies  string
+		want      map[string]ddl.RowDeletionPolicy
+		errorWant bool
+	}{
+		{
+			name:     "no policies",
+			policies: "",
+			want:     map[string]ddl.RowDeletionPolicy{},
+		},
+		{
+			name:     "several tables",
+			policies: "events=created_at:30,sessions=last_seen:0",
+			want: map[string]ddl.RowDeletionPolicy{
+				"events":   {Col: "created_at", Days: 30},
+				"sessions": {Col: "last_seen", Days: 0},
+			},
+		},
+		{
+			name:     "column with a colon",
+			policies: `"logs=a:b:7"`,
+			want:     map[string]ddl.RowDeletionPolicy{"logs": {Col: "a:b", Days: 7}},
+		},
+		{
+			name:      "missing days",
+			policies:  "events=created_at",
+			errorWant: true,
+		},
+		{
+			name:      "missing column",
+			policies:  "events=:30",
+			errorWant: true,
+		},
+		{
+			name:      "negative days",
+			policies:  "events=created_at:-1",
+			errorWant: true,
+		},
+		{
+			name:      "duplicate table",
+			policies:  "events=a:1,events=b:2",
+			errorWant: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		policies, err := NewRowDeletionPolicies(tc.policies)
+		if tc.errorWant {
+			assert.NotNil(t, err, tc.name)
+			continue
+		}
+		assert.Nil(t, err, tc.name)
+		assert.Equal(t, tc.want, policies, tc.name)
+	}
+}
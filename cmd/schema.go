@@ -20,6 +20,11 @@ type SchemaCmd struct {
 	target        string
 	targetProfile string
 	filePrefix    string // TODO: move filePrefix to global flags
+	naming        string
+	typeOverrides string
+	hotspotRemedy string
+	syntheticPKey string
+	rowDeletion   string
 }
 
 // Name returns the name of operation.
@@ -50,6 +55,11 @@ func (cmd *SchemaCmd) SetFlags(f *flag.FlagSet) {
 	f.StringVar(&cmd.target, "target", "Spanner", "Specifies the target DB, defaults to Spanner (accepted values: `Spanner`)")
 	f.StringVar(&cmd.targetProfile, "target-profile", "", "Flag for specifying connection profile for target database e.g., \"dialect=postgresql\"")
 	f.StringVar(&cmd.filePrefix, "prefix", "", "File prefix for generated files")
+	f.StringVar(&cmd.typeOverrides, "type-overrides", "", "Specifies a JSON or YAML file of source type to Spanner type overrides")
+	f.StringVar(&cmd.naming, "naming", "", "Flag for specifying the naming policy for Spanner names e.g., \"case=snake,strip-prefix=tbl_\"")
+	f.StringVar(&cmd.hotspotRemedy, "hotspot-remedy", "", "Remedy applied to primary keys that cause hotspots (accepted values: `reorder`, `shard`)")
+	f.StringVar(&cmd.rowDeletion, "row-deletion-policy", "", "Flag for specifying row deletion policies (TTL) of source tables e.g., \"events=created_at:30\"")
+	f.StringVar(&cmd.syntheticPKey, "synthetic-pk", "", "Kind of synthetic primary key added to tables without one (accepted values: `sequence`, `uuid`), defaults to sequence")
 }
 
 func (cmd *SchemaCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
@@ -75,6 +85,25 @@ func (cmd *SchemaCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interfa
 	}
 	targetDb := targetProfile.ToLegacyTargetDb()
 
+	naming, err := NewNamingPolicy(cmd.naming)
+	if err != nil {
+		return subcommands.ExitUsageError
+	}
+	typeOverrides, err := readTypeOverrides(cmd.typeOverrides)
+	if err != nil {
+		return subcommands.ExitUsageError
+	}
+	if err = internal.CheckHotspotRemedy(cmd.hotspotRemedy); err != nil {
+		return subcommands.ExitUsageError
+	}
+	if err = internal.CheckSyntheticPKey(cmd.syntheticPKey); err != nil {
+		return subcommands.ExitUsageError
+	}
+	rowDeletion, err := NewRowDeletionPolicies(cmd.rowDeletion)
+	if err != nil {
+		return subcommands.ExitUsageError
+	}
+
 	dumpFilePath := ""
 	if sourceProfile.ty == SourceProfileTypeFile && (sourceProfile.file.format == "" || sourceProfile.file.format == "dump") {
 		dumpFilePath = sourceProfile.file.path
@@ -84,13 +113,18 @@ func (cmd *SchemaCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interfa
 		defer ioHelper.In.Close()
 	}
 
-	// If filePrefix not explicitly set, use generated dbName.
-	if cmd.filePrefix == "" {
-		dbName, err := conversion.GetDatabaseName(driverName, time.Now())
+	// Database options are recorded with the name of the database, so
+	// we use the target profile's dbname or generate one.
+	dbName := targetProfile.conn.sp.dbname
+	if dbName == "" {
+		dbName, err = conversion.GetDatabaseName(driverName, time.Now())
 		if err != nil {
 			err = fmt.Errorf("can't generate database name for prefix: %v", err)
 			return subcommands.ExitFailure
 		}
+	}
+	// If filePrefix not explicitly set, use generated dbName.
+	if cmd.filePrefix == "" {
 		cmd.filePrefix = dbName + "."
 	}
 
@@ -103,7 +137,7 @@ func (cmd *SchemaCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interfa
 		}
 	}
 	var conv *internal.Conv
-	conv, err = conversion.SchemaConv(driverName, targetDb, &ioHelper, schemaSampleSize)
+	conv, err = conversion.SchemaConv(driverName, targetDb, &ioHelper, schemaSampleSize, conversion.SchemaOptions{Filters: sourceProfile.filters, NamingPolicy: naming, TypeOverrides: typeOverrides, HotspotRemedy: cmd.hotspotRemedy, SyntheticPKey: cmd.syntheticPKey, RowDeletion: rowDeletion, ChangeStreams: targetProfile.ChangeStreams(), DbOptions: targetProfile.DbOptions(dbName)})
 	if err != nil {
 		return subcommands.ExitFailure
 	}
@@ -5,6 +5,8 @@ import (
 	"os"
 	"strconv"
 	"strings"
+
+	"github.com/cloudspannerecosystem/harbourbridge/internal"
 )
 
 type SourceProfileType int
@@ -19,6 +21,7 @@ const (
 type SourceProfileFile struct {
 	path   string
 	format string
+	dbName string // Database whose changes are replayed (format "binlog" only).
 }
 
 func NewSourceProfileFile(params map[string]string) SourceProfileFile {
@@ -33,6 +36,7 @@ func NewSourceProfileFile(params map[string]string) SourceProfileFile {
 		fmt.Printf("source-profile format defaulting to `dump`\n")
 		profile.format = "dump"
 	}
+	profile.dbName = params["db_name"]
 	return profile
 }
 
@@ -183,10 +187,47 @@ func NewSourceProfileConfig(path string) SourceProfileConfig {
 }
 
 type SourceProfile struct {
-	ty     SourceProfileType
-	file   SourceProfileFile
-	conn   SourceProfileConnection
-	config SourceProfileConfig
+	ty      SourceProfileType
+	file    SourceProfileFile
+	conn    SourceProfileConnection
+	config  SourceProfileConfig
+	filters internal.Filters
+}
+
+// NewSourceProfileFilters parses the table and column filters of a source
+// profile. All of "schemas", "tables", "exclude-tables", "columns" and
+// "exclude-columns" take a comma separated list of glob patterns (quote the
+// key=value pair if it lists more than one pattern). Column patterns have
+// the form "table.column".
+func NewSourceProfileFilters(params map[string]string) (internal.Filters, error) {
+	filters := internal.Filters{}
+	var err error
+	if tables, ok := params["tables"]; ok {
+		if filters.Tables, err = internal.ParsePatterns(tables); err != nil {
+			return filters, fmt.Errorf("could not parse tables = %v: %v", tables, err)
+		}
+	}
+	if tables, ok := params["exclude-tables"]; ok {
+		if filters.ExcludeTables, err = internal.ParsePatterns(tables); err != nil {
+			return filters, fmt.Errorf("could not parse exclude-tables = %v: %v", tables, err)
+		}
+	}
+	if schemas, ok := params["schemas"]; ok {
+		if filters.Schemas, err = internal.ParsePatterns(schemas); err != nil {
+			return filters, fmt.Errorf("could not parse schemas = %v: %v", schemas, err)
+		}
+	}
+	if cols, ok := params["columns"]; ok {
+		if filters.Columns, err = internal.ParseColumnPatterns(cols); err != nil {
+			return filters, fmt.Errorf("could not parse columns = %v: %v", cols, err)
+		}
+	}
+	if cols, ok := params["exclude-columns"]; ok {
+		if filters.ExcludeColumns, err = internal.ParseColumnPatterns(cols); err != nil {
+			return filters, fmt.Errorf("could not parse exclude-columns = %v: %v", cols, err)
+		}
+	}
+	return filters, nil
 }
 
 // ToLegacyDriver converts source profile to equivalent legacy global flags
@@ -235,16 +276,27 @@ func (src SourceProfile) ToLegacyDriver(source string) (string, error) {
 // File path can be a local file path or a gcs file path. Support for more file
 // path types can be added in future.
 // File format can be "dump" e.g., when specifying a mysqldump or pgdump etc.
-// Support for more formats e.g., "csv", "avro" etc can be added in future.
+// The data command also accepts "binlog" for the output of mysqlbinlog
+// --verbose, whose changes it replays (only those of database db_name, if
+// set). Support for more formats e.g., "csv", "avro" etc can be added in future.
 //
 // Example: -source-profile="file=/tmp/abc, format=dump"
 // Example: -source-profile="file=gcs://bucket_name/cart.txt, format=dump"
+// Example: -source-profile="file=/tmp/changes.txt, format=binlog, db_name=shop"
 //
 // Format 2. Specify source connection parameters. If none specified, then read
 // from envrironment variables.
 //
 // Format 3. Specify a config file that specifies source connection profile.
 //
+// Formats 1 and 2 also accept schema, table and column filters, which
+// restrict conversion to a subset of the source tables and columns. All take
+// a comma separated list of glob patterns.
+//
+// Example: -source-profile='file=/tmp/abc,"tables=orders,order_*",exclude-tables=*_tmp'
+// Example: -source-profile='file=/tmp/abc,"exclude-columns=*.ssn,users.legacy_*"'
+// Example: -source-profile='host=localhost,"schemas=sales,hr"'
+//
 func NewSourceProfile(s string, source string) (SourceProfile, error) {
 	if source == "" {
 		return SourceProfile{}, fmt.Errorf("cannot leave -source flag empty, please specify source databases e.g., -source=postgres etc")
@@ -253,10 +305,14 @@ func NewSourceProfile(s string, source string) (SourceProfile, error) {
 	if err != nil {
 		return SourceProfile{}, fmt.Errorf("could not parse source profile, error = %v", err)
 	}
+	filters, err := NewSourceProfileFilters(params)
+	if err != nil {
+		return SourceProfile{}, err
+	}
 
 	if _, ok := params["file"]; ok || filePipedToStdin() {
 		profile := NewSourceProfileFile(params)
-		return SourceProfile{ty: SourceProfileTypeFile, file: profile}, nil
+		return SourceProfile{ty: SourceProfileTypeFile, file: profile, filters: filters}, nil
 	} else if format, ok := params["format"]; ok {
 		// File is not passed in from stdin or specified using "file" flag.
 		return SourceProfile{ty: SourceProfileTypeFile}, fmt.Errorf("file not specified, but format set to %v", format)
@@ -268,7 +324,7 @@ func NewSourceProfile(s string, source string) (SourceProfile, error) {
 		// connection parameters could be specified as part of environment
 		// variables.
 		conn, err := NewSourceProfileConnection(source, params)
-		return SourceProfile{ty: SourceProfileTypeConnection, conn: conn}, err
+		return SourceProfile{ty: SourceProfileTypeConnection, conn: conn, filters: filters}, err
 	}
 }
 
@@ -3,6 +3,7 @@ package cmd
 import (
 	"testing"
 
+	"github.com/cloudspannerecosystem/harbourbridge/internal"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -43,6 +44,12 @@ func TestNewSourceProfileFile(t *testing.T) {
 			pipedToStdin: false,
 			want:         SourceProfileFile{format: "dump", path: "file1.mysqldump"},
 		},
+		{
+			name:         "binlog format with database",
+			params:       map[string]string{"format": "binlog", "file": "changes.txt", "db_name": "shop"},
+			pipedToStdin: false,
+			want:         SourceProfileFile{format: "binlog", path: "changes.txt", dbName: "shop"},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -53,3 +60,53 @@ func TestNewSourceProfileFile(t *testing.T) {
 		assert.Equal(t, profile, tc.want, tc.name)
 	}
 }
+
+func TestNewSourceProfileFilters(t *testing.T) {
+	testCases := []struct {
+		name      string
+		params    map[string]string
+		want      internal.Filters
+		errorWant bool
+	}{
+		{
+			name:   "no filters",
+			params: map[string]string{"file": "file1.mysqldump"},
+			want:   internal.Filters{},
+		},
+		{
+			name:   "include and exclude",
+			params: map[string]string{"tables": "orders, order_*", "exclude-tables": "*_tmp"},
+			want:   internal.Filters{Tables: []string{"orders", "order_*"}, ExcludeTables: []string{"*_tmp"}},
+		},
+		{
+			name:      "bad pattern",
+			params:    map[string]string{"tables": "[a-"},
+			errorWant: true,
+		},
+		{
+			name:   "column filters",
+			params: map[string]string{"columns": "users.id,users.name", "exclude-columns": "*.ssn"},
+			want:   internal.Filters{Columns: []string{"users.id", "users.name"}, ExcludeColumns: []string{"*.ssn"}},
+		},
+		{
+			name:   "schema filters",
+			params: map[string]string{"schemas": "sales, hr_*"},
+			want:   internal.Filters{Schemas: []string{"sales", "hr_*"}},
+		},
+		{
+			name:      "column pattern without table",
+			params:    map[string]string{"exclude-columns": "ssn"},
+			errorWant: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		filters, err := NewSourceProfileFilters(tc.params)
+		if tc.errorWant {
+			assert.NotNil(t, err, tc.name)
+			continue
+		}
+		assert.Nil(t, err, tc.name)
+		assert.Equal(t, tc.want, filters, tc.name)
+	}
+}
@@ -2,7 +2,10 @@ package cmd
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
+
+	"github.com/cloudspannerecosystem/harbourbridge/spanner/ddl"
 )
 
 type TargetProfileType int
@@ -25,6 +28,8 @@ type TargetProfileConnectionSpanner struct {
 	instance string
 	dbname   string
 	dialect  string
+	streams  []ddl.ChangeStream  // Change streams created after data migration.
+	options  ddl.DatabaseOptions // Database options, without the database name.
 }
 
 type TargetProfileConnection struct {
@@ -83,6 +88,19 @@ func (trg TargetProfile) ToLegacyTargetDb() string {
 // Example: -target-profile="instance=my-instance1,dbname=my-new-db1"
 // Example: -target-profile="instance=my-instance1,dbname=my-new-db1,dialect=PostgreSQL"
 //
+// A change stream can be created once data has been migrated: changestreams
+// is either "all" or a comma separated list of Spanner tables (quote the
+// key=value pair if it lists more than one table), and changestream-name,
+// changestream-retention and changestream-capture optionally set its name,
+// retention period and value capture type.
+//
+// Example: -target-profile="instance=my-instance1,changestreams=all,changestream-retention=7d"
+//
+// Database options version_retention_period, default_leader and
+// optimizer_version are set when the database is created.
+//
+// Example: -target-profile="instance=my-instance1,version_retention_period=3d,optimizer_version=4"
+//
 func NewTargetProfile(s string) (TargetProfile, error) {
 	params, err := parseProfile(s)
 	if err != nil {
@@ -105,7 +123,88 @@ func NewTargetProfile(s string) (TargetProfile, error) {
 	if dialect, ok := params["dialect"]; ok {
 		sp.dialect = dialect
 	}
+	if sp.streams, err = newChangeStreams(params); err != nil {
+		return TargetProfile{}, fmt.Errorf("could not parse target profile, error = %v", err)
+	}
+	if sp.options, err = newDatabaseOptions(params); err != nil {
+		return TargetProfile{}, fmt.Errorf("could not parse target profile, error = %v", err)
+	}
 
 	conn := TargetProfileConnection{ty: TargetProfileConnectionTypeSpanner, sp: sp}
 	return TargetProfile{ty: TargetProfileTypeConnection, conn: conn}, nil
 }
+
+// defaultChangeStreamName is the name of the change stream created by
+// the changestreams target profile key, unless changestream-name is set.
+const defaultChangeStreamName = "migration_changes"
+
+// newChangeStreams parses the change stream keys of a target profile. It
+// returns nil if no change stream is requested. Tables and options are only
+// checked against the schema once it has been converted.
+func newChangeStreams(params map[string]string) ([]ddl.ChangeStream, error) {
+	tables, ok := params["changestreams"]
+	if !ok {
+		for _, k := range []string{"changestream-name", "changestream-retention", "changestream-capture"} {
+			if _, ok := params[k]; ok {
+				return nil, fmt.Errorf("%s requires changestreams", k)
+			}
+		}
+		return nil, nil
+	}
+	cs := ddl.ChangeStream{
+		Name:             defaultChangeStreamName,
+		RetentionPeriod:  params["changestream-retention"],
+		ValueCaptureType: strings.ToUpper(params["changestream-capture"]),
+	}
+	if name, ok := params["changestream-name"]; ok {
+		cs.Name = name
+	}
+	if strings.ToLower(strings.TrimSpace(tables)) == "all" {
+		cs.All = true
+		return []ddl.ChangeStream{cs}, nil
+	}
+	for _, t := range strings.Split(tables, ",") {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			return nil, fmt.Errorf("could not parse changestreams = %v: empty table name", tables)
+		}
+		cs.Tables = append(cs.Tables, ddl.ChangeStreamTable{Table: t})
+	}
+	return []ddl.ChangeStream{cs}, nil
+}
+
+// ChangeStreams returns the change streams requested by the target
+// profile, or nil if there are none.
+func (trg TargetProfile) ChangeStreams() []ddl.ChangeStream {
+	if trg.ty != TargetProfileTypeConnection || trg.conn.ty != TargetProfileConnectionTypeSpanner {
+		return nil
+	}
+	return trg.conn.sp.streams
+}
+
+// newDatabaseOptions parses the database options of a target profile.
+func newDatabaseOptions(params map[string]string) (ddl.DatabaseOptions, error) {
+	opts := ddl.DatabaseOptions{
+		VersionRetentionPeriod: params["version_retention_period"],
+		DefaultLeader:          params["default_leader"],
+	}
+	if v, ok := params["optimizer_version"]; ok {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			return opts, fmt.Errorf("could not parse optimizer_version = %v: expected a positive integer", v)
+		}
+		opts.OptimizerVersion = n
+	}
+	return opts, opts.Check()
+}
+
+// DbOptions returns the database options of the target profile for
+// database dbName. Options are recorded with the database name, since
+// ALTER DATABASE statements need it.
+func (trg TargetProfile) DbOptions(dbName string) ddl.DatabaseOptions {
+	opts := trg.conn.sp.options
+	if !opts.Empty() {
+		opts.Database = dbName
+	}
+	return opts
+}
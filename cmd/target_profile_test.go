@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/cloudspannerecosystem/harbourbridge/spanner/ddl"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTargetProfile_ChangeStreams(t *testing.T) {
+	testCases := []struct {
+		name      string
+		profile   string
+		want      []ddl.ChangeStream
+		errorWant bool
+	}{
+		{
+			name:    "no change streams",
+			profile: "instance=my-instance",
+			want:    nil,
+		},
+		{
+			name:    "all tables",
+			profile: "instance=my-instance,changestreams=all",
+			want:    []ddl.ChangeStream{{Name: "migration_changes", All: true}},
+		},
+		{
+			name:    "tables and options",
+			profile: `"changestreams=users, orders",changestream-name=cdc,changestream-retention=7d,changestream-capture=new_row`,
+			want: []ddl.ChangeStream{{
+				Name:             "cdc",
+				Tables:           []ddl.ChangeStreamTable{{Table: "users"}, {Table: "orders"}},
+				RetentionPeriod:  "7d",
+				ValueCaptureType: ddl.NewRow,
+			}},
+		},
+		{
+			name:      "empty table",
+			profile:   `"changestreams=users,"`,
+			errorWant: true,
+		},
+		{
+			name:      "options without changestreams",
+			profile:   "changestream-retention=7d",
+			errorWant: true,
+		},
+	}
+	for _, tc := range testCases {
+		trg, err := NewTargetProfile(tc.profile)
+		if tc.errorWant {
+			assert.NotNil(t, err, tc.name)
+			continue
+		}
+		assert.Nil(t, err, tc.name)
+		assert.Equal(t, tc.want, trg.ChangeStreams(), tc.name)
+	}
+}
+
+func TestNewTargetProfile_DbOptions(t *testing.T) {
+	testCases := []struct {
+		name      string
+		profile   string
+		want      ddl.DatabaseOptions
+		errorWant bool
+	}{
+		{
+			name:    "no options",
+			profile: "instance=my-instance",
+			want:    ddl.DatabaseOptions{},
+		},
+		{
+			name:    "all options",
+			profile: "instance=my-instance,version_retention_period=3d,default_leader=us-east1,optimizer_version=4",
+			want:    ddl.DatabaseOptions{Database: "mydb", VersionRetentionPeriod: "3d", DefaultLeader: "us-east1", OptimizerVersion: 4},
+		},
+		{
+			name:      "retention period too long",
+			profile:   "version_retention_period=30d",
+			errorWant: true,
+		},
+		{
+			name:      "bad optimizer version",
+			profile:   "optimizer_version=latest",
+			errorWant: true,
+		},
+	}
+	for _, tc := range testCases {
+		trg, err := NewTargetProfile(tc.profile)
+		if tc.errorWant {
+			assert.NotNil(t, err, tc.name)
+			continue
+		}
+		assert.Nil(t, err, tc.name)
+		assert.Equal(t, tc.want, trg.DbOptions("mydb"), tc.name)
+	}
+}
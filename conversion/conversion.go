@@ -35,8 +35,8 @@ import (
 	"log"
 	"os"
 	"os/exec"
+	"sort"
 	"strings"
-	"sync"
 	"sync/atomic"
 	"syscall"
 	"time"
@@ -48,6 +48,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/session"
 	dydb "github.com/aws/aws-sdk-go/service/dynamodb"
 	_ "github.com/go-sql-driver/mysql"
+	"github.com/googleapis/gax-go/v2"
 	_ "github.com/lib/pq"
 	"golang.org/x/crypto/ssh/terminal"
 	"google.golang.org/api/iterator"
@@ -90,29 +91,132 @@ var (
 	MaxWorkers = 20
 )
 
-func SchemaConv(driver string, targetDb string, ioHelper *IOStreams, schemaSampleSize int64) (*internal.Conv, error) {
+// SchemaOptions configures schema conversion. Options are recorded in
+// the conv returned by SchemaConv, so that they also apply to subsequent
+// data conversion.
+type SchemaOptions struct {
+	Filters       internal.Filters                 // Restricts conversion to a subset of source tables and columns.
+	NamingPolicy  internal.NamingPolicy            // Transforms source names into Spanner names.
+	TypeOverrides internal.TypeOverrides           // Changes the Spanner type of source columns.
+	HotspotRemedy string                           // Remedy applied to primary keys that cause hotspots (see internal.MarkHotspot).
+	SyntheticPKey string                           // Kind of synthetic primary keys added to tables without one.
+	RowDeletion   map[string]ddl.RowDeletionPolicy // Row deletion policies, keyed by source table (Col is a source column).
+	ChangeStreams []ddl.ChangeStream               // Change streams created after data migration (tables and columns are Spanner names).
+	DbOptions     ddl.DatabaseOptions              // Database options set when the database is created.
+}
+
+func (opts SchemaOptions) apply(conv *internal.Conv) {
+	conv.Filters = opts.Filters
+	conv.NamingPolicy = opts.NamingPolicy
+	conv.TypeOverrides = opts.TypeOverrides
+	conv.HotspotRemedy = opts.HotspotRemedy
+	conv.SyntheticPKey = opts.SyntheticPKey
+}
+
+// SchemaConv performs schema conversion for driver, configured by opts.
+func SchemaConv(driver string, targetDb string, ioHelper *IOStreams, schemaSampleSize int64, opts SchemaOptions) (*internal.Conv, error) {
+	if err := validateTypeOverrides(driver, opts.TypeOverrides); err != nil {
+		return nil, err
+	}
+	var conv *internal.Conv
+	var err error
 	switch driver {
 	case POSTGRES, MYSQL:
-		return schemaFromSQL(driver, targetDb)
+		conv, err = schemaFromSQL(driver, targetDb, opts)
 	case PGDUMP, MYSQLDUMP:
-		return schemaFromDump(driver, targetDb, ioHelper)
+		conv, err = schemaFromDump(driver, targetDb, ioHelper, opts)
 	case DYNAMODB:
-		return schemaFromDynamoDB(schemaSampleSize)
+		conv, err = schemaFromDynamoDB(schemaSampleSize, opts)
 	default:
 		return nil, fmt.Errorf("schema conversion for driver %s not supported", driver)
 	}
+	if err != nil {
+		return nil, err
+	}
+	if err := conv.SetRowDeletionPolicies(opts.RowDeletion); err != nil {
+		return nil, err
+	}
+	if err := conv.SetChangeStreams(opts.ChangeStreams); err != nil {
+		return nil, err
+	}
+	if err := opts.DbOptions.Check(); err != nil {
+		return nil, err
+	}
+	conv.DbOptions = opts.DbOptions
+	// Limit violations are recorded in conv for the report. Whether they
+	// block database creation is up to the caller.
+	conv.CheckLimits()
+	return conv, nil
 }
 
-func DataConv(driver string, ioHelper *IOStreams, client *sp.Client, conv *internal.Conv, dataOnly bool) (*spanner.BatchWriter, error) {
+// validateTypeOverrides checks that global and per-table type overrides
+// are allowed mappings for driver's source types. Per-column overrides
+// depend on the source schema, and are checked during schema conversion.
+func validateTypeOverrides(driver string, to internal.TypeOverrides) error {
+	if to.Empty() {
+		return nil
+	}
+	var toSpannerType func(srcType string, spType string, mods []int64) (ddl.Type, []internal.SchemaIssue)
+	switch driver {
+	case POSTGRES, PGDUMP:
+		toSpannerType = postgres.ToSpannerTypeWithTarget
+	case MYSQL, MYSQLDUMP:
+		toSpannerType = mysql.ToSpannerTypeWithTarget
+	default:
+		return fmt.Errorf("type overrides are not supported for driver %s", driver)
+	}
+	check := func(srcType, spType string) error {
+		// Only tinyint(1) can be mapped to BOOL, but we accept BOOL for
+		// all tinyint columns (like the web UI does).
+		if driver == MYSQL || driver == MYSQLDUMP {
+			if srcType == "tinyint" && spType == ddl.Bool {
+				return nil
+			}
+		}
+		if ty, _ := toSpannerType(srcType, spType, nil); ty.Name != spType {
+			return fmt.Errorf("invalid type override: source type %s can't be mapped to Spanner type %s", srcType, spType)
+		}
+		return nil
+	}
+	for srcType, spType := range to.Global {
+		if err := check(srcType, spType); err != nil {
+			return err
+		}
+	}
+	for _, m := range to.Tables {
+		for srcType, spType := range m {
+			if err := check(srcType, spType); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// DataOptions configures data conversion.
+type DataOptions struct {
+	Readers          int   // Number of source tables (or key ranges of tables) read concurrently (direct access to source database only). Zero means 1.
+	MaxRowsPerSecond int64 // Limit on the rate at which rows are written to Spanner. Zero means no limit.
+	ReadPageSize     int64 // Number of rows read by each query of a source table with a primary key (direct access to source database only). Zero means tables are read with a single query.
+	Snapshot         bool  // If true, source data is read from a consistent snapshot (direct access to source database only).
+}
+
+// DataConv performs data conversion for driver, configured by opts.
+func DataConv(driver string, ioHelper *IOStreams, client *sp.Client, conv *internal.Conv, dataOnly bool, opts DataOptions) (*spanner.BatchWriter, error) {
 	config := spanner.BatchWriterConfig{
-		BytesLimit: 100 * 1000 * 1000,
-		WriteLimit: 40,
-		RetryLimit: 1000,
-		Verbose:    internal.Verbose(),
+		BytesLimit:       100 * 1000 * 1000,
+		WriteLimit:       40,
+		RetryLimit:       1000,
+		Verbose:          internal.Verbose(),
+		MaxRowsPerSecond: opts.MaxRowsPerSecond,
 	}
+	if conv.WriteMode() == "" {
+		conv.SetWriteMode(spanner.WriteModeInsert)
+	}
+	config.WriteMode = conv.WriteMode()
 	switch driver {
 	case POSTGRES, MYSQL:
-		return dataFromSQL(driver, config, client, conv)
+		return dataFromSQL(driver, config, client, conv, common.ReadOptions{Readers: opts.Readers, PageSize: opts.ReadPageSize, Snapshot: opts.Snapshot})
 	case PGDUMP, MYSQLDUMP:
 		if conv.SpSchema.CheckInterleaved() {
 			return nil, fmt.Errorf("HarbourBridge does not currently support data conversion from dump files\nif the schema contains interleaved tables. Suggest using direct access to source database\ni.e. using drivers postgres and mysql.")
@@ -168,7 +272,7 @@ func mysqlDriverConfig() (string, error) {
 	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s", user, password, server, port, dbname), nil
 }
 
-func schemaFromSQL(driver string, targetDb string) (*internal.Conv, error) {
+func schemaFromSQL(driver string, targetDb string, opts SchemaOptions) (*internal.Conv, error) {
 	driverConfig, err := driverConfig(driver)
 	if err != nil {
 		return nil, err
@@ -179,6 +283,7 @@ func schemaFromSQL(driver string, targetDb string) (*internal.Conv, error) {
 	}
 	conv := internal.MakeConv()
 	conv.TargetDb = targetDb
+	opts.apply(conv)
 	err = ProcessInfoSchema(driver, conv, sourceDB)
 	if err != nil {
 		return nil, err
@@ -186,12 +291,12 @@ func schemaFromSQL(driver string, targetDb string) (*internal.Conv, error) {
 	return conv, nil
 }
 
-func dataFromSQL(driver string, config spanner.BatchWriterConfig, client *sp.Client, conv *internal.Conv) (*spanner.BatchWriter, error) {
+func dataFromSQL(driver string, config spanner.BatchWriterConfig, client *sp.Client, conv *internal.Conv, readOpts common.ReadOptions) (*spanner.BatchWriter, error) {
 	// TODO: Refactor to avoid redundant calls to driverConfig and
 	// Open in schemaFromSQL and dataFromSQL. Also refactor to
-	// share code with dataFromPgDump. Use single transaction for
-	// reading schema and data from source db to get consistent
-	// dump.
+	// share code with dataFromPgDump. Data is read from a consistent
+	// snapshot if readOpts.Snapshot is set, but the schema is read
+	// separately (by schemaFromSQL).
 	driverConfig, err := driverConfig(driver)
 	if err != nil {
 		return nil, err
@@ -218,11 +323,8 @@ func dataFromSQL(driver string, config spanner.BatchWriterConfig, client *sp.Cli
 	}
 	writer := spanner.NewBatchWriter(config)
 	conv.SetDataMode()
-	conv.SetDataSink(
-		func(table string, cols []string, vals []interface{}) {
-			writer.AddRow(table, cols, vals)
-		})
-	err = ProcessSQLData(driver, conv, sourceDB)
+	setDataSink(conv, writer)
+	err = ProcessSQLData(driver, conv, sourceDB, readOpts)
 	if err != nil {
 		return nil, err
 	}
@@ -230,6 +332,56 @@ func dataFromSQL(driver string, config spanner.BatchWriterConfig, client *sp.Cli
 	return writer, nil
 }
 
+// setDataSink configures conv to write rows to writer. If conv tracks data
+// migration progress, rows are added to conv's checkpoint as they are
+// written (see internal.Conv.WriteRow).
+func setDataSink(conv *internal.Conv, writer *spanner.BatchWriter) {
+	conv.SetDataSink(writer.AddRow)
+	conv.SetAckDataSink(writer.AddRowWithAck)
+}
+
+// RereadTables returns the Spanner tables that a resumed data migration
+// from a direct connection to the source database reads again from the
+// start: the tables with a synthetic primary key that weren't complete.
+// Their rows can't be matched with the rows already written, which have
+// different synthetic keys, so these tables must be emptied first (see
+// TruncateTables).
+func RereadTables(conv *internal.Conv) []string {
+	var l []string
+	for spTable := range conv.SyntheticPKeys {
+		src, ok := conv.ToSource[spTable]
+		if !ok || !conv.IncludeTable(src.Name) {
+			continue
+		}
+		if !conv.Checkpoint().TableComplete(src.Name, "") {
+			l = append(l, spTable)
+		}
+	}
+	sort.Strings(l)
+	return l
+}
+
+// TruncateTables deletes all rows of the Spanner tables, using partitioned
+// DML so that the size of the tables doesn't matter.
+func TruncateTables(ctx context.Context, client *sp.Client, conv *internal.Conv, tables []string, out *os.File) error {
+	for _, t := range tables {
+		fmt.Fprintf(out, "Deleting rows of table %s, which is migrated again from the start (it has no primary key)\n", t)
+		if _, err := client.PartitionedUpdate(ctx, sp.Statement{SQL: truncateStatement(conv, t)}); err != nil {
+			return fmt.Errorf("can't delete rows of table %s: %v", t, err)
+		}
+	}
+	return nil
+}
+
+// truncateStatement returns the DML statement that deletes all rows of
+// table, in the dialect of conv's target database.
+func truncateStatement(conv *internal.Conv, table string) string {
+	if Dialect(conv) == ddl.PostgreSQL {
+		return fmt.Sprintf(`DELETE FROM "%s" WHERE true`, table)
+	}
+	return fmt.Sprintf("DELETE FROM `%s` WHERE true", table)
+}
+
 func getDynamoDBClientConfig() *aws.Config {
 	cfg := aws.Config{}
 	endpointOverride := os.Getenv("DYNAMODB_ENDPOINT_OVERRIDE")
@@ -239,8 +391,9 @@ func getDynamoDBClientConfig() *aws.Config {
 	return &cfg
 }
 
-func schemaFromDynamoDB(sampleSize int64) (*internal.Conv, error) {
+func schemaFromDynamoDB(sampleSize int64, opts SchemaOptions) (*internal.Conv, error) {
 	conv := internal.MakeConv()
+	opts.apply(conv)
 	mySession := session.Must(session.NewSession())
 	dydbClient := dydb.New(mySession, getDynamoDBClientConfig())
 	err := dynamodb.ProcessSchema(conv, dydbClient, []string{}, sampleSize)
@@ -269,10 +422,7 @@ func dataFromDynamoDB(config spanner.BatchWriterConfig, client *sp.Client, conv
 	}
 	writer := spanner.NewBatchWriter(config)
 	conv.SetDataMode()
-	conv.SetDataSink(
-		func(table string, cols []string, vals []interface{}) {
-			writer.AddRow(table, cols, vals)
-		})
+	setDataSink(conv, writer)
 
 	err := dynamodb.ProcessData(conv, dydbClient)
 	if err != nil {
@@ -304,7 +454,7 @@ func NewIOStreams(driver string, dumpFile string) IOStreams {
 	return io
 }
 
-func schemaFromDump(driver string, targetDb string, ioHelper *IOStreams) (*internal.Conv, error) {
+func schemaFromDump(driver string, targetDb string, ioHelper *IOStreams, opts SchemaOptions) (*internal.Conv, error) {
 	f, n, err := getSeekable(ioHelper.In)
 	if err != nil {
 		printSeekError(driver, err, ioHelper.Out)
@@ -314,6 +464,7 @@ func schemaFromDump(driver string, targetDb string, ioHelper *IOStreams) (*inter
 	ioHelper.BytesRead = n
 	conv := internal.MakeConv()
 	conv.TargetDb = targetDb
+	opts.apply(conv)
 	p := internal.NewProgress(n, "Generating schema", internal.Verbose(), false)
 	r := internal.NewReader(bufio.NewReader(f), p)
 	conv.SetSchemaMode() // Build schema and ignore data in dump.
@@ -363,10 +514,7 @@ func dataFromDump(driver string, config spanner.BatchWriterConfig, ioHelper *IOS
 	}
 	writer := spanner.NewBatchWriter(config)
 	conv.SetDataMode() // Process data in dump; schema is unchanged.
-	conv.SetDataSink(
-		func(table string, cols []string, vals []interface{}) {
-			writer.AddRow(table, cols, vals)
-		})
+	setDataSink(conv, writer)
 	ProcessDump(driver, conv, r)
 	writer.Flush()
 	p.Done()
@@ -374,6 +522,41 @@ func dataFromDump(driver string, config spanner.BatchWriterConfig, ioHelper *IOS
 	return writer, nil
 }
 
+// ReplayBinlog replays changes made to a MySQL database after its data was
+// migrated, reading them from ioHelper.In (the output of mysqlbinlog
+// --verbose) from position start. Changes are written to Spanner in the
+// order they were made, and only tables of database dbName (or of any
+// database, if dbName is empty) are replayed. It returns the position from
+// which later changes can be replayed, even if replay fails part way.
+func ReplayBinlog(ioHelper *IOStreams, client *sp.Client, conv *internal.Conv, dbName string, start internal.SourcePosition, opts DataOptions) (*spanner.BatchWriter, internal.SourcePosition, error) {
+	config := spanner.BatchWriterConfig{
+		BytesLimit: 100 * 1000 * 1000,
+		// Changes to a row must be applied in order, so we write one
+		// batch at a time.
+		WriteLimit: 1,
+		RetryLimit: 1000,
+		Verbose:    internal.Verbose(),
+		// Inserts and updates both carry complete rows.
+		WriteMode:        spanner.WriteModeInsertOrUpdate,
+		MaxRowsPerSecond: opts.MaxRowsPerSecond,
+		Write: func(m []*sp.Mutation) error {
+			_, err := client.Apply(context.Background(), m)
+			return err
+		},
+	}
+	writer := spanner.NewBatchWriter(config)
+	conv.SetDataMode()
+	// Row stats count the changes replayed, rather than the rows migrated.
+	conv.Stats.Rows = make(map[string]int64)
+	conv.Stats.GoodRows = make(map[string]int64)
+	conv.Stats.BadRows = make(map[string]int64)
+	conv.SetDataSink(writer.AddRow)
+	conv.SetDeleteSink(writer.DeleteRow)
+	pos, err := mysql.ReplayBinlog(conv, ioHelper.In, dbName, start)
+	writer.Flush()
+	return writer, pos, err
+}
+
 // Report generates a report of schema and data conversion.
 func Report(driver string, badWrites map[string]int64, BytesRead int64, banner string, conv *internal.Conv, reportFileName string, out *os.File) {
 	f, err := os.Create(reportFileName)
@@ -499,6 +682,8 @@ func CreateOrUpdateDatabase(ctx context.Context, adminClient *database.DatabaseA
 // It automatically determines an appropriate project, selects a
 // Spanner instance to use, generates a new Spanner DB name,
 // and call into the Spanner admin interface to create the new DB.
+// For the experimental PostgreSQL target, a PostgreSQL-dialect database
+// is created.
 func CreateDatabase(ctx context.Context, adminClient *database.DatabaseAdminClient, dbURI string, conv *internal.Conv, out *os.File) error {
 	project, instance, dbName := parseDbURI(dbURI)
 	fmt.Fprintf(out, "Creating new database %s in instance %s with default permissions ... \n", dbName, instance)
@@ -506,31 +691,71 @@ func CreateDatabase(ctx context.Context, adminClient *database.DatabaseAdminClie
 	// Spanner DDL doesn't accept them), and protects table and col names
 	// using backticks (to avoid any issues with Spanner reserved words).
 	// Foreign Keys are set to false since we create them post data migration.
-	schema := conv.SpSchema.GetDDL(ddl.Config{Comments: false, ProtectIds: true, Tables: true, ForeignKeys: false})
-	op, err := adminClient.CreateDatabase(ctx, &adminpb.CreateDatabaseRequest{
+	// Secondary indexes are also built post data migration if conv defers them.
+	schema := append(dbOptionsDDL(conv, dbName), conv.SpSchema.GetDDL(ddl.Config{Comments: false, ProtectIds: true, Tables: true, ForeignKeys: false, SkipIndexes: conv.DeferIndexes(), Dialect: Dialect(conv)})...)
+	req := &adminpb.CreateDatabaseRequest{
 		Parent:          fmt.Sprintf("projects/%s/instances/%s", project, instance),
 		CreateStatement: "CREATE DATABASE `" + dbName + "`",
 		ExtraStatements: schema,
-	})
+	}
+	if Dialect(conv) == ddl.PostgreSQL {
+		// PostgreSQL-dialect databases can't be created with extra
+		// statements, so the schema is added once the database exists.
+		req.CreateStatement = `CREATE DATABASE "` + dbName + `"`
+		req.DatabaseDialect = adminpb.DatabaseDialect_POSTGRESQL
+		req.ExtraStatements = nil
+	}
+	op, err := adminClient.CreateDatabase(ctx, req)
 	if err != nil {
 		return fmt.Errorf("can't build CreateDatabaseRequest: %w", AnalyzeError(err, dbURI))
 	}
 	if _, err := op.Wait(ctx); err != nil {
 		return fmt.Errorf("createDatabase call failed: %w", AnalyzeError(err, dbURI))
 	}
+	if req.DatabaseDialect == adminpb.DatabaseDialect_POSTGRESQL && len(schema) > 0 {
+		op, err := adminClient.UpdateDatabaseDdl(ctx, &adminpb.UpdateDatabaseDdlRequest{
+			Database:   dbURI,
+			Statements: schema,
+		})
+		if err != nil {
+			return fmt.Errorf("can't build UpdateDatabaseDdlRequest: %w", AnalyzeError(err, dbURI))
+		}
+		if err := op.Wait(ctx); err != nil {
+			return fmt.Errorf("UpdateDatabaseDdl call failed: %w", AnalyzeError(err, dbURI))
+		}
+	}
 	fmt.Fprintf(out, "Created database successfully.\n")
 	return nil
 }
 
+// dbOptionsDDL returns the statements that set conv's database options on
+// database dbName (which takes precedence over the database name recorded
+// in the options).
+func dbOptionsDDL(conv *internal.Conv, dbName string) []string {
+	opts := conv.DbOptions
+	opts.Database = dbName
+	return opts.PrintAlterDatabase(ddl.Config{ProtectIds: true, Dialect: Dialect(conv)})
+}
+
+// Dialect returns the Spanner SQL dialect of conv's target database.
+func Dialect(conv *internal.Conv) string {
+	if conv.TargetDb == TARGET_EXPERIMENTAL_POSTGRES {
+		return ddl.PostgreSQL
+	}
+	return ddl.GoogleSQL
+}
+
 func UpdateDatabase(ctx context.Context, adminClient *database.DatabaseAdminClient, dbURI string, conv *internal.Conv, out *os.File) error {
 	fmt.Fprintf(out, "Updating schema for %s with default permissions ... \n", dbURI)
 	// The schema we send to Spanner excludes comments (since Cloud
 	// Spanner DDL doesn't accept them), and protects table and col names
 	// using backticks (to avoid any issues with Spanner reserved words).
 	// Foreign Keys are set to false since we create them post data migration.
+	// Secondary indexes are also built post data migration if conv defers them.
+	_, _, dbName := parseDbURI(dbURI)
 	op, err := adminClient.UpdateDatabaseDdl(ctx, &adminpb.UpdateDatabaseDdlRequest{
 		Database:   dbURI,
-		Statements: conv.SpSchema.GetDDL(ddl.Config{Comments: false, ProtectIds: true, Tables: true, ForeignKeys: false}),
+		Statements: append(dbOptionsDDL(conv, dbName), conv.SpSchema.GetDDL(ddl.Config{Comments: false, ProtectIds: true, Tables: true, ForeignKeys: false, SkipIndexes: conv.DeferIndexes(), Dialect: Dialect(conv)})...),
 	})
 	if err != nil {
 		return fmt.Errorf("can't build UpdateDatabaseDdlRequest: %w", AnalyzeError(err, dbURI))
@@ -542,6 +767,30 @@ func UpdateDatabase(ctx context.Context, adminClient *database.DatabaseAdminClie
 	return nil
 }
 
+// DdlReader reads the DDL statements of Spanner databases. It is
+// implemented by *database.DatabaseAdminClient.
+type DdlReader interface {
+	GetDatabaseDdl(ctx context.Context, req *adminpb.GetDatabaseDdlRequest, opts ...gax.CallOption) (*adminpb.GetDatabaseDdlResponse, error)
+}
+
+// DiffDatabase compares conv's Spanner schema with the schema of the
+// existing database dbURI, and returns the differences along with the DDL
+// statements needed to update the database.
+func DiffDatabase(ctx context.Context, adminClient DdlReader, dbURI string, conv *internal.Conv) (ddl.SchemaDiff, error) {
+	if Dialect(conv) != ddl.GoogleSQL {
+		return ddl.SchemaDiff{}, fmt.Errorf("diff only supports GoogleSQL databases")
+	}
+	dbDdl, err := adminClient.GetDatabaseDdl(ctx, &adminpb.GetDatabaseDdlRequest{Database: dbURI})
+	if err != nil {
+		return ddl.SchemaDiff{}, fmt.Errorf("can't fetch database ddl: %w", AnalyzeError(err, dbURI))
+	}
+	existing, err := ddl.ParseDatabaseDDL(strings.Join(dbDdl.Statements, ";\n"))
+	if err != nil {
+		return ddl.SchemaDiff{}, fmt.Errorf("can't parse ddl of database %s: %v", dbURI, err)
+	}
+	return conv.SpSchema.Diff(existing), nil
+}
+
 // parseURI parses an unknown URI string that could be a database, instance or project URI.
 func parseURI(URI string) (project, instance, dbName string) {
 	project, instance, dbName = "", "", ""
@@ -575,13 +824,25 @@ func parseProjectURI(projectURI string) (project string) {
 	return
 }
 
+// ForeignKeyOptions configures how UpdateDDLForeignKeys adds foreign keys.
+type ForeignKeyOptions struct {
+	BatchSize  int    // Maximum number of foreign keys added by each UpdateDatabaseDdl request. 0 means 1.
+	FailedFile string // File the statements of foreign keys that couldn't be added are written to. Empty means none.
+}
+
 // UpdateDDLForeignKeys updates the Spanner database with foreign key
-// constraints using ALTER TABLE statements.
-func UpdateDDLForeignKeys(ctx context.Context, adminClient *database.DatabaseAdminClient, dbURI string, conv *internal.Conv, out *os.File) error {
+// constraints using ALTER TABLE statements. Transient errors are retried
+// with backoff, and foreign keys that still can't be added are skipped, and
+// written to opts.FailedFile so that they can be applied later.
+func UpdateDDLForeignKeys(ctx context.Context, adminClient *database.DatabaseAdminClient, dbURI string, conv *internal.Conv, opts ForeignKeyOptions, out *os.File) error {
+	return updateForeignKeys(ctx, NewDdlUpdater(adminClient), dbURI, conv, opts, out)
+}
+
+func updateForeignKeys(ctx context.Context, u DdlUpdater, dbURI string, conv *internal.Conv, opts ForeignKeyOptions, out *os.File) error {
 	// The schema we send to Spanner excludes comments (since Cloud
 	// Spanner DDL doesn't accept them), and protects table and col names
 	// using backticks (to avoid any issues with Spanner reserved words).
-	fkStmts := conv.SpSchema.GetDDL(ddl.Config{Comments: false, ProtectIds: true, Tables: false, ForeignKeys: true})
+	fkStmts := conv.SpSchema.GetDDL(ddl.Config{Comments: false, ProtectIds: true, Tables: false, ForeignKeys: true, Dialect: Dialect(conv)})
 	if len(fkStmts) == 0 {
 		return nil
 	}
@@ -597,52 +858,70 @@ Recommended value is between 20-30.`)
 	}
 	msg := fmt.Sprintf("Updating schema of database %s with foreign key constraints ...", dbURI)
 	p := internal.NewProgress(int64(len(fkStmts)), msg, internal.Verbose(), true)
+	failed := updateDdlInParallel(ctx, u, dbURI, conv, fkStmts, opts.BatchSize, "foreign key", p)
+	p.Done()
+	if len(failed) > 0 && opts.FailedFile != "" {
+		if err := writeFailedDdl(failed, opts.FailedFile); err != nil {
+			return fmt.Errorf("can't write foreign keys that couldn't be added: %v", err)
+		}
+		fmt.Fprintf(out, "Couldn't add %d of %d foreign keys. Wrote their statements to file '%s'.\n", len(failed), len(fkStmts), opts.FailedFile)
+	}
+	return nil
+}
 
-	workers := make(chan int, MaxWorkers)
-	for i := 1; i <= MaxWorkers; i++ {
-		workers <- i
-	}
-	var progressMutex sync.Mutex
-	progress := int64(0)
-
-	// We dispatch parallel foreign key create requests to ensure the backfill runs in parallel to reduce overall time.
-	// This cuts down the time taken to a third (approx) compared to Serial and Batched creation. We also do not want to create
-	// too many requests and get throttled due to network or hitting catalog memory limits.
-	// Ensure atmost `MaxWorkers` go routines run in parallel that each update the ddl with one foreign key statement.
-	for _, fkStmt := range fkStmts {
-		workerId := <-workers
-		go func(fkStmt string, workerId int) {
-			defer func() {
-				// Locking the progress reporting otherwise progress results displayed could be in random order.
-				progressMutex.Lock()
-				progress++
-				p.MaybeReport(progress)
-				progressMutex.Unlock()
-				workers <- workerId
-			}()
-			internal.VerbosePrintf("Submitting new FK create request: %s\n", fkStmt)
-			op, err := adminClient.UpdateDatabaseDdl(ctx, &adminpb.UpdateDatabaseDdlRequest{
-				Database:   dbURI,
-				Statements: []string{fkStmt},
-			})
-			if err != nil {
-				fmt.Printf("Cannot submit request for create foreign key with statement: %s\n due to error: %s. Skipping this foreign key...\n", fkStmt, err)
-				conv.Unexpected(fmt.Sprintf("Can't add foreign key with statement %s: %s", fkStmt, err))
-				return
-			}
-			if err := op.Wait(ctx); err != nil {
-				fmt.Printf("Can't add foreign key with statement: %s\n due to error: %s. Skipping this foreign key...\n", fkStmt, err)
-				conv.Unexpected(fmt.Sprintf("Can't add foreign key with statement %s: %s", fkStmt, err))
-				return
-			}
-			internal.VerbosePrintln("Updated schema with statement: " + fkStmt)
-		}(fkStmt, workerId)
+// writeFailedDdl writes DDL statements that couldn't be applied to file
+// name, in a form that can be applied later e.g. using gcloud.
+func writeFailedDdl(stmts []string, name string) error {
+	return ioutil.WriteFile(name, []byte(strings.Join(stmts, ";\n\n")+"\n"), 0644)
+}
+
+// CreateIndexes builds the secondary indexes of conv's Spanner schema, if
+// conv defers index creation (see internal.Conv.SetDeferIndexes). It is
+// called once data has been migrated. Indexes are built in parallel, and an
+// index that can't be built is reported and skipped.
+func CreateIndexes(ctx context.Context, adminClient *database.DatabaseAdminClient, dbURI string, conv *internal.Conv, out *os.File) error {
+	if !conv.DeferIndexes() {
+		return nil
 	}
-	// Wait for all the goroutines to finish.
-	for i := 1; i <= MaxWorkers; i++ {
-		<-workers
+	indexStmts := conv.SpSchema.GetIndexDDL(ddl.Config{Comments: false, ProtectIds: true, Dialect: Dialect(conv)})
+	if len(indexStmts) == 0 {
+		return nil
 	}
+	msg := fmt.Sprintf("Building secondary indexes of database %s ...", dbURI)
+	p := internal.NewProgress(int64(len(indexStmts)), msg, internal.Verbose(), true)
+	failed := updateDdlInParallel(ctx, NewDdlUpdater(adminClient), dbURI, conv, indexStmts, 1, "index", p)
 	p.Done()
+	if len(failed) > 0 {
+		fmt.Fprintf(out, "Couldn't build %d of %d secondary indexes. The statements for the missing indexes are:\n%s\n", len(failed), len(indexStmts), strings.Join(failed, ";\n"))
+	}
+	return nil
+}
+
+// CreateChangeStreams creates conv's change streams in the Spanner
+// database. It is called once data has been migrated, so that writing the
+// existing data doesn't flood the change streams.
+func CreateChangeStreams(ctx context.Context, adminClient *database.DatabaseAdminClient, dbURI string, conv *internal.Conv, out *os.File) error {
+	if len(conv.ChangeStreams) == 0 {
+		return nil
+	}
+	// The schema may have been replaced since the change streams were
+	// set (e.g. by a schema file), so we check them again.
+	if err := conv.SpSchema.CheckChangeStreams(conv.ChangeStreams); err != nil {
+		return err
+	}
+	stmts := ddl.GetChangeStreamDDL(conv.ChangeStreams, ddl.Config{ProtectIds: true, Dialect: Dialect(conv)})
+	fmt.Fprintf(out, "Creating change streams for database %s ...\n", dbURI)
+	op, err := adminClient.UpdateDatabaseDdl(ctx, &adminpb.UpdateDatabaseDdlRequest{
+		Database:   dbURI,
+		Statements: stmts,
+	})
+	if err != nil {
+		return fmt.Errorf("can't build UpdateDatabaseDdlRequest: %w", AnalyzeError(err, dbURI))
+	}
+	if err := op.Wait(ctx); err != nil {
+		return fmt.Errorf("UpdateDatabaseDdl call failed: %w", AnalyzeError(err, dbURI))
+	}
+	fmt.Fprintf(out, "Created %d change stream(s).\n", len(stmts))
 	return nil
 }
 
@@ -713,7 +992,8 @@ func getInstances(ctx context.Context, project string) ([]string, error) {
 }
 
 // WriteSchemaFile writes DDL statements in a file. It includes CREATE TABLE
-// statements and ALTER TABLE statements to add foreign keys.
+// statements, ALTER TABLE statements to add foreign keys, CREATE CHANGE
+// STREAM statements and an ALTER DATABASE statement to set database options.
 // The parameter name should end with a .txt.
 func WriteSchemaFile(conv *internal.Conv, now time.Time, name string, out *os.File) {
 	f, err := os.Create(name)
@@ -726,7 +1006,9 @@ func WriteSchemaFile(conv *internal.Conv, now time.Time, name string, out *os.Fi
 	// and doesn't add backticks around table and column names. This file is
 	// intended for explanatory and documentation purposes, and is not strictly
 	// legal Cloud Spanner DDL (Cloud Spanner doesn't currently support comments).
-	spDDL := conv.SpSchema.GetDDL(ddl.Config{Comments: true, ProtectIds: false, Tables: true, ForeignKeys: true})
+	spDDL := conv.SpSchema.GetDDL(ddl.Config{Comments: true, ProtectIds: false, Tables: true, ForeignKeys: true, Dialect: Dialect(conv)})
+	spDDL = append(spDDL, ddl.GetChangeStreamDDL(conv.ChangeStreams, ddl.Config{Dialect: Dialect(conv)})...)
+	spDDL = append(spDDL, conv.DbOptions.PrintAlterDatabase(ddl.Config{Dialect: Dialect(conv)})...)
 	if len(spDDL) == 0 {
 		spDDL = []string{"\n-- Schema is empty -- no tables found\n"}
 	}
@@ -753,7 +1035,9 @@ func WriteSchemaFile(conv *internal.Conv, now time.Time, name string, out *os.Fi
 
 	// We change 'Comments' to false and 'ProtectIds' to true below to write out a
 	// schema file that is a legal Cloud Spanner DDL.
-	spDDL = conv.SpSchema.GetDDL(ddl.Config{Comments: false, ProtectIds: true, Tables: true, ForeignKeys: true})
+	spDDL = conv.SpSchema.GetDDL(ddl.Config{Comments: false, ProtectIds: true, Tables: true, ForeignKeys: true, Dialect: Dialect(conv)})
+	spDDL = append(spDDL, ddl.GetChangeStreamDDL(conv.ChangeStreams, ddl.Config{ProtectIds: true, Dialect: Dialect(conv)})...)
+	spDDL = append(spDDL, conv.DbOptions.PrintAlterDatabase(ddl.Config{ProtectIds: true, Dialect: Dialect(conv)})...)
 	if len(spDDL) == 0 {
 		spDDL = []string{"\n-- Schema is empty -- no tables found\n"}
 	}
@@ -768,6 +1052,33 @@ func WriteSchemaFile(conv *internal.Conv, now time.Time, name string, out *os.Fi
 	fmt.Fprintf(out, "Wrote legal schema ddl to file '%s'.\n", name)
 }
 
+// WriteDiffFile writes the differences between the generated schema and an
+// existing database as comments, followed by the DDL statements that update
+// the database.
+func WriteDiffFile(d ddl.SchemaDiff, now time.Time, dbURI, name string, out *os.File) {
+	f, err := os.Create(name)
+	if err != nil {
+		fmt.Fprintf(out, "Can't create diff file %s: %v\n", name, err)
+		return
+	}
+	defer f.Close()
+	l := []string{fmt.Sprintf("-- Schema diff against %s generated %s\n", dbURI, now.Format("2006-01-02 15:04:05"))}
+	if d.Empty() {
+		l = append(l, "-- Database schema matches the generated schema.\n")
+	}
+	for _, s := range d.Differences {
+		l = append(l, "-- "+s+"\n")
+	}
+	if len(d.Statements) > 0 {
+		l = append(l, "\n", strings.Join(d.Statements, ";\n\n"), ";\n")
+	}
+	if _, err := f.WriteString(strings.Join(l, "")); err != nil {
+		fmt.Fprintf(out, "Can't write out diff file: %v\n", err)
+		return
+	}
+	fmt.Fprintf(out, "Wrote schema diff to file '%s'.\n", name)
+}
+
 // WriteSessionFile writes conv struct to a file in JSON format.
 func WriteSessionFile(conv *internal.Conv, name string, out *os.File) {
 	f, err := os.Create(name)
@@ -822,6 +1133,39 @@ func ReadSessionFile(conv *internal.Conv, sessionJSON string) error {
 	return nil
 }
 
+// ReadSchemaFile reads a Spanner DDL file, typically a hand-edited version
+// of the schema.ddl.txt file generated by schema conversion, and uses it
+// as conv's Spanner schema. Change streams and database options in the file
+// replace conv's.
+func ReadSchemaFile(conv *internal.Conv, name string) error {
+	if Dialect(conv) != ddl.GoogleSQL {
+		return fmt.Errorf("schema files are only supported for GoogleSQL databases")
+	}
+	s, err := ioutil.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	d, err := ddl.ParseAllDDL(string(s))
+	if err != nil {
+		return fmt.Errorf("can't parse schema file %s: %v", name, err)
+	}
+	if err := d.Schema.CheckRowDeletionPolicies(); err != nil {
+		return fmt.Errorf("can't use schema file %s: %v", name, err)
+	}
+	if err := d.Schema.CheckChangeStreams(d.ChangeStreams); err != nil {
+		return fmt.Errorf("can't use schema file %s: %v", name, err)
+	}
+	if err := d.DbOptions.Check(); err != nil {
+		return fmt.Errorf("can't use schema file %s: %v", name, err)
+	}
+	if err := conv.ReplaceSpSchema(d.Schema); err != nil {
+		return fmt.Errorf("can't use schema file %s: %v", name, err)
+	}
+	conv.ChangeStreams = d.ChangeStreams
+	conv.DbOptions = d.DbOptions
+	return nil
+}
+
 // WriteBadData prints summary stats about bad rows and writes detailed info
 // to file 'name'.
 func WriteBadData(bw *spanner.BatchWriter, conv *internal.Conv, banner, name string, out *os.File) {
@@ -1072,15 +1416,15 @@ func SetRowStats(driver string, conv *internal.Conv, db *sql.DB) error {
 }
 
 // ProcessSQLData invokes ProcessSQLData function from a sql package based on driver selected.
-func ProcessSQLData(driver string, conv *internal.Conv, db *sql.DB) error {
+// Tables are read as configured by opts.
+func ProcessSQLData(driver string, conv *internal.Conv, db *sql.DB, opts common.ReadOptions) error {
 	switch driver {
 	//TODO - move this logic into a factory within the sources dir
 	case MYSQL:
-		common.ProcessSQLData(conv, db, mysql.InfoSchemaImpl{os.Getenv("MYSQLDATABASE")})
+		return common.ProcessSQLData(conv, db, mysql.InfoSchemaImpl{os.Getenv("MYSQLDATABASE")}, opts)
 	case POSTGRES:
-		common.ProcessSQLData(conv, db, postgres.InfoSchemaImpl{})
+		return common.ProcessSQLData(conv, db, postgres.InfoSchemaImpl{}, opts)
 	default:
 		return fmt.Errorf("Data conversion for driver %s is not supported", driver)
 	}
-	return nil
 }
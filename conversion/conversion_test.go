@@ -0,0 +1,88 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conversion
+
+import (
+	"context"
+	"testing"
+
+	"github.com/googleapis/gax-go/v2"
+	"github.com/stretchr/testify/assert"
+	adminpb "google.golang.org/genproto/googleapis/spanner/admin/database/v1"
+
+	"github.com/cloudspannerecosystem/harbourbridge/internal"
+	"github.com/cloudspannerecosystem/harbourbridge/spanner/ddl"
+)
+
+func TestRereadTables(t *testing.T) {
+	conv := internal.MakeConv()
+	for _, name := range []string{"done", "partial", "new", "keyed", "excluded"} {
+		conv.ToSource[name] = internal.NameAndCols{Name: name, Cols: map[string]string{}}
+		if name != "keyed" {
+			conv.SyntheticPKeys[name] = internal.SyntheticPKey{Col: "synth_id", Kind: internal.SyntheticPKeyUUID}
+		}
+	}
+	conv.Filters.ExcludeTables = []string{"excluded"}
+	cp := internal.NewCheckpoint("")
+	cp.TableRead("done", "")
+	conv.SetCheckpoint(cp)
+	// Tables with a synthetic key that weren't complete are read again
+	// from the start, whether or not any of their rows were written.
+	assert.Equal(t, []string{"new", "partial"}, RereadTables(conv))
+}
+
+func TestTruncateStatement(t *testing.T) {
+	conv := internal.MakeConv()
+	assert.Equal(t, "DELETE FROM `t` WHERE true", truncateStatement(conv, "t"))
+	conv.TargetDb = TARGET_EXPERIMENTAL_POSTGRES
+	assert.Equal(t, `DELETE FROM "t" WHERE true`, truncateStatement(conv, "t"))
+}
+
+// fakeDdlReader returns fixed DDL statements.
+type fakeDdlReader struct {
+	statements []string
+}
+
+func (r fakeDdlReader) GetDatabaseDdl(ctx context.Context, req *adminpb.GetDatabaseDdlRequest, opts ...gax.CallOption) (*adminpb.GetDatabaseDdlResponse, error) {
+	return &adminpb.GetDatabaseDdlResponse{Statements: r.statements}, nil
+}
+
+func TestDiffDatabase(t *testing.T) {
+	conv := internal.MakeConv()
+	conv.SpSchema["t"] = ddl.CreateTable{
+		Name:     "t",
+		ColNames: []string{"a", "b", "c"},
+		ColDefs: map[string]ddl.ColumnDef{
+			"a": {Name: "a", T: ddl.Type{Name: ddl.Int64}, NotNull: true},
+			"b": {Name: "b", T: ddl.Type{Name: ddl.Timestamp}},
+			"c": {Name: "c", T: ddl.Type{Name: ddl.String, Len: ddl.MaxLength}},
+		},
+		Pks:     []ddl.IndexKey{{Col: "a"}},
+		Indexes: []ddl.CreateIndex{{Name: "t_b", Table: "t", Keys: []ddl.IndexKey{{Col: "b"}}}},
+	}
+	// The existing database uses column OPTIONS and STORING clauses, which
+	// the Spanner schema can't represent.
+	r := fakeDdlReader{statements: []string{
+		"CREATE TABLE t (\n  a INT64 NOT NULL,\n  b TIMESTAMP OPTIONS (\n    allow_commit_timestamp = true\n  ),\n) PRIMARY KEY(a)",
+		"CREATE INDEX t_b ON t(b) STORING (a)",
+	}}
+	diff, err := DiffDatabase(context.Background(), r, "projects/p/instances/i/databases/d", conv)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"ALTER TABLE `t` ADD COLUMN `c` STRING(MAX)"}, diff.Statements)
+
+	conv.TargetDb = TARGET_EXPERIMENTAL_POSTGRES
+	_, err = DiffDatabase(context.Background(), r, "projects/p/instances/i/databases/d", conv)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,162 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conversion
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/cloudspannerecosystem/harbourbridge/internal"
+	"github.com/cloudspannerecosystem/harbourbridge/spanner/ddl"
+)
+
+// fakeDdlUpdater applies statements in order, and fails a statement with
+// the next of its scripted errors (if any).
+type fakeDdlUpdater struct {
+	mu       sync.Mutex
+	errs     map[string][]error // Errors returned for a statement, in order.
+	applied  []string
+	requests [][]string
+}
+
+func (u *fakeDdlUpdater) UpdateDdl(ctx context.Context, dbURI string, stmts []string) (int, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.requests = append(u.requests, stmts)
+	for i, stmt := range stmts {
+		if errs := u.errs[stmt]; len(errs) > 0 {
+			u.errs[stmt] = errs[1:]
+			return i, errs[0]
+		}
+		u.applied = append(u.applied, stmt)
+	}
+	return len(stmts), nil
+}
+
+func TestIsTransientDdlError(t *testing.T) {
+	assert.True(t, isTransientDdlError(status.Error(codes.ResourceExhausted, "quota")))
+	assert.True(t, isTransientDdlError(status.Error(codes.Aborted, "aborted")))
+	assert.True(t, isTransientDdlError(status.Error(codes.DeadlineExceeded, "deadline")))
+	assert.True(t, isTransientDdlError(fmt.Errorf("wrapped: %w", status.Error(codes.Unavailable, "unavailable"))))
+	assert.False(t, isTransientDdlError(status.Error(codes.FailedPrecondition, "bad foreign key")))
+	assert.False(t, isTransientDdlError(fmt.Errorf("not a grpc error")))
+}
+
+func TestApplyDdl(t *testing.T) {
+	defer func(backoff time.Duration) { ddlRetryBackoff = backoff }(ddlRetryBackoff)
+	ddlRetryBackoff = time.Millisecond
+	transient := status.Error(codes.ResourceExhausted, "quota")
+	permanent := status.Error(codes.FailedPrecondition, "bad foreign key")
+
+	// Transient errors are retried, from the failed statement on.
+	u := &fakeDdlUpdater{errs: map[string][]error{"b": {transient, transient}}}
+	assert.Empty(t, applyDdl(context.Background(), u, "db", []string{"a", "b", "c"}))
+	assert.Equal(t, []string{"a", "b", "c"}, u.applied)
+	assert.Equal(t, [][]string{{"a", "b", "c"}, {"b", "c"}, {"b", "c"}}, u.requests)
+
+	// Permanent errors aren't retried, and the failed statement is skipped.
+	u = &fakeDdlUpdater{errs: map[string][]error{"b": {permanent}}}
+	failures := applyDdl(context.Background(), u, "db", []string{"a", "b", "c"})
+	assert.Equal(t, []ddlFailure{{stmt: "b", err: permanent}}, failures)
+	assert.Equal(t, []string{"a", "c"}, u.applied)
+	assert.Equal(t, [][]string{{"a", "b", "c"}, {"c"}}, u.requests)
+
+	// Transient errors are retried at most ddlMaxAttempts times.
+	var errs []error
+	for i := 0; i < ddlMaxAttempts; i++ {
+		errs = append(errs, transient)
+	}
+	u = &fakeDdlUpdater{errs: map[string][]error{"a": errs}}
+	failures = applyDdl(context.Background(), u, "db", []string{"a"})
+	assert.Equal(t, []ddlFailure{{stmt: "a", err: transient}}, failures)
+	assert.Empty(t, u.applied)
+	assert.Equal(t, ddlMaxAttempts, len(u.requests))
+}
+
+func TestUpdateForeignKeys(t *testing.T) {
+	defer func(backoff time.Duration) { ddlRetryBackoff = backoff }(ddlRetryBackoff)
+	ddlRetryBackoff = time.Millisecond
+	conv := internal.MakeConv()
+	for _, name := range []string{"t1", "t2", "t3"} {
+		conv.SpSchema[name] = ddl.CreateTable{
+			Name:     name,
+			ColNames: []string{"a"},
+			ColDefs:  map[string]ddl.ColumnDef{"a": {Name: "a", T: ddl.Type{Name: ddl.Int64}}},
+			Pks:      []ddl.IndexKey{{Col: "a"}},
+			Fks:      []ddl.Foreignkey{{Name: "fk_" + name, Columns: []string{"a"}, ReferTable: "ref", ReferColumns: []string{"a"}}},
+		}
+	}
+	stmt := func(table string) string {
+		return fmt.Sprintf("ALTER TABLE `%s` ADD CONSTRAINT `fk_%s` FOREIGN KEY (`a`) REFERENCES `ref` (`a`)", table, table)
+	}
+	dir, err := ioutil.TempDir("", "fks")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name         string
+		batchSize    int
+		errs         map[string][]error
+		wantRequests int
+		wantFailed   []string
+	}{
+		{
+			name:         "one foreign key per request",
+			batchSize:    0,
+			wantRequests: 3,
+		},
+		{
+			name:         "batches",
+			batchSize:    2,
+			wantRequests: 2,
+		},
+		{
+			name:         "transient and permanent errors",
+			batchSize:    3,
+			errs:         map[string][]error{stmt("t1"): {status.Error(codes.Aborted, "aborted")}, stmt("t2"): {status.Error(codes.FailedPrecondition, "bad data")}},
+			wantRequests: 3,
+			wantFailed:   []string{stmt("t2")},
+		},
+	}
+	for _, tc := range tests {
+		u := &fakeDdlUpdater{errs: tc.errs}
+		failedFile := filepath.Join(dir, tc.name+".ddl.txt")
+		err := updateForeignKeys(context.Background(), u, "db", conv, ForeignKeyOptions{BatchSize: tc.batchSize, FailedFile: failedFile}, os.Stdout)
+		assert.Nil(t, err, tc.name)
+		assert.Equal(t, tc.wantRequests, len(u.requests), tc.name)
+		applied := append([]string{}, u.applied...)
+		applied = append(applied, tc.wantFailed...)
+		sort.Strings(applied)
+		assert.Equal(t, []string{stmt("t1"), stmt("t2"), stmt("t3")}, applied, tc.name)
+		b, err := ioutil.ReadFile(failedFile)
+		if len(tc.wantFailed) == 0 {
+			assert.True(t, os.IsNotExist(err), tc.name)
+		} else {
+			assert.Nil(t, err, tc.name)
+			assert.Equal(t, stmt("t2")+"\n", string(b), tc.name)
+		}
+	}
+}
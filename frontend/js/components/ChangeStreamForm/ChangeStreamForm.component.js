@@ -0,0 +1,130 @@
+import Actions from "../../services/Action.service.js";
+
+const VALUE_CAPTURE_TYPES = ["OLD_AND_NEW_VALUES", "NEW_VALUES", "NEW_ROW"];
+
+class ChangeStreamForm extends HTMLElement {
+  get data() {
+    return Actions.getChangeStreamData();
+  }
+
+  connectedCallback() {
+    this.render();
+    let { streams, tables } = this.data;
+
+    document.getElementById("change-stream-name")?.addEventListener("input", (e) => {
+      document.getElementById("add-change-stream-button").disabled = e.target.value.trim() === "";
+    });
+
+    document.getElementById("all-tables-switch")?.addEventListener("change", (e) => {
+      document.getElementById("change-stream-table-list").style.display = e.target.checked ? "none" : "block";
+    });
+
+    if (document.getElementById("add-change-stream-button")) {
+      document
+        .getElementById("add-change-stream-button")
+        .addEventListener("click", () => {
+          Actions.addChangeStream(
+            document.getElementById("change-stream-name").value.trim(),
+            document.getElementById("all-tables-switch").checked,
+            tables.filter((_, idx) => document.getElementById("change-stream-table-" + idx).checked),
+            document.getElementById("change-stream-retention").value.trim(),
+            document.getElementById("change-stream-value-capture").value
+          );
+        });
+    }
+
+    streams.map((_, idx) => {
+      document
+        .getElementById("drop-change-stream-" + idx)
+        .addEventListener("click", () => {
+          Actions.dropChangeStream(idx);
+        });
+    });
+  }
+
+  render() {
+    let { streams, tables } = this.data;
+    this.innerHTML = `
+    <div class="change-stream-list">
+        ${streams.length > 0 ? `<table class="fk-table change-stream-table">
+            <thead>
+                <tr>
+                    <th>Name</th>
+                    <th>Tables</th>
+                    <th>Retention</th>
+                    <th>Value Capture</th>
+                    <th>Action</th>
+                </tr>
+            </thead>
+            <tbody>
+                ${streams.map((stream, idx) => {
+                  return `
+                <tr>
+                    <td class="acc-table-td">${stream.Name}</td>
+                    <td class="acc-table-td">${stream.All ? "ALL" : (stream.Tables || []).map((t) => t.Table).join(", ")}</td>
+                    <td class="acc-table-td">${stream.RetentionPeriod || "default"}</td>
+                    <td class="acc-table-td">${stream.ValueCaptureType || "default"}</td>
+                    <td class="acc-table-td">
+                        <button class="drop-button" id="drop-change-stream-${idx}" data-toggle="tooltip"
+                            data-placement="bottom" title="this will delete change stream permanently">
+                            <span><i class="large material-icons remove-icon vertical-alignment-middle">delete</i></span>
+                            <span class="vertical-alignment-middle">Drop</span>
+                        </button>
+                    </td>
+                </tr>`;
+                }).join("")}
+            </tbody>
+        </table>` : `<div class="no-change-stream">No change streams</div>`}
+    </div>
+    <form id="change-stream-form">
+        <div class="form-group">
+            <label for="change-stream-name" class="bmd-label-floating black-label">Enter change stream name</label>
+            <input type="text" class="form-control black-border" name="change-stream-name"
+            id="change-stream-name" autocomplete="off">
+        </div>
+        <div class="unique-swith-container">
+            <span class="unique-swith-label">All tables</span>
+            <label class="switch">
+                <input id="all-tables-switch" type="checkbox">
+                <span class="slider round"></span>
+            </label>
+        </div>
+        <div id="change-stream-table-list" class="column-list-container">
+            ${tables.map((table, idx) => {
+              return `
+            <div class="new-index-column-list">
+                <span class="column-name">${table}</span>
+                <span class="bmd-form-group is-filled">
+                    <div class="checkbox float-right">
+                        <label>
+                            <input type="checkbox" value="" id="change-stream-table-${idx}">
+                            <span class="checkbox-decorator"><span class="check black-border"></span>
+                                <div class="ripple-container"></div>
+                            </span>
+                        </label>
+                    </div>
+                </span>
+            </div>`;
+            }).join("")}
+        </div>
+        <div class="form-group">
+            <label for="change-stream-retention" class="bmd-label-floating black-label">Retention period (e.g. 36h or 7d)</label>
+            <input type="text" class="form-control black-border" name="change-stream-retention"
+            id="change-stream-retention" autocomplete="off">
+        </div>
+        <div class="form-group">
+            <label for="change-stream-value-capture" class="black-label">Value capture type</label>
+            <select class="form-control black-border" id="change-stream-value-capture">
+                <option value="">default</option>
+                ${VALUE_CAPTURE_TYPES.map((type) => `<option value="${type}">${type}</option>`).join("")}
+            </select>
+        </div>
+    </form>`;
+  }
+
+  constructor() {
+    super();
+  }
+}
+
+window.customElements.define("hb-change-stream-form", ChangeStreamForm);
@@ -0,0 +1,37 @@
+import '../ChangeStreamForm.component.js'
+import Store from '../../../services/Store.service.js'
+
+describe('rendering test of change stream form', () => {
+    test('Change stream form component render fine without data', () => {
+        document.body.innerHTML = `<hb-change-stream-form></hb-change-stream-form>`
+        let component = document.body.querySelector('hb-change-stream-form');
+        expect(component).not.toBe(null)
+        expect(component.innerHTML).not.toBe('')
+        expect(document.getElementById('change-stream-form')).not.toBe(null)
+        expect(document.getElementsByClassName('no-change-stream').length).toBe(1)
+        expect(document.getElementById('change-stream-value-capture').options.length).toBe(4)
+    })
+
+    test('render existing change streams and table checkbox list', () => {
+        Store.updateTableData('reportTabContent', {
+            SpSchema: { orders: {}, customers: {} },
+            ChangeStreams: [
+                { Name: 'cs_all', All: true, Tables: null, RetentionPeriod: '7d', ValueCaptureType: '' },
+                { Name: 'cs_orders', All: false, Tables: [{ Table: 'orders', Cols: null }], RetentionPeriod: '', ValueCaptureType: 'NEW_ROW' },
+            ]
+        })
+        document.body.innerHTML = `<hb-change-stream-form></hb-change-stream-form>`
+        let rows = document.querySelectorAll('.change-stream-table tbody tr')
+        expect(rows.length).toBe(2)
+        expect(rows[0].innerHTML).toContain('ALL')
+        expect(rows[0].innerHTML).toContain('7d')
+        expect(rows[1].innerHTML).toContain('orders')
+        expect(rows[1].innerHTML).toContain('NEW_ROW')
+        expect(document.getElementById('drop-change-stream-1')).not.toBe(null)
+        let tables = document.getElementsByClassName('column-name')
+        expect(tables.length).toBe(2)
+        expect(tables[0].innerHTML).toBe('customers')
+        expect(tables[1].innerHTML).toBe('orders')
+        expect(document.getElementById('change-stream-table-1').checked).toBe(false)
+    })
+})
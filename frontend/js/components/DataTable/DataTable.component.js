@@ -158,6 +158,63 @@ class DataTable extends HTMLElement {
         `;
     }
 
+    rowDeletionPolicyComponent(tableIndex, spTable, tableMode) {
+        let rdp = spTable.RowDeletionPolicy;
+        let timestampCols = spTable.ColNames.filter((col) => spTable.ColDefs[col].T.Name === "TIMESTAMP");
+        return `
+            <div>
+                <div class="foreign-key-header" role="tab">
+                    <h5 class="mb-0">
+                        <a class="index-font" data-toggle="collapse" href="#row-deletion-policy-${tableIndex}">
+                            Row Deletion Policy
+                        </a>
+                    </h5>
+                </div>
+                <div class="collapse index-collapse show" id="row-deletion-policy-${tableIndex}">
+                    <div class="mdc-card mdc-card-content summary-border">
+                        <div class="mdc-card fk-content">
+                            ${timestampCols.length > 0 ? `<table class="index-acc-table fk-table">
+                                <thead>
+                                    <tr>
+                                        <th>Timestamp Column</th>
+                                        <th>Days</th>
+                                        <th>Action</th>
+                                    </tr>
+                                </thead>
+                                <tbody>
+                                    <tr>
+                                        <td class="acc-table-td">
+                                            <select class="form-control spanner-input" id="rdp-column-${tableIndex}"
+                                                ${tableMode ? "" : "disabled"}>
+                                                <option value="">None</option>
+                                                ${timestampCols.map((col) => {
+            return `<option value="${col}" ${rdp && rdp.Col === col ? "selected" : ""}>${col}</option>`;
+        }).join("")}
+                                            </select>
+                                        </td>
+                                        <td class="acc-table-td">
+                                            <input type="number" min="1" class="form-control spanner-input" autocomplete="off"
+                                                id="rdp-days-${tableIndex}" value="${rdp ? rdp.Days : ""}" ${tableMode ? "" : "disabled"} />
+                                        </td>
+                                        <td class="acc-table-td">
+                                            <button class="drop-button" id="rdp-save-${tableIndex}" data-toggle="tooltip"
+                                                data-placement="bottom" title="rows older than the given number of days will be deleted"
+                                                ${tableMode ? "" : "disabled"}>
+                                                <span><i class="large material-icons remove-icon vertical-alignment-middle">save</i></span>
+                                                <span class="vertical-alignment-middle">Set</span>
+                                            </button>
+                                        </td>
+                                    </tr>
+                                </tbody>
+                            </table>`
+                : `<div>A row deletion policy needs a TIMESTAMP column</div>`}
+                        </div>
+                    </div>
+                </div>
+            </div>
+        `;
+    }
+
     render() {
         let { tableName, tableIndex, data } = this;
         let countSrc = [], countSp = [], notNullConstraint = [];
@@ -357,6 +414,7 @@ class DataTable extends HTMLElement {
                 </div>
             ${spTable.Fks?.length > 0 ? this.fkComponent(tableIndex, tableName, spTable.Fks, tableMode) : `<div></div>`}
             ${this.secIndexComponent(tableIndex, tableName, spTable.Indexes, tableMode)}
+            ${this.rowDeletionPolicyComponent(tableIndex, spTable, tableMode)}
             <div class="summary-card">
                 <div class="summary-card-header" role="tab">
                     <h5 class="mb-0">
@@ -440,6 +498,11 @@ class DataTable extends HTMLElement {
             });
         }
 
+        document.getElementById('rdp-save-' + tableIndex)?.addEventListener('click', () => {
+            Actions.setRowDeletionPolicy(tableName, document.getElementById('rdp-column-' + tableIndex).value,
+                document.getElementById('rdp-days-' + tableIndex).value);
+        })
+
         document.getElementById('pre-btn' + tableIndex).addEventListener('click', async () => {
             Actions.showSpinner()
             let columnStatus = true;
@@ -2,6 +2,7 @@ import "../../components/ConnectToDbForm/ConnectToDbForm.component.js";
 import "../../components/LoadDbDumpForm/LoadDbDumpForm.component.js";
 import "../../components/LoadSessionFileForm/LoadSessionFileForm.component.js";
 import "../../components/AddIndexForm/AddIndexForm.component.js";
+import "../../components/ChangeStreamForm/ChangeStreamForm.component.js";
 import {MODALCONFIGS} from "./../../config/constantData.js";
 
 class Modal extends HTMLElement {
@@ -69,6 +70,9 @@ class Modal extends HTMLElement {
       case "createIndexModal":
         modalButtons = MODALCONFIGS.ADD_INDEX_MODAL;
         break;
+      case "changeStreamModal":
+        modalButtons = MODALCONFIGS.CHANGE_STREAM_MODAL;
+        break;
       case "editTableWarningModal":
         modalButtons = MODALCONFIGS.EDIT_TABLE_WARNING_MODAL;
         break;
@@ -47,6 +47,8 @@ class Instructions extends HTMLElement {
                     <li> Drop foreign key from a table</li>
                     <li> Drop secondary index from a table</li>
                     <li> Convert foreign key into interleave table</li>
+                    <li> Set a row deletion policy (TTL) for a table</li>
+                    <li> Add or drop change streams</li>
                     <li> Search a table</li>
                     <li> Download schema, report and session files</li>
                 </ul>
@@ -122,6 +124,8 @@ class Instructions extends HTMLElement {
                     <li> Select to convert foreign key to interleave or use as is (if option is available)</li>
                     <li> Drop a column by unselecting any checkbox</li>
                     <li> Drop a foreign key or secondary index by expanding foreign keys or secondary indexes tab inside table</li>
+                    <li> Set a row deletion policy by picking a timestamp column and a number of days in the row deletion policy
+                        tab inside table and clicking on set (pick none to remove the policy)</li>
                     <li> Click on save changes button to save the changes</li>
                     <li> If current table is involved in foreign key/secondary indexes relationship with other table then user will
                         be prompt to delete foreign key or secondary indexes and then proceed with save changes</li>
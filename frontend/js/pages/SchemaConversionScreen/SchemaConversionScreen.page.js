@@ -112,6 +112,7 @@ class SchemaConversionScreen extends HTMLElement {
             <div class="accordion md-accordion" id="accordion" role="tablist" aria-multiselectable="true">
             ${tableNameArray.length > 0 ? `<hb-site-button buttonid="reportExpandButton" classname="expand" buttonaction="expandAll" text="${changingText}"></hb-site-button>`:''}
             ${tableNameArray.length > 0 ? `<hb-site-button buttonid="editButton" classname="expand right-align" buttonaction="editGlobalDataType" text="Edit Global Data Type"></hb-site-button>` :''}
+            ${tableNameArray.length > 0 ? `<hb-site-button buttonid="changeStreamButton" classname="expand right-align" buttonaction="editChangeStreams" text="Change Streams"></hb-site-button>` :''}
               <div id='reportDiv'>
                 ${tableNameArray.map((tableName, index) => {
                     return `
@@ -176,7 +177,9 @@ class SchemaConversionScreen extends HTMLElement {
     <hb-modal modalId="editTableWarningModal" content="edit table" contentIcon="cancel" 
       connectIconClass="connect-icon-failure" modalBodyClass="connection-modal-body" title="Error Message"></hb-modal>
     <hb-modal modalId="createIndexModal" content="" contentIcon="" 
-      connectIconClass="" modalBodyClass="" title="Select keys for new index"></hb-modal>`;
+      connectIconClass="" modalBodyClass="" title="Select keys for new index"></hb-modal>
+    <hb-modal modalId="changeStreamModal" content="<hb-change-stream-form></hb-change-stream-form>" contentIcon="" 
+      connectIconClass="" modalBodyClass="" title="Change Streams"></hb-modal>`;
 
     initSchemaScreenTasks();
     if (currentTab === "reportTab" && !this.testing) {
@@ -850,6 +850,80 @@ const Actions = (() => {
             }
         },
 
+        setRowDeletionPolicy: async(tableName, col, days) => {
+            if (col !== "" && !(parseInt(days) > 0)) {
+                showSnackbar("Please enter a positive number of days for the row deletion policy", " redBg");
+                return;
+            }
+            Actions.showSpinner()
+            let res = await Fetch.getAppData("POST", "/rowdeletionpolicy?table=" + tableName,
+                col === "" ? {} : { Col: col, Days: parseInt(days) });
+            if (res.ok) {
+                res = await res.json();
+                Store.updatePrimaryKeys(res);
+                Store.updateTableData("reportTabContent", res);
+                Actions.ddlSummaryAndConversionApiCall();
+                Actions.resetReportTableData();
+            } else {
+                res = await res.text();
+                Actions.hideSpinner()
+                showSnackbar(res, " redBg");
+            }
+        },
+
+        editChangeStreams: () => {
+            jQuery("#changeStreamModal").modal();
+        },
+
+        getChangeStreamData: () => {
+            let { ChangeStreams, SpSchema } = Store.getinstance().tableData.reportTabContent;
+            return {
+                streams: ChangeStreams ? ChangeStreams : [],
+                tables: SpSchema ? Object.keys(SpSchema).sort() : []
+            };
+        },
+
+        addChangeStream: async(name, all, tables, retention, valueCaptureType) => {
+            let { streams } = Actions.getChangeStreamData();
+            if (!all && tables.length === 0) {
+                showSnackbar("Please select atleast one table or all tables for the change stream", " redBg");
+                return;
+            }
+            if (streams.some((stream) => stream.Name === name)) {
+                showSnackbar("Change stream with name: " + name + " already exists.\n Please try with a different name", " redBg");
+                return;
+            }
+            let newStream = {
+                Name: name,
+                All: all,
+                Tables: all ? [] : tables.map((table) => ({ Table: table, Cols: [] })),
+                RetentionPeriod: retention,
+                ValueCaptureType: valueCaptureType
+            };
+            await Actions.setChangeStreams([...streams, newStream]);
+        },
+
+        dropChangeStream: async(pos) => {
+            let { streams } = Actions.getChangeStreamData();
+            await Actions.setChangeStreams(streams.filter((_, idx) => idx !== pos));
+        },
+
+        setChangeStreams: async(streams) => {
+            Actions.showSpinner()
+            let res = await Fetch.getAppData("POST", "/changestreams", streams);
+            if (res.ok) {
+                jQuery("#changeStreamModal").modal("hide");
+                res = await res.json();
+                Store.updatePrimaryKeys(res);
+                Store.updateTableData("reportTabContent", res);
+                Actions.resetReportTableData();
+            } else {
+                res = await res.text();
+                Actions.hideSpinner()
+                showSnackbar(res, " redBg");
+            }
+        },
+
         showSpinner: () => {
             let toggle_spinner = document.getElementById("toggle-spinner");
             toggle_spinner.style.display = "block";
@@ -0,0 +1,31 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"github.com/cloudspannerecosystem/harbourbridge/spanner/ddl"
+)
+
+// SetChangeStreams replaces conv's change streams with l, after checking
+// them against conv's Spanner schema. Unlike row deletion policies, change
+// streams refer to Spanner tables and columns. If l is invalid, conv is
+// unchanged.
+func (conv *Conv) SetChangeStreams(l []ddl.ChangeStream) error {
+	if err := conv.SpSchema.CheckChangeStreams(l); err != nil {
+		return err
+	}
+	conv.ChangeStreams = l
+	return nil
+}
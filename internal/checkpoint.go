@@ -0,0 +1,401 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// checkpointInterval is the minimum time between saves of a checkpoint
+// file while a migration is in progress.
+var checkpointInterval = 10 * time.Second
+
+// Checkpoint records the progress of a data migration, so that a
+// migration that dies part way through can be resumed without rewriting
+// the rows Spanner already has. Progress only moves past a row once that
+// row and every row read before it (by the same reader) have been
+// acknowledged by the writer, so everything before the recorded positions
+// is known to be done.
+//
+// For SQL sources, tables are read in primary key order and we record the
+// primary key of the last row done for each table, along with the tables
+// that are complete. Large tables may be split into key ranges that are
+// read in parallel, in which case we record the same for each range. For
+// dump sources, we record the byte offset of the statement being processed
+// and the number of its rows that are done.
+type Checkpoint struct {
+	Tables     map[string]*TableCheckpoint // Maps source table name to its progress.
+	DumpOffset int64                       // Dump sources: rows of statements starting before this offset are done.
+	DumpRows   int64                       // Dump sources: number of rows done of the statement at DumpOffset.
+	Snapshot   *SourcePosition             `json:",omitempty"` // SQL sources: position of the first snapshot data was read from, for replaying later changes.
+	Replayed   *SourcePosition             `json:",omitempty"` // MySQL sources: position up to which later changes have been replayed.
+	file       string
+	lock       sync.Mutex                    // Protects all fields once rows are being tracked.
+	pending    map[stream][]*checkpointEntry // Rows (and ends of reads) not yet reflected in the checkpoint, in read order.
+	saved      time.Time                     // Time of the last save.
+}
+
+// TableCheckpoint records the progress of a source table (or of a key
+// range of the table).
+type TableCheckpoint struct {
+	Complete bool                        // All rows are done.
+	LastKey  []string                    `json:",omitempty"` // Primary key values of the last row done.
+	Ranges   map[string]*TableCheckpoint `json:",omitempty"` // Maps key range name to its progress, for tables read in key ranges.
+}
+
+// RowPosition identifies where a source row was read from.
+type RowPosition struct {
+	Table  string   // Source table.
+	Range  string   // Key range of the table being read (empty if the whole table is read).
+	Key    []string // Primary key values (SQL sources, for tables with a primary key).
+	Dump   bool     // The row was read from a dump (rather than an SQL source).
+	Offset int64    // Byte offset of the statement containing the row (dump sources).
+	Row    int64    // Index of the row among those written for the statement (dump sources).
+}
+
+// SourcePosition identifies a point in the change history of a source
+// database, from which changes made after a snapshot of the database was
+// read can be replayed.
+type SourcePosition struct {
+	BinlogFile string `json:",omitempty"` // MySQL binary log file.
+	BinlogPos  uint64 `json:",omitempty"` // Offset of the next event in BinlogFile.
+	LSN        string `json:",omitempty"` // PostgreSQL write-ahead log location.
+}
+
+// String returns a description of pos for reports.
+func (pos SourcePosition) String() string {
+	switch {
+	case pos.BinlogFile != "":
+		return fmt.Sprintf("binlog file %s, position %d", pos.BinlogFile, pos.BinlogPos)
+	case pos.LSN != "":
+		return fmt.Sprintf("WAL LSN %s", pos.LSN)
+	default:
+		return "unknown position"
+	}
+}
+
+// stream identifies a sequence of rows read in order by a single reader:
+// a table or key range of a table for SQL sources, and the whole dump for
+// dump sources.
+type stream struct {
+	table, keyRange string
+}
+
+func (pos RowPosition) stream() stream {
+	if pos.Dump {
+		return stream{}
+	}
+	return stream{pos.Table, pos.Range}
+}
+
+type checkpointEntry struct {
+	pos     RowPosition
+	readEnd bool // Marks the end of the read of pos.Table (or pos.Range) rather than a row.
+	done    bool
+}
+
+// NewCheckpoint returns an empty checkpoint that is saved to file (or not
+// saved at all if file is empty).
+func NewCheckpoint(file string) *Checkpoint {
+	return &Checkpoint{Tables: make(map[string]*TableCheckpoint), file: file, pending: make(map[stream][]*checkpointEntry), saved: time.Now()}
+}
+
+// ReadCheckpoint reads a checkpoint from file. Progress made from here on
+// is saved to the same file.
+func ReadCheckpoint(file string) (*Checkpoint, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	cp := NewCheckpoint(file)
+	if err := json.Unmarshal(b, cp); err != nil {
+		return nil, fmt.Errorf("can't parse checkpoint file %s: %v", file, err)
+	}
+	if cp.Tables == nil {
+		cp.Tables = make(map[string]*TableCheckpoint)
+	}
+	return cp, nil
+}
+
+// Save writes cp to its file. The file is replaced atomically, so a
+// migration that dies while saving leaves the previous checkpoint intact.
+func (cp *Checkpoint) Save() error {
+	if cp == nil {
+		return nil
+	}
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+	return cp.save()
+}
+
+func (cp *Checkpoint) save() error {
+	if cp.file == "" {
+		return nil
+	}
+	b, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := cp.file + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0644); err != nil {
+		return fmt.Errorf("can't write checkpoint file %s: %v", tmp, err)
+	}
+	if err := os.Rename(tmp, cp.file); err != nil {
+		return fmt.Errorf("can't write checkpoint file %s: %v", cp.file, err)
+	}
+	cp.saved = time.Now()
+	return nil
+}
+
+// SetSnapshot records the position of the snapshot data is read from,
+// unless one is already recorded: changes must be replayed from the first
+// snapshot of a migration that is resumed.
+func (cp *Checkpoint) SetSnapshot(pos SourcePosition) {
+	if cp == nil {
+		return
+	}
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+	if cp.Snapshot == nil {
+		cp.Snapshot = &pos
+	}
+}
+
+// SetReplayed records that changes made to the source database have been
+// replayed up to pos.
+func (cp *Checkpoint) SetReplayed(pos SourcePosition) {
+	if cp == nil {
+		return
+	}
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+	cp.Replayed = &pos
+}
+
+// ReplayStart returns the position from which changes made to the source
+// database should be replayed: where the last replay stopped, or else the
+// snapshot data was read from. It returns false if neither is recorded.
+func (cp *Checkpoint) ReplayStart() (SourcePosition, bool) {
+	if cp == nil {
+		return SourcePosition{}, false
+	}
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+	switch {
+	case cp.Replayed != nil:
+		return *cp.Replayed, true
+	case cp.Snapshot != nil:
+		return *cp.Snapshot, true
+	}
+	return SourcePosition{}, false
+}
+
+// progress returns the progress recorded for table (or its key range
+// keyRange, if not empty), or nil if there is none. If create is true,
+// missing entries are created. Must be called with cp.lock held.
+func (cp *Checkpoint) progress(table, keyRange string, create bool) *TableCheckpoint {
+	t, ok := cp.Tables[table]
+	if !ok {
+		if !create {
+			return nil
+		}
+		t = &TableCheckpoint{}
+		cp.Tables[table] = t
+	}
+	if keyRange == "" {
+		return t
+	}
+	r, ok := t.Ranges[keyRange]
+	if !ok {
+		if !create {
+			return nil
+		}
+		if t.Ranges == nil {
+			t.Ranges = make(map[string]*TableCheckpoint)
+		}
+		r = &TableCheckpoint{}
+		t.Ranges[keyRange] = r
+	}
+	return r
+}
+
+// SetRanges records that table is read in the key ranges named in l.
+// Ranges already recorded keep their progress.
+func (cp *Checkpoint) SetRanges(table string, l []string) {
+	if cp == nil {
+		return
+	}
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+	for _, r := range l {
+		cp.progress(table, r, true)
+	}
+}
+
+// Ranges returns the names of the key ranges recorded for table, in
+// sorted order, or nil if the table isn't read in key ranges.
+func (cp *Checkpoint) Ranges(table string) []string {
+	if cp == nil {
+		return nil
+	}
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+	t, ok := cp.Tables[table]
+	if !ok {
+		return nil
+	}
+	var l []string
+	for r := range t.Ranges {
+		l = append(l, r)
+	}
+	sort.Strings(l)
+	return l
+}
+
+// TableComplete returns true if cp records that all rows of table (or of
+// its key range keyRange, if not empty) are done.
+func (cp *Checkpoint) TableComplete(table, keyRange string) bool {
+	if cp == nil {
+		return false
+	}
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+	return cp.complete(table, keyRange)
+}
+
+// complete is TableComplete with cp.lock held. A table is also complete if
+// all of its key ranges are.
+func (cp *Checkpoint) complete(table, keyRange string) bool {
+	if t := cp.progress(table, "", false); t != nil && t.Complete {
+		return true
+	}
+	t := cp.progress(table, keyRange, false)
+	return t != nil && t.Complete
+}
+
+// LastKey returns the primary key values of the last row of table (or of
+// its key range keyRange, if not empty) that cp records as done, or nil if
+// there is none.
+func (cp *Checkpoint) LastKey(table, keyRange string) []string {
+	if cp == nil {
+		return nil
+	}
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+	if t := cp.progress(table, keyRange, false); t != nil {
+		return t.LastKey
+	}
+	return nil
+}
+
+// Done returns true if cp records that the row at pos is done. Only dump
+// positions and complete tables are checked: SQL sources skip done rows
+// when reading the table (see LastKey).
+func (cp *Checkpoint) Done(pos RowPosition) bool {
+	if cp == nil {
+		return false
+	}
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+	if cp.complete(pos.Table, pos.Range) {
+		return true
+	}
+	if !pos.Dump {
+		return false
+	}
+	return pos.Offset < cp.DumpOffset || (pos.Offset == cp.DumpOffset && pos.Row < cp.DumpRows)
+}
+
+// Add starts tracking the row at pos, and returns the function to call
+// once the row has been written. Rows of each table (or key range) must be
+// added in the order they are read. The checkpoint never moves past a row
+// that isn't written (e.g. one dropped after a write error), so resuming
+// reads it again.
+func (cp *Checkpoint) Add(pos RowPosition) func() {
+	if cp == nil {
+		return nil
+	}
+	e := &checkpointEntry{pos: pos}
+	s := pos.stream()
+	cp.lock.Lock()
+	cp.pending[s] = append(cp.pending[s], e)
+	cp.lock.Unlock()
+	return func() {
+		cp.lock.Lock()
+		defer cp.lock.Unlock()
+		e.done = true
+		cp.advance(s)
+	}
+}
+
+// TableRead records that all rows of table (or of its key range keyRange,
+// if not empty) have been read (and added). The table (or range) is
+// complete once they are all done.
+func (cp *Checkpoint) TableRead(table, keyRange string) {
+	if cp == nil {
+		return
+	}
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+	pos := RowPosition{Table: table, Range: keyRange}
+	s := pos.stream()
+	cp.pending[s] = append(cp.pending[s], &checkpointEntry{pos: pos, readEnd: true, done: true})
+	cp.advance(s)
+}
+
+// advance moves the checkpoint past the leading run of done entries of
+// stream s, and saves it if it hasn't been saved recently. Must be called
+// with cp.lock held.
+func (cp *Checkpoint) advance(s stream) {
+	l := cp.pending[s]
+	n := 0
+	for ; n < len(l) && l[n].done; n++ {
+		pos := l[n].pos
+		switch {
+		case l[n].readEnd:
+			cp.progress(pos.Table, pos.Range, true).Complete = true
+			if t := cp.Tables[pos.Table]; pos.Range != "" && !t.Complete {
+				// A table read in key ranges is complete once all ranges are.
+				t.Complete = true
+				for _, r := range t.Ranges {
+					t.Complete = t.Complete && r.Complete
+				}
+			}
+		case pos.Key != nil:
+			cp.progress(pos.Table, pos.Range, true).LastKey = pos.Key
+		case pos.Dump:
+			cp.DumpOffset, cp.DumpRows = pos.Offset, pos.Row+1
+		}
+	}
+	if n == 0 {
+		return
+	}
+	if n == len(l) {
+		delete(cp.pending, s)
+	} else {
+		cp.pending[s] = l[n:]
+	}
+	if time.Since(cp.saved) >= checkpointInterval {
+		if err := cp.save(); err != nil {
+			VerbosePrintf("%v\n", err)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckpointSQL(t *testing.T) {
+	cp := NewCheckpoint("")
+	ack1 := cp.Add(RowPosition{Table: "t", Key: []string{"1"}})
+	ack2 := cp.Add(RowPosition{Table: "t", Key: []string{"2"}})
+	cp.TableRead("t", "")
+	ack3 := cp.Add(RowPosition{Table: "u", Key: []string{"a", "b"}})
+	cp.TableRead("u", "")
+
+	// Rows acknowledged out of order don't move the checkpoint until all
+	// earlier rows are done.
+	ack2()
+	assert.Nil(t, cp.LastKey("t", ""))
+	ack1()
+	assert.Equal(t, []string{"2"}, cp.LastKey("t", ""))
+	assert.True(t, cp.TableComplete("t", ""))
+	assert.True(t, cp.Done(RowPosition{Table: "t", Key: []string{"7"}}))
+	assert.False(t, cp.TableComplete("u", ""))
+	ack3()
+	assert.Equal(t, []string{"a", "b"}, cp.LastKey("u", ""))
+	assert.True(t, cp.TableComplete("u", ""))
+	assert.Empty(t, cp.pending)
+}
+
+func TestCheckpointRanges(t *testing.T) {
+	cp := NewCheckpoint("")
+	cp.SetRanges("t", []string{"10:", ":10"})
+	assert.Equal(t, []string{"10:", ":10"}, cp.Ranges("t"))
+	assert.Nil(t, cp.Ranges("u"))
+
+	// Key ranges are read concurrently, and progress of each is tracked
+	// separately.
+	ack1 := cp.Add(RowPosition{Table: "t", Range: ":10", Key: []string{"1"}})
+	ack2 := cp.Add(RowPosition{Table: "t", Range: "10:", Key: []string{"10"}})
+	cp.TableRead("t", "10:")
+	ack2()
+	assert.Nil(t, cp.LastKey("t", ":10"))
+	assert.Equal(t, []string{"10"}, cp.LastKey("t", "10:"))
+	assert.True(t, cp.TableComplete("t", "10:"))
+	assert.False(t, cp.TableComplete("t", ""))
+
+	// The table is complete once all its key ranges are.
+	cp.TableRead("t", ":10")
+	ack1()
+	assert.True(t, cp.TableComplete("t", ":10"))
+	assert.True(t, cp.TableComplete("t", ""))
+	assert.Empty(t, cp.pending)
+}
+
+func TestCheckpointDump(t *testing.T) {
+	cp := NewCheckpoint("")
+	var acks []func()
+	for _, pos := range []RowPosition{{Dump: true, Offset: 10, Row: 0}, {Dump: true, Offset: 10, Row: 1}, {Dump: true, Offset: 50, Row: 0}} {
+		acks = append(acks, cp.Add(pos))
+	}
+	acks[0]()
+	acks[2]()
+	assert.True(t, cp.Done(RowPosition{Dump: true, Offset: 10, Row: 0}))
+	assert.False(t, cp.Done(RowPosition{Dump: true, Offset: 10, Row: 1}))
+	acks[1]()
+	assert.Equal(t, int64(50), cp.DumpOffset)
+	assert.Equal(t, int64(1), cp.DumpRows)
+	assert.True(t, cp.Done(RowPosition{Dump: true, Offset: 10, Row: 5}))
+	assert.True(t, cp.Done(RowPosition{Dump: true, Offset: 50, Row: 0}))
+	assert.False(t, cp.Done(RowPosition{Dump: true, Offset: 50, Row: 1}))
+	assert.False(t, cp.Done(RowPosition{Dump: true, Offset: 90, Row: 0}))
+	// Positions of SQL sources are never done unless their table is
+	// complete.
+	assert.False(t, cp.Done(RowPosition{Table: "t"}))
+}
+
+func TestCheckpointDump_StartOffset(t *testing.T) {
+	// Rows of a statement at the very start of a dump are tracked too.
+	cp := NewCheckpoint("")
+	cp.Add(RowPosition{Dump: true, Offset: 0, Row: 0})()
+	assert.Equal(t, int64(0), cp.DumpOffset)
+	assert.Equal(t, int64(1), cp.DumpRows)
+	assert.True(t, cp.Done(RowPosition{Dump: true, Offset: 0, Row: 0}))
+	assert.False(t, cp.Done(RowPosition{Dump: true, Offset: 0, Row: 1}))
+	assert.Empty(t, cp.pending)
+}
+
+func TestCheckpointSaveAndRead(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "db.checkpoint.json")
+
+	cp := NewCheckpoint(file)
+	cp.Add(RowPosition{Table: "t", Key: []string{"42"}})()
+	cp.TableRead("u", "")
+	cp.Add(RowPosition{Dump: true, Offset: 100, Row: 3})()
+	assert.Nil(t, cp.Save())
+
+	got, err := ReadCheckpoint(file)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"42"}, got.LastKey("t", ""))
+	assert.False(t, got.TableComplete("t", ""))
+	assert.True(t, got.TableComplete("u", ""))
+	assert.Equal(t, int64(100), got.DumpOffset)
+	assert.Equal(t, int64(4), got.DumpRows)
+
+	_, err = ReadCheckpoint(filepath.Join(dir, "missing.json"))
+	assert.NotNil(t, err)
+	assert.Nil(t, ioutil.WriteFile(file, []byte("not json"), 0644))
+	_, err = ReadCheckpoint(file)
+	assert.NotNil(t, err)
+}
+
+func TestCheckpointSnapshot(t *testing.T) {
+	cp := NewCheckpoint("")
+	first := SourcePosition{BinlogFile: "mysql-bin.000003", BinlogPos: 154}
+	// Changes must be replayed from the first snapshot read.
+	cp.SetSnapshot(first)
+	cp.SetSnapshot(SourcePosition{BinlogFile: "mysql-bin.000004", BinlogPos: 4})
+	assert.Equal(t, &first, cp.Snapshot)
+	assert.Equal(t, "binlog file mysql-bin.000003, position 154", first.String())
+	assert.Equal(t, "WAL LSN 0/16B6C50", SourcePosition{LSN: "0/16B6C50"}.String())
+	assert.Equal(t, "unknown position", SourcePosition{}.String())
+}
+
+func TestCheckpointReplayStart(t *testing.T) {
+	cp := NewCheckpoint("")
+	_, ok := cp.ReplayStart()
+	assert.False(t, ok)
+	snapshot := SourcePosition{BinlogFile: "mysql-bin.000003", BinlogPos: 154}
+	cp.SetSnapshot(snapshot)
+	pos, ok := cp.ReplayStart()
+	assert.True(t, ok)
+	assert.Equal(t, snapshot, pos)
+	// Later replays continue from where the last one stopped.
+	replayed := SourcePosition{BinlogFile: "mysql-bin.000004", BinlogPos: 1024}
+	cp.SetReplayed(replayed)
+	pos, ok = cp.ReplayStart()
+	assert.True(t, ok)
+	assert.Equal(t, replayed, pos)
+}
+
+func TestCheckpointNil(t *testing.T) {
+	var cp *Checkpoint
+	assert.Nil(t, cp.Add(RowPosition{Table: "t"}))
+	cp.TableRead("t", "")
+	assert.False(t, cp.TableComplete("t", ""))
+	assert.False(t, cp.Done(RowPosition{Dump: true, Offset: 1}))
+	assert.Nil(t, cp.Save())
+}
@@ -15,7 +15,9 @@
 package internal
 
 import (
+	"crypto/rand"
 	"fmt"
+	"math/bits"
 	"time"
 
 	"github.com/cloudspannerecosystem/harbourbridge/schema"
@@ -33,11 +35,29 @@ type Conv struct {
 	ToSource       map[string]NameAndCols              // Maps from Spanner table name to source-DB table name and column mapping.
 	UsedNames      map[string]bool                     // Map storing the names that are already assigned to tables, indices or foreign key contraints.
 	dataSink       func(table string, cols []string, values []interface{})
+	ackSink        func(table string, cols []string, values []interface{}, ack func())
+	deleteSink     func(table string, keyCols []string, key []interface{})
 	Location       *time.Location // Timezone (for timestamp conversion).
 	sampleBadRows  rowSamples     // Rows that generated errors during conversion.
 	Stats          stats
-	TimezoneOffset string // Timezone offset for timestamp conversion.
-	TargetDb       string // The target database to which HarbourBridge is writing.
+	TimezoneOffset string                  // Timezone offset for timestamp conversion.
+	TargetDb       string                  // The target database to which HarbourBridge is writing.
+	Filters        Filters                 // Restricts conversion to a subset of source tables.
+	DefaultSchema  string                  // Schema of source tables whose names aren't schema qualified e.g. the first database of a MySQL dump. Empty means "public".
+	NamingPolicy   NamingPolicy            // Transforms source names into Spanner names.
+	TypeOverrides  TypeOverrides           // Changes the Spanner type of source columns.
+	HotspotRemedy  string                  // Remedy applied to primary keys that cause hotspots (empty means none).
+	HotspotShards  map[string]HotspotShard // Maps Spanner table name to shard column added by HotspotRemedy.
+	SyntheticPKey  string                  // Kind of synthetic primary keys added by AddPrimaryKeys (empty means SyntheticPKeySequence).
+	LimitIssues    map[string][]string     // Maps source-DB table name to violations of Spanner schema limits found by CheckLimits.
+	ChangeStreams  []ddl.ChangeStream      // Change streams created once data has been migrated.
+	DbOptions      ddl.DatabaseOptions     // Database options (ALTER DATABASE ... SET OPTIONS) of the Spanner database.
+	transforms     []Transform             // Transformation rules applied to data before it is written.
+	deferIndexes   bool                    // If true, secondary indexes are built after data is migrated.
+	checkpoint     *Checkpoint             // Tracks data migration progress (nil if not tracked).
+	position       RowPosition             // Position of the next row passed to dataSink.
+	writeMode      string                  // How rows are written to Spanner e.g. insert or insert_or_update (empty if not set).
+	snapshot       *SourcePosition         // Position of the consistent snapshot data was read from (nil if data wasn't read from a snapshot).
 }
 
 type mode int
@@ -53,6 +73,27 @@ const (
 type SyntheticPKey struct {
 	Col      string
 	Sequence int64
+	Kind     string // How key values are generated. Empty means SyntheticPKeySequence.
+}
+
+// Kinds of synthetic primary keys.
+const (
+	// SyntheticPKeySequence keys are INT64 values obtained by bit-reversing
+	// a sequence counter, so that consecutive rows aren't written to the
+	// same split.
+	SyntheticPKeySequence = "sequence"
+	// SyntheticPKeyUUID keys are random (version 4) UUIDs stored as STRING(36).
+	SyntheticPKeyUUID = "uuid"
+)
+
+// CheckSyntheticPKey returns an error if kind isn't a supported kind of
+// synthetic primary key.
+func CheckSyntheticPKey(kind string) error {
+	switch kind {
+	case "", SyntheticPKeySequence, SyntheticPKeyUUID:
+		return nil
+	}
+	return fmt.Errorf("unknown synthetic primary key kind %q, expected %s or %s", kind, SyntheticPKeySequence, SyntheticPKeyUUID)
 }
 
 // SchemaIssue specifies a schema conversion issue.
@@ -77,6 +118,18 @@ const (
 	Datetime
 	Widened
 	Time
+	ExcludedTableForeignKey
+	ExcludedColumn
+	ExcludedColumnIndex
+	ExcludedColumnForeignKey
+	ExcludedPrimaryKey
+	TypeOverride
+	InvalidTypeOverride
+	SequentialKey
+	TimestampKey
+	HotspotRemedied
+	HotspotInterleaved
+	ReservedKeyword
 )
 
 // NameAndCols contains the name of a table and its columns.
@@ -148,6 +201,20 @@ func (conv *Conv) SetDataSink(ds func(table string, cols []string, values []inte
 	conv.dataSink = ds
 }
 
+// SetAckDataSink configures the data sink used instead of dataSink when
+// conv tracks data migration progress. It must call ack once the row has
+// been written, and never for rows that are dropped, so that progress isn't
+// recorded past them.
+func (conv *Conv) SetAckDataSink(ds func(table string, cols []string, values []interface{}, ack func())) {
+	conv.ackSink = ds
+}
+
+// SetDeleteSink configures conv to use the specified sink for deleting
+// rows, given their primary key columns and values.
+func (conv *Conv) SetDeleteSink(ds func(table string, keyCols []string, key []interface{})) {
+	conv.deleteSink = ds
+}
+
 // Note on modes.
 // We process the dump output twice. In the first pass (schema mode) we
 // build the schema, and the second pass (data mode) we write data to
@@ -170,19 +237,216 @@ func (conv *Conv) SetDataMode() {
 	conv.mode = dataOnly
 }
 
-// WriteRow calls dataSink and updates row stats.
+// SetDeferIndexes configures whether secondary indexes are created along
+// with their tables, or built once data has been migrated (which makes bulk
+// loading faster, since writes don't have to maintain the indexes).
+func (conv *Conv) SetDeferIndexes(b bool) {
+	conv.deferIndexes = b
+}
+
+// DeferIndexes returns true if secondary indexes are built once data has
+// been migrated.
+func (conv *Conv) DeferIndexes() bool {
+	return conv.deferIndexes
+}
+
+// SetWriteMode records how rows are written to Spanner (see the write
+// modes of spanner.BatchWriter), so that it can be reported.
+func (conv *Conv) SetWriteMode(mode string) {
+	conv.writeMode = mode
+}
+
+// WriteMode returns how rows are written to Spanner, or an empty string if
+// it hasn't been set.
+func (conv *Conv) WriteMode() string {
+	return conv.writeMode
+}
+
+// SetCheckpoint sets the checkpoint used to track (and resume) the
+// progress of data migration.
+func (conv *Conv) SetCheckpoint(cp *Checkpoint) {
+	conv.checkpoint = cp
+}
+
+// Checkpoint returns conv's checkpoint, or nil if data migration progress
+// isn't tracked.
+func (conv *Conv) Checkpoint() *Checkpoint {
+	return conv.checkpoint
+}
+
+// SetSnapshot records that source data is read from a consistent snapshot
+// of the source database, at position pos of its change history.
+func (conv *Conv) SetSnapshot(pos SourcePosition) {
+	conv.snapshot = &pos
+}
+
+// Snapshot returns the position of the consistent snapshot source data was
+// read from, or nil if data wasn't read from a snapshot.
+func (conv *Conv) Snapshot() *SourcePosition {
+	return conv.snapshot
+}
+
+// SetRowSource records the source table (and its key range, if the table
+// is read in key ranges) of the rows about to be written (SQL and
+// DynamoDB sources).
+func (conv *Conv) SetRowSource(srcTable, keyRange string) {
+	conv.position = RowPosition{Table: srcTable, Range: keyRange}
+}
+
+// SetRowKey records the primary key values of the next row to be written
+// (SQL sources).
+func (conv *Conv) SetRowKey(key []string) {
+	conv.position.Key = key
+}
+
+// SetDumpOffset records the byte offset of the dump statement whose rows
+// are about to be written (dump sources).
+func (conv *Conv) SetDumpOffset(offset int64) {
+	conv.position = RowPosition{Dump: true, Offset: offset}
+}
+
+// RowPosition returns the position of the row being passed to dataSink.
+func (conv *Conv) RowPosition() RowPosition {
+	return conv.position
+}
+
+// NewDataWorker returns a copy of conv for converting data concurrently
+// with conv and other workers. Workers share conv's schemas and data
+// sinks, but have their own stats, bad row samples, synthetic key
+// sequences and row position, which MergeDataWorker adds back to conv.
+// Schemas must not be changed while workers are in use. Workers don't
+// count rows (see Stats.Rows), which are only counted in schema mode.
+func (conv *Conv) NewDataWorker() *Conv {
+	w := *conv
+	w.SyntheticPKeys = make(map[string]SyntheticPKey)
+	for k, v := range conv.SyntheticPKeys {
+		w.SyntheticPKeys[k] = v
+	}
+	w.sampleBadRows = rowSamples{bytesLimit: conv.sampleBadRows.bytesLimit}
+	w.Stats = stats{
+		Rows:       conv.Stats.Rows,
+		GoodRows:   make(map[string]int64),
+		BadRows:    make(map[string]int64),
+		Statement:  make(map[string]*statementStat),
+		Unexpected: make(map[string]int64),
+	}
+	w.position = RowPosition{}
+	return &w
+}
+
+// MergeDataWorker adds the stats and bad row samples of worker w (see
+// NewDataWorker) to conv. Synthetic key sequences are advanced to the
+// furthest point reached by conv or w.
+func (conv *Conv) MergeDataWorker(w *Conv) {
+	for k, n := range w.Stats.GoodRows {
+		conv.Stats.GoodRows[k] += n
+	}
+	for k, n := range w.Stats.BadRows {
+		conv.Stats.BadRows[k] += n
+	}
+	for k, n := range w.Stats.Unexpected {
+		conv.Stats.Unexpected[k] += n
+	}
+	for k, s := range w.Stats.Statement {
+		t := conv.getStatementStat(k)
+		t.Schema += s.Schema
+		t.Data += s.Data
+		t.Skip += s.Skip
+		t.Error += s.Error
+	}
+	conv.Stats.Reparsed += w.Stats.Reparsed
+	for _, r := range w.sampleBadRows.rows {
+		conv.CollectBadRow(r.table, r.cols, r.vals)
+	}
+	for k, v := range w.SyntheticPKeys {
+		if v.Sequence > conv.SyntheticPKeys[k].Sequence {
+			conv.SyntheticPKeys[k] = v
+		}
+	}
+}
+
+// WriteRow applies conv's transformation rules to a row, then calls
+// dataSink (or ackSink, if conv tracks data migration progress) and
+// updates row stats.
 func (conv *Conv) WriteRow(srcTable, spTable string, spCols []string, spVals []interface{}) {
 	if conv.dataSink == nil {
 		msg := "Internal error: ProcessDataRow called but dataSink not configured"
 		VerbosePrintf("%s\n", msg)
 		conv.Unexpected(msg)
 		conv.StatsAddBadRow(srcTable, conv.DataMode())
+	} else if vals, err := conv.applyTransforms(srcTable, spTable, spCols, spVals); err != nil {
+		conv.Unexpected(fmt.Sprintf("Error while transforming data: %s\n", err))
+		conv.StatsAddBadRow(srcTable, conv.DataMode())
+		conv.CollectBadRow(srcTable, spCols, valsToStrings(spVals))
 	} else {
-		conv.dataSink(spTable, spCols, spVals)
+		spCols, vals = conv.addShardValue(spTable, spCols, vals)
+		conv.position.Table = srcTable
+		if cp := conv.checkpoint; cp != nil && conv.ackSink != nil {
+			// Rows the checkpoint records as done are already in Spanner.
+			if !cp.Done(conv.position) {
+				conv.ackSink(spTable, spCols, vals, cp.Add(conv.position))
+			}
+		} else {
+			conv.dataSink(spTable, spCols, vals)
+		}
+		conv.position.Row++
 		conv.statsAddGoodRow(srcTable, conv.DataMode())
 	}
 }
 
+// DeleteRow applies conv's transformation rules to a row, then calls
+// deleteSink with the row's primary key and updates row stats. The row
+// must include all primary key columns, but other columns are ignored.
+func (conv *Conv) DeleteRow(srcTable, spTable string, spCols []string, spVals []interface{}) {
+	if conv.deleteSink == nil {
+		msg := "Internal error: DeleteRow called but deleteSink not configured"
+		VerbosePrintf("%s\n", msg)
+		conv.Unexpected(msg)
+		conv.StatsAddBadRow(srcTable, conv.DataMode())
+		return
+	}
+	vals, err := conv.applyTransforms(srcTable, spTable, spCols, spVals)
+	if err != nil {
+		conv.Unexpected(fmt.Sprintf("Error while transforming data: %s\n", err))
+		conv.StatsAddBadRow(srcTable, conv.DataMode())
+		conv.CollectBadRow(srcTable, spCols, valsToStrings(spVals))
+		return
+	}
+	spCols, vals = conv.addShardValue(spTable, spCols, vals)
+	var keyCols []string
+	var key []interface{}
+	for _, k := range conv.SpSchema[spTable].Pks {
+		i := indexOf(spCols, k.Col)
+		if i < 0 {
+			conv.Unexpected(fmt.Sprintf("Can't delete row of table %s: no value for primary key column %s\n", spTable, k.Col))
+			conv.StatsAddBadRow(srcTable, conv.DataMode())
+			conv.CollectBadRow(srcTable, spCols, valsToStrings(spVals))
+			return
+		}
+		keyCols = append(keyCols, k.Col)
+		key = append(key, vals[i])
+	}
+	conv.deleteSink(spTable, keyCols, key)
+	conv.statsAddGoodRow(srcTable, conv.DataMode())
+}
+
+func indexOf(l []string, s string) int {
+	for i, x := range l {
+		if x == s {
+			return i
+		}
+	}
+	return -1
+}
+
+func valsToStrings(vals []interface{}) []string {
+	var l []string
+	for _, v := range vals {
+		l = append(l, fmt.Sprintf("%v", v))
+	}
+	return l
+}
+
 // Rows returns the total count of data rows processed.
 func (conv *Conv) Rows() int64 {
 	n := int64(0)
@@ -258,14 +522,42 @@ func (conv *Conv) AddPrimaryKeys() {
 		if len(ct.Pks) == 0 {
 			k := conv.buildPrimaryKey(t)
 			ct.ColNames = append(ct.ColNames, k)
-			ct.ColDefs[k] = ddl.ColumnDef{Name: k, T: ddl.Type{Name: ddl.Int64}}
+			ty := ddl.Type{Name: ddl.Int64}
+			if conv.SyntheticPKey == SyntheticPKeyUUID {
+				ty = ddl.Type{Name: ddl.String, Len: 36}
+			}
+			ct.ColDefs[k] = ddl.ColumnDef{Name: k, T: ty}
 			ct.Pks = []ddl.IndexKey{{Col: k}}
 			conv.SpSchema[t] = ct
-			conv.SyntheticPKeys[t] = SyntheticPKey{k, 0}
+			conv.SyntheticPKeys[t] = SyntheticPKey{Col: k, Sequence: 0, Kind: conv.SyntheticPKey}
 		}
 	}
 }
 
+// NextSyntheticPKey returns the next value of the synthetic primary key
+// of Spanner table spTable.
+func (conv *Conv) NextSyntheticPKey(spTable string) interface{} {
+	aux := conv.SyntheticPKeys[spTable]
+	if aux.Kind == SyntheticPKeyUUID {
+		return newUUID()
+	}
+	v := int64(bits.Reverse64(uint64(aux.Sequence)))
+	aux.Sequence++
+	conv.SyntheticPKeys[spTable] = aux
+	return v
+}
+
+// newUUID returns a random (version 4) UUID as defined in RFC 4122.
+func newUUID() string {
+	var u [16]byte
+	if _, err := rand.Read(u[:]); err != nil {
+		panic(fmt.Sprintf("can't generate UUID: %v", err))
+	}
+	u[6] = (u[6] & 0x0f) | 0x40 // Version 4.
+	u[8] = (u[8] & 0x3f) | 0x80 // RFC 4122 variant.
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:])
+}
+
 // SetLocation configures the timezone for data conversion.
 func (conv *Conv) SetLocation(loc *time.Location) {
 	conv.Location = loc
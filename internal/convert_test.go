@@ -15,6 +15,7 @@
 package internal
 
 import (
+	"regexp"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -106,3 +107,62 @@ func TestAddPrimaryKeys(t *testing.T) {
 	assert.Equal(t, e, conv.SpSchema["table"])
 	assert.Equal(t, SyntheticPKey{Col: "synth_id", Sequence: 0}, conv.SyntheticPKeys["table"])
 }
+
+func TestAddPrimaryKeys_UUID(t *testing.T) {
+	conv := MakeConv()
+	conv.SyntheticPKey = SyntheticPKeyUUID
+	conv.SpSchema["table"] = ddl.CreateTable{
+		Name:     "table",
+		ColNames: []string{"a"},
+		ColDefs: map[string]ddl.ColumnDef{
+			"a": {Name: "a", T: ddl.Type{Name: ddl.Int64}},
+		},
+		Pks: []ddl.IndexKey{}}
+	conv.AddPrimaryKeys()
+	assert.Equal(t, ddl.ColumnDef{Name: "synth_id", T: ddl.Type{Name: ddl.String, Len: 36}}, conv.SpSchema["table"].ColDefs["synth_id"])
+	assert.Equal(t, SyntheticPKey{Col: "synth_id", Sequence: 0, Kind: SyntheticPKeyUUID}, conv.SyntheticPKeys["table"])
+}
+
+func TestNextSyntheticPKey(t *testing.T) {
+	conv := MakeConv()
+	conv.SyntheticPKeys["seq"] = SyntheticPKey{Col: "synth_id"}
+	conv.SyntheticPKeys["uuid"] = SyntheticPKey{Col: "synth_id", Kind: SyntheticPKeyUUID}
+	assert.Equal(t, int64(0), conv.NextSyntheticPKey("seq"))
+	assert.Equal(t, int64(-9223372036854775808), conv.NextSyntheticPKey("seq"))
+	assert.Equal(t, int64(4611686018427387904), conv.NextSyntheticPKey("seq"))
+	assert.Equal(t, int64(3), conv.SyntheticPKeys["seq"].Sequence)
+	u1 := conv.NextSyntheticPKey("uuid").(string)
+	u2 := conv.NextSyntheticPKey("uuid").(string)
+	assert.Regexp(t, regexp.MustCompile("^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$"), u1)
+	assert.NotEqual(t, u1, u2)
+	assert.Equal(t, int64(0), conv.SyntheticPKeys["uuid"].Sequence)
+	assert.Nil(t, CheckSyntheticPKey(SyntheticPKeyUUID))
+	assert.NotNil(t, CheckSyntheticPKey("random"))
+}
+
+func TestDataWorkers(t *testing.T) {
+	conv := MakeConv()
+	conv.SetDataMode()
+	conv.SyntheticPKeys["a"] = SyntheticPKey{Col: "synth_id", Sequence: 5}
+	conv.SyntheticPKeys["b"] = SyntheticPKey{Col: "synth_id", Sequence: 7}
+	conv.Stats.GoodRows["a"] = 10
+	w1, w2 := conv.NewDataWorker(), conv.NewDataWorker()
+	w1.statsAddGoodRow("a", true)
+	w1.NextSyntheticPKey("a")
+	w1.Unexpected("oops")
+	w2.StatsAddBadRow("b", true)
+	w2.CollectBadRow("b", []string{"c"}, []string{"v"})
+	w2.NextSyntheticPKey("b")
+	w2.NextSyntheticPKey("b")
+	// Workers don't change conv until they're merged.
+	assert.Equal(t, int64(10), conv.Stats.GoodRows["a"])
+	assert.Equal(t, int64(5), conv.SyntheticPKeys["a"].Sequence)
+	conv.MergeDataWorker(w1)
+	conv.MergeDataWorker(w2)
+	assert.Equal(t, int64(11), conv.Stats.GoodRows["a"])
+	assert.Equal(t, int64(1), conv.Stats.BadRows["b"])
+	assert.Equal(t, int64(1), conv.Unexpecteds())
+	assert.Equal(t, []string{"table=b cols=[c] data=[v]\n"}, conv.SampleBadRows(10))
+	assert.Equal(t, int64(6), conv.SyntheticPKeys["a"].Sequence)
+	assert.Equal(t, int64(9), conv.SyntheticPKeys["b"].Sequence)
+}
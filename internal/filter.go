@@ -0,0 +1,169 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Filters restricts conversion to a subset of the source database.
+// Patterns use path.Match glob syntax (e.g. "orders_*") and are matched
+// against source table names, as they appear in conv.SrcSchema (for
+// PostgreSQL tables outside the public schema this is "schema.table").
+// Column patterns have the form "table.column", where the text after the
+// last "." matches the column and the rest matches the table
+// e.g. "*.ssn" or "users.legacy_*".
+type Filters struct {
+	Tables         []string // If non-empty, only tables matching one of these patterns are converted.
+	ExcludeTables  []string // Tables matching any of these patterns are skipped.
+	Columns        []string // Projection: for tables matched by one of these patterns, only matching columns are converted.
+	ExcludeColumns []string // Columns matching any of these patterns are dropped.
+	Schemas        []string // If non-empty, only tables in schemas matching one of these patterns are converted.
+}
+
+// ParsePatterns splits a comma separated list of glob patterns and checks
+// that each pattern is well formed.
+func ParsePatterns(s string) ([]string, error) {
+	var l []string
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %v", p, err)
+		}
+		l = append(l, p)
+	}
+	return l, nil
+}
+
+// ParseColumnPatterns is like ParsePatterns, but also checks that each
+// pattern has the form "table.column".
+func ParseColumnPatterns(s string) ([]string, error) {
+	l, err := ParsePatterns(s)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range l {
+		if i := strings.LastIndex(p, "."); i <= 0 || i == len(p)-1 {
+			return nil, fmt.Errorf("invalid column pattern %q: expected table.column", p)
+		}
+	}
+	return l, nil
+}
+
+// IncludeTable returns true if source table srcTable passes conv's table
+// filters i.e. it is in one of the selected schemas and matches the include
+// list (when these are specified), and does not match the exclude list.
+func (conv *Conv) IncludeTable(srcTable string) bool {
+	f := conv.Filters
+	if len(f.Schemas) > 0 {
+		schema, _ := SplitSchema(srcTable)
+		if schema == "" {
+			schema = conv.defaultSchema()
+		}
+		if !matchAny(f.Schemas, schema) {
+			return false
+		}
+	}
+	if len(f.Tables) > 0 && !matchAny(f.Tables, srcTable) {
+		return false
+	}
+	return !matchAny(f.ExcludeTables, srcTable)
+}
+
+// defaultSchema returns the schema of source tables whose names aren't
+// schema qualified.
+func (conv *Conv) defaultSchema() string {
+	if conv.DefaultSchema != "" {
+		return conv.DefaultSchema
+	}
+	return "public" // PostgreSQL's default schema.
+}
+
+// DropsColumn returns true if the column filters in f drop column srcCol
+// of table srcTable. Note that primary key columns are never dropped: use
+// conv.IncludeColumn to decide whether a column is converted.
+func (f Filters) DropsColumn(srcTable, srcCol string) bool {
+	projected, matched := false, false
+	for _, p := range f.Columns {
+		t, c := splitColumnPattern(p)
+		if ok, _ := path.Match(t, srcTable); !ok {
+			continue
+		}
+		projected = true
+		if ok, _ := path.Match(c, srcCol); ok {
+			matched = true
+			break
+		}
+	}
+	if projected && !matched {
+		return true
+	}
+	for _, p := range f.ExcludeColumns {
+		t, c := splitColumnPattern(p)
+		okT, _ := path.Match(t, srcTable)
+		okC, _ := path.Match(c, srcCol)
+		if okT && okC {
+			return true
+		}
+	}
+	return false
+}
+
+// IncludeColumn returns true if column srcCol of source table srcTable
+// passes conv's column filters. Primary key columns are always included.
+func (conv *Conv) IncludeColumn(srcTable, srcCol string) bool {
+	f := conv.Filters
+	if len(f.Columns) == 0 && len(f.ExcludeColumns) == 0 {
+		return true
+	}
+	for _, k := range conv.SrcSchema[srcTable].PrimaryKeys {
+		if k.Column == srcCol {
+			return true
+		}
+	}
+	return !f.DropsColumn(srcTable, srcCol)
+}
+
+// IncludedColumns returns the subset of srcCols that passes conv's column
+// filters, preserving order.
+func (conv *Conv) IncludedColumns(srcTable string, srcCols []string) []string {
+	var l []string
+	for _, c := range srcCols {
+		if conv.IncludeColumn(srcTable, c) {
+			l = append(l, c)
+		}
+	}
+	return l
+}
+
+func splitColumnPattern(p string) (string, string) {
+	i := strings.LastIndex(p, ".")
+	return p[:i], p[i+1:]
+}
+
+func matchAny(patterns []string, s string) bool {
+	for _, p := range patterns {
+		// Patterns are validated by ParsePatterns, so we ignore errors.
+		if ok, _ := path.Match(p, s); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"testing"
+
+	"github.com/cloudspannerecosystem/harbourbridge/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePatterns(t *testing.T) {
+	l, err := ParsePatterns(" orders, order_* ,,public.*")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"orders", "order_*", "public.*"}, l)
+	_, err = ParsePatterns("orders,[a-")
+	assert.NotNil(t, err)
+}
+
+func TestIncludeTable(t *testing.T) {
+	tests := []struct {
+		name     string
+		filters  Filters
+		table    string
+		expected bool
+	}{
+		{"No filters", Filters{}, "orders", true},
+		{"Included", Filters{Tables: []string{"cart", "order*"}}, "orders", true},
+		{"Not included", Filters{Tables: []string{"cart", "order*"}}, "product", false},
+		{"Excluded", Filters{ExcludeTables: []string{"*_tmp"}}, "orders_tmp", false},
+		{"Not excluded", Filters{ExcludeTables: []string{"*_tmp"}}, "orders", true},
+		{"Exclude wins", Filters{Tables: []string{"order*"}, ExcludeTables: []string{"*_tmp"}}, "orders_tmp", false},
+		{"Schema qualified", Filters{Tables: []string{"sales.*"}}, "sales.orders", true},
+		{"Schema selected", Filters{Schemas: []string{"sales", "hr"}}, "sales.orders", true},
+		{"Schema not selected", Filters{Schemas: []string{"sales", "hr"}}, "ops.orders", false},
+		{"Default schema not selected", Filters{Schemas: []string{"sales"}}, "orders", false},
+		{"Default schema selected", Filters{Schemas: []string{"public"}}, "orders", true},
+	}
+	for _, tc := range tests {
+		conv := MakeConv()
+		conv.Filters = tc.filters
+		assert.Equal(t, tc.expected, conv.IncludeTable(tc.table), tc.name)
+	}
+}
+
+func TestParseColumnPatterns(t *testing.T) {
+	l, err := ParseColumnPatterns("*.ssn, users.legacy_*,public.users.name")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"*.ssn", "users.legacy_*", "public.users.name"}, l)
+	for _, s := range []string{"ssn", ".ssn", "users.", "users.[a-"} {
+		_, err = ParseColumnPatterns(s)
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestIncludeColumn(t *testing.T) {
+	tests := []struct {
+		name     string
+		filters  Filters
+		table    string
+		col      string
+		expected bool
+	}{
+		{"No filters", Filters{}, "users", "ssn", true},
+		{"Excluded", Filters{ExcludeColumns: []string{"*.ssn"}}, "users", "ssn", false},
+		{"Not excluded", Filters{ExcludeColumns: []string{"*.ssn"}}, "users", "name", true},
+		{"Projected", Filters{Columns: []string{"users.name"}}, "users", "name", true},
+		{"Not projected", Filters{Columns: []string{"users.name"}}, "users", "ssn", false},
+		{"Other table", Filters{Columns: []string{"users.name"}}, "orders", "total", true},
+		{"Exclude wins", Filters{Columns: []string{"users.*"}, ExcludeColumns: []string{"users.ssn"}}, "users", "ssn", false},
+		{"Primary key excluded", Filters{ExcludeColumns: []string{"*.id"}}, "users", "id", true},
+		{"Primary key not projected", Filters{Columns: []string{"users.name"}}, "users", "id", true},
+	}
+	for _, tc := range tests {
+		conv := MakeConv()
+		conv.SrcSchema["users"] = schema.Table{Name: "users", PrimaryKeys: []schema.Key{{Column: "id"}}}
+		conv.Filters = tc.filters
+		assert.Equal(t, tc.expected, conv.IncludeColumn(tc.table, tc.col), tc.name)
+	}
+	conv := MakeConv()
+	conv.SrcSchema["users"] = schema.Table{Name: "users", PrimaryKeys: []schema.Key{{Column: "id"}}}
+	conv.Filters = Filters{ExcludeColumns: []string{"users.ssn"}}
+	assert.Equal(t, []string{"id", "name"}, conv.IncludedColumns("users", []string{"id", "ssn", "name"}))
+	assert.True(t, conv.Filters.DropsColumn("users", "ssn"))
+}
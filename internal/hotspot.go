@@ -0,0 +1,143 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	"github.com/cloudspannerecosystem/harbourbridge/spanner/ddl"
+)
+
+// Remedies that can be applied to primary keys that cause hotspots.
+// Bit-reversing key values and using UUIDs are also good remedies, but
+// they change key values (and hence all references to them), so we only
+// suggest them in the report.
+const (
+	HotspotRemedyReorder = "reorder" // Move the hotspot column to the end of the primary key.
+	HotspotRemedyShard   = "shard"   // Prefix the primary key with a hash-based shard column.
+)
+
+// HotspotShardCount is the number of distinct values of shard columns.
+const HotspotShardCount = 16
+
+// HotspotShard specifies a shard column that was added to the primary key
+// of a Spanner table. Its value is computed from the value of KeyCol.
+type HotspotShard struct {
+	Col    string
+	KeyCol string
+	Count  int64
+}
+
+// CheckHotspotRemedy returns an error if remedy isn't a supported
+// remedy for hotspots.
+func CheckHotspotRemedy(remedy string) error {
+	switch remedy {
+	case "", HotspotRemedyReorder, HotspotRemedyShard:
+		return nil
+	}
+	return fmt.Errorf("unknown hotspot remedy %q, expected %s or %s", remedy, HotspotRemedyReorder, HotspotRemedyShard)
+}
+
+// MarkHotspot records that source column srcCol, the leading primary key
+// column of srcTable, causes hotspots in Spanner table spTable for reason
+// issue, and applies conv.HotspotRemedy (if any) to the Spanner primary key.
+// The remedy is skipped for tables that are interleaved or have interleaved
+// children, since their primary keys must keep the parent key as a prefix.
+func (conv *Conv) MarkHotspot(srcTable, srcCol, spTable string, issue SchemaIssue) {
+	if conv.Issues[srcTable] == nil {
+		conv.Issues[srcTable] = make(map[string][]SchemaIssue)
+	}
+	if conv.IsHotspot(srcTable, srcCol) {
+		return
+	}
+	conv.Issues[srcTable][srcCol] = append(conv.Issues[srcTable][srcCol], issue)
+	ct, ok := conv.SpSchema[spTable]
+	if !ok || len(ct.Pks) == 0 || conv.HotspotRemedy == "" {
+		return
+	}
+	if ct.Parent != "" || conv.hasInterleavedChildren(spTable) {
+		conv.Issues[srcTable][srcCol] = append(conv.Issues[srcTable][srcCol], HotspotInterleaved)
+		return
+	}
+	switch conv.HotspotRemedy {
+	case HotspotRemedyReorder:
+		if len(ct.Pks) < 2 {
+			return
+		}
+		ct.Pks = append(ct.Pks[1:len(ct.Pks):len(ct.Pks)], ct.Pks[0])
+	case HotspotRemedyShard:
+		col := conv.hotspotShardCol(ct)
+		ct.ColNames = append([]string{col}, ct.ColNames...)
+		ct.ColDefs[col] = ddl.ColumnDef{Name: col, T: ddl.Type{Name: ddl.Int64}, NotNull: true}
+		ct.Pks = append([]ddl.IndexKey{{Col: col}}, ct.Pks...)
+		if conv.HotspotShards == nil {
+			conv.HotspotShards = make(map[string]HotspotShard)
+		}
+		conv.HotspotShards[spTable] = HotspotShard{Col: col, KeyCol: ct.Pks[1].Col, Count: HotspotShardCount}
+	default:
+		return
+	}
+	conv.SpSchema[spTable] = ct
+	conv.Issues[srcTable][srcCol] = append(conv.Issues[srcTable][srcCol], HotspotRemedied)
+}
+
+// IsHotspot returns true if source column srcCol of srcTable has been
+// marked as causing hotspots.
+func (conv *Conv) IsHotspot(srcTable, srcCol string) bool {
+	for _, i := range conv.Issues[srcTable][srcCol] {
+		if i == SequentialKey || i == TimestampKey {
+			return true
+		}
+	}
+	return false
+}
+
+func (conv *Conv) hasInterleavedChildren(spTable string) bool {
+	for _, ct := range conv.SpSchema {
+		if ct.Parent == spTable {
+			return true
+		}
+	}
+	return false
+}
+
+func (conv *Conv) hotspotShardCol(ct ddl.CreateTable) string {
+	base := "shard_id"
+	key := base
+	for count := 0; ; count++ {
+		if _, ok := ct.ColDefs[key]; !ok {
+			return key
+		}
+		key = fmt.Sprintf("%s%d", base, count)
+	}
+}
+
+// addShardValue appends the value of spTable's shard column (if any) to
+// spCols and spVals.
+func (conv *Conv) addShardValue(spTable string, spCols []string, spVals []interface{}) ([]string, []interface{}) {
+	shard, ok := conv.HotspotShards[spTable]
+	if !ok {
+		return spCols, spVals
+	}
+	for i, c := range spCols {
+		if c == shard.KeyCol {
+			h := fnv.New64a()
+			fmt.Fprintf(h, "%v", spVals[i])
+			return append(spCols[:len(spCols):len(spCols)], shard.Col), append(spVals[:len(spVals):len(spVals)], int64(h.Sum64()%uint64(shard.Count)))
+		}
+	}
+	return spCols, spVals
+}
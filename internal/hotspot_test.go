@@ -0,0 +1,146 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudspannerecosystem/harbourbridge/spanner/ddl"
+)
+
+func mkHotspotConv(remedy string) *Conv {
+	conv := MakeConv()
+	conv.HotspotRemedy = remedy
+	conv.SpSchema["events"] = ddl.CreateTable{
+		Name:     "events",
+		ColNames: []string{"id", "kind", "shard_id"},
+		ColDefs: map[string]ddl.ColumnDef{
+			"id":       {Name: "id", T: ddl.Type{Name: ddl.Int64}, NotNull: true},
+			"kind":     {Name: "kind", T: ddl.Type{Name: ddl.String, Len: ddl.MaxLength}, NotNull: true},
+			"shard_id": {Name: "shard_id", T: ddl.Type{Name: ddl.Int64}},
+		},
+		Pks: []ddl.IndexKey{{Col: "id"}, {Col: "kind"}},
+	}
+	return conv
+}
+
+func TestMarkHotspot(t *testing.T) {
+	conv := mkHotspotConv("")
+	conv.MarkHotspot("events", "id", "events", SequentialKey)
+	conv.MarkHotspot("events", "id", "events", TimestampKey)
+	assert.Equal(t, []SchemaIssue{SequentialKey}, conv.Issues["events"]["id"])
+	assert.True(t, conv.IsHotspot("events", "id"))
+	assert.False(t, conv.IsHotspot("events", "kind"))
+	assert.Equal(t, []ddl.IndexKey{{Col: "id"}, {Col: "kind"}}, conv.SpSchema["events"].Pks)
+
+	conv = mkHotspotConv(HotspotRemedyReorder)
+	conv.MarkHotspot("events", "id", "events", SequentialKey)
+	assert.Equal(t, []SchemaIssue{SequentialKey, HotspotRemedied}, conv.Issues["events"]["id"])
+	assert.Equal(t, []ddl.IndexKey{{Col: "kind"}, {Col: "id"}}, conv.SpSchema["events"].Pks)
+
+	conv = mkHotspotConv(HotspotRemedyShard)
+	conv.MarkHotspot("events", "id", "events", SequentialKey)
+	assert.Equal(t, []SchemaIssue{SequentialKey, HotspotRemedied}, conv.Issues["events"]["id"])
+	assert.Equal(t, []ddl.IndexKey{{Col: "shard_id0"}, {Col: "id"}, {Col: "kind"}}, conv.SpSchema["events"].Pks)
+	assert.Equal(t, []string{"shard_id0", "id", "kind", "shard_id"}, conv.SpSchema["events"].ColNames)
+	assert.Equal(t, ddl.ColumnDef{Name: "shard_id0", T: ddl.Type{Name: ddl.Int64}, NotNull: true}, conv.SpSchema["events"].ColDefs["shard_id0"])
+	assert.Equal(t, HotspotShard{Col: "shard_id0", KeyCol: "id", Count: HotspotShardCount}, conv.HotspotShards["events"])
+}
+
+func TestMarkHotspot_Interleaved(t *testing.T) {
+	for _, remedy := range []string{HotspotRemedyReorder, HotspotRemedyShard} {
+		// Parent of an interleaved table.
+		conv := mkHotspotConv(remedy)
+		conv.SpSchema["clicks"] = ddl.CreateTable{
+			Name:     "clicks",
+			ColNames: []string{"id", "kind", "seq"},
+			Pks:      []ddl.IndexKey{{Col: "id"}, {Col: "kind"}, {Col: "seq"}},
+			Parent:   "events",
+		}
+		conv.MarkHotspot("events", "id", "events", SequentialKey)
+		assert.Equal(t, []SchemaIssue{SequentialKey, HotspotInterleaved}, conv.Issues["events"]["id"])
+		assert.Equal(t, []ddl.IndexKey{{Col: "id"}, {Col: "kind"}}, conv.SpSchema["events"].Pks)
+		assert.Equal(t, []string{"id", "kind", "shard_id"}, conv.SpSchema["events"].ColNames)
+		assert.Empty(t, conv.HotspotShards)
+
+		// Interleaved table.
+		conv.MarkHotspot("clicks", "id", "clicks", SequentialKey)
+		assert.Equal(t, []SchemaIssue{SequentialKey, HotspotInterleaved}, conv.Issues["clicks"]["id"])
+		assert.Equal(t, []ddl.IndexKey{{Col: "id"}, {Col: "kind"}, {Col: "seq"}}, conv.SpSchema["clicks"].Pks)
+	}
+}
+
+func TestWriteRow_HotspotShard(t *testing.T) {
+	conv := mkHotspotConv(HotspotRemedyShard)
+	conv.MarkHotspot("events", "id", "events", SequentialKey)
+	conv.SetDataMode()
+	var rows [][]interface{}
+	conv.SetDataSink(func(table string, cols []string, vals []interface{}) {
+		assert.Equal(t, []string{"id", "kind", "shard_id0"}, cols)
+		rows = append(rows, vals)
+	})
+	for i := int64(0); i < 100; i++ {
+		conv.WriteRow("events", "events", []string{"id", "kind"}, []interface{}{i, "click"})
+	}
+	shards := make(map[int64]bool)
+	for _, r := range rows {
+		shard := r[2].(int64)
+		assert.True(t, shard >= 0 && shard < HotspotShardCount)
+		shards[shard] = true
+	}
+	// Consecutive keys are spread across shards.
+	assert.True(t, len(shards) > 1)
+
+	// Shard values are deterministic.
+	var again [][]interface{}
+	conv.SetDataSink(func(table string, cols []string, vals []interface{}) {
+		again = append(again, vals)
+	})
+	conv.WriteRow("events", "events", []string{"id", "kind"}, []interface{}{int64(0), "click"})
+	assert.Equal(t, rows[0], again[0])
+}
+
+func TestDeleteRow_HotspotShard(t *testing.T) {
+	conv := mkHotspotConv(HotspotRemedyShard)
+	conv.MarkHotspot("events", "id", "events", SequentialKey)
+	conv.SetDataMode()
+	var written []interface{}
+	conv.SetDataSink(func(table string, cols []string, vals []interface{}) {
+		written = vals
+	})
+	var deleted []interface{}
+	conv.SetDeleteSink(func(table string, keyCols []string, key []interface{}) {
+		assert.Equal(t, []string{"shard_id0", "id", "kind"}, keyCols)
+		deleted = key
+	})
+	conv.WriteRow("events", "events", []string{"id", "kind"}, []interface{}{int64(7), "click"})
+	conv.DeleteRow("events", "events", []string{"id", "kind"}, []interface{}{int64(7), "click"})
+	// The key of the deleted row includes the shard the row was written to.
+	assert.Equal(t, []interface{}{written[2], int64(7), "click"}, deleted)
+	assert.Equal(t, int64(2), conv.Stats.GoodRows["events"])
+
+	// Rows missing a primary key column can't be deleted.
+	conv.DeleteRow("events", "events", []string{"kind"}, []interface{}{"click"})
+	assert.Equal(t, int64(1), conv.Stats.BadRows["events"])
+}
+
+func TestCheckHotspotRemedy(t *testing.T) {
+	assert.Nil(t, CheckHotspotRemedy(""))
+	assert.Nil(t, CheckHotspotRemedy(HotspotRemedyReorder))
+	assert.Nil(t, CheckHotspotRemedy(HotspotRemedyShard))
+	assert.NotNil(t, CheckHotspotRemedy("uuid"))
+}
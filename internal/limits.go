@@ -0,0 +1,47 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CheckLimits checks conv's Spanner schema against Spanner's schema limits
+// and records violations in conv.LimitIssues, keyed by source table, so
+// they appear in the report. It returns an error listing the violations, if
+// any, which callers can use to block database creation.
+func (conv *Conv) CheckLimits() error {
+	conv.LimitIssues = make(map[string][]string)
+	var l []string
+	for _, v := range conv.SpSchema.CheckLimits() {
+		srcTable := v.Table
+		if ts, ok := conv.ToSource[v.Table]; ok {
+			srcTable = ts.Name
+		}
+		msg := v.Msg
+		if v.Col != "" {
+			msg = fmt.Sprintf("Column '%s': %s", v.Col, msg)
+		}
+		conv.LimitIssues[srcTable] = append(conv.LimitIssues[srcTable], msg)
+		l = append(l, fmt.Sprintf("table %s: %s", v.Table, msg))
+	}
+	if len(l) == 0 {
+		return nil
+	}
+	sort.Strings(l)
+	return fmt.Errorf("schema violates Spanner limits: %s", strings.Join(l, "; "))
+}
@@ -0,0 +1,69 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudspannerecosystem/harbourbridge/schema"
+	"github.com/cloudspannerecosystem/harbourbridge/spanner/ddl"
+)
+
+func TestCheckLimits(t *testing.T) {
+	conv := MakeConv()
+	conv.SrcSchema["Order"] = schema.Table{
+		Name:     "Order",
+		ColNames: []string{"id", "notes"},
+		ColDefs: map[string]schema.Column{
+			"id":    {Name: "id", Type: schema.Type{Name: "bigint"}},
+			"notes": {Name: "notes", Type: schema.Type{Name: "blob"}},
+		},
+	}
+	spTable, _ := GetSpannerTable(conv, "Order")
+	spCols, _ := GetSpannerCols(conv, "Order", []string{"id", "notes"})
+	conv.SpSchema[spTable] = ddl.CreateTable{
+		Name:     spTable,
+		ColNames: spCols,
+		ColDefs: map[string]ddl.ColumnDef{
+			"id":    {Name: "id", T: ddl.Type{Name: ddl.Int64}},
+			"notes": {Name: "notes", T: ddl.Type{Name: ddl.Bytes, Len: 20000000}},
+		},
+		Pks: []ddl.IndexKey{{Col: "id"}},
+	}
+	err := conv.CheckLimits()
+	assert.NotNil(t, err)
+	assert.Equal(t, map[string][]string{"Order": {
+		"table name Order is a reserved keyword, so it must be quoted with backticks in queries",
+		"Column 'notes': BYTES length 20000000 is more than the limit of 10485760",
+	}}, conv.LimitIssues)
+
+	tr := buildTableReport(conv, "Order", nil)
+	assert.Equal(t, int64(2), tr.Warnings)
+	assert.Equal(t, []tableReportBody{{Heading: "Warnings", Lines: []string{
+		"Spanner limit: table name Order is a reserved keyword, so it must be quoted with backticks in queries",
+		"Spanner limit: Column 'notes': BYTES length 20000000 is more than the limit of 10485760",
+	}}}, tr.Body)
+
+	// Violations are recomputed on each call.
+	ct := conv.SpSchema[spTable]
+	ct.ColDefs["notes"] = ddl.ColumnDef{Name: "notes", T: ddl.Type{Name: ddl.Bytes, Len: ddl.MaxLength}}
+	delete(conv.SpSchema, spTable)
+	ct.Name = "orders"
+	conv.SpSchema["orders"] = ct
+	assert.Nil(t, conv.CheckLimits())
+	assert.Empty(t, conv.LimitIssues)
+}
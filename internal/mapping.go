@@ -30,6 +30,8 @@ import (
 // a) the new table name is legal
 // b) the new table name doesn't clash with other Spanner table names
 // c) we consistently return the same name for this table.
+// Before legalizing the name, we apply conv.NamingPolicy, which may also
+// rename reserved keywords.
 //
 // conv.UsedNames tracks Spanner names that have been used for table names, foreign key constraints
 // and indexes. We use this to ensure we generate unique names when
@@ -43,7 +45,7 @@ func GetSpannerTable(conv *Conv, srcTable string) (string, error) {
 	if sp, found := conv.ToSpanner[srcTable]; found {
 		return sp.Name, nil
 	}
-	spTable := getSpannerId(conv, srcTable)
+	spTable := uniqueSpannerId(conv, conv.spannerTable(srcTable))
 	if spTable != srcTable {
 		VerbosePrintf("Mapping source DB table %s to Spanner table %s\n", srcTable, spTable)
 	}
@@ -61,6 +63,8 @@ func GetSpannerTable(conv *Conv, srcTable string) (string, error) {
 // a) the new col name is legal
 // b) the new col name doesn't clash with other col names in the same table
 // c) we consistently return the same name for the same col.
+// Before legalizing the name, we apply conv.NamingPolicy. Columns renamed
+// because they are reserved keywords get a ReservedKeyword issue.
 func GetSpannerCol(conv *Conv, srcTable, srcCol string, mustExist bool) (string, error) {
 	if srcTable == "" {
 		return "", fmt.Errorf("bad parameter: table string is empty")
@@ -84,7 +88,14 @@ func GetSpannerCol(conv *Conv, srcTable, srcCol string, mustExist bool) (string,
 	if mustExist {
 		return "", fmt.Errorf("table %s does not have a column %s", srcTable, srcCol)
 	}
-	spCol, _ := FixName(srcCol)
+	spCol, _ := FixName(conv.NamingPolicy.name(srcCol))
+	if renamed := conv.renameReserved(spCol); renamed != spCol {
+		spCol = renamed
+		if conv.Issues[srcTable] == nil {
+			conv.Issues[srcTable] = make(map[string][]SchemaIssue)
+		}
+		conv.Issues[srcTable][srcCol] = append(conv.Issues[srcTable][srcCol], ReservedKeyword)
+	}
 	if _, found := conv.ToSource[sp.Name].Cols[spCol]; found {
 		// spCol has been used before i.e. FixName caused a collision.
 		// Add unique postfix: use number of cols in this table so far.
@@ -139,7 +150,7 @@ func ToSpannerForeignKey(conv *Conv, srcId string) string {
 	if srcId == "" {
 		return ""
 	}
-	return getSpannerId(conv, srcId)
+	return getSpannerId(conv, conv.NamingPolicy.name(srcId))
 }
 
 // ToSpannerIndexName maps source index name to legal Spanner index name.
@@ -152,7 +163,7 @@ func ToSpannerForeignKey(conv *Conv, srcId string) string {
 // they only have to be unique for a table. Hence we must map each source
 // constraint name to a unique spanner constraint name.
 func ToSpannerIndexName(conv *Conv, srcId string) string {
-	return getSpannerId(conv, srcId)
+	return getSpannerId(conv, conv.NamingPolicy.name(srcId))
 }
 
 // conv.UsedNames tracks Spanner names that have been used for table names, foreign key constraints
@@ -161,6 +172,12 @@ func ToSpannerIndexName(conv *Conv, srcId string) string {
 // distinct and should not differ only in case.
 func getSpannerId(conv *Conv, srcId string) string {
 	spKeyName, _ := FixName(srcId)
+	return uniqueSpannerId(conv, conv.renameReserved(spKeyName))
+}
+
+// uniqueSpannerId makes legal Spanner name spKeyName unique (see
+// getSpannerId).
+func uniqueSpannerId(conv *Conv, spKeyName string) string {
 	if _, found := conv.UsedNames[strings.ToLower(spKeyName)]; found {
 		// spKeyName has been used before.
 		// Add unique postfix: use number of keys so far.
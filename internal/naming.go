@@ -0,0 +1,212 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/cloudspannerecosystem/harbourbridge/spanner/ddl"
+)
+
+// NamingPolicy specifies how source names are transformed into Spanner
+// names. It is applied to table, column, index and foreign key names
+// before they are legalized by FixName, so the uniqueness guarantees of
+// GetSpannerTable, GetSpannerCol, ToSpannerIndexName and
+// ToSpannerForeignKey still hold. The zero value leaves names unchanged.
+type NamingPolicy struct {
+	SnakeCase      bool     // Convert names to snake_case e.g. "OrderItems" becomes "order_items".
+	Lowercase      bool     // Convert names to lower case.
+	StripPrefixes  []string // Prefixes removed from table names e.g. "tbl_".
+	TablePrefix    string   // Prefix added to table names e.g. "sales_" when merging several Postgres schemas.
+	Schemas        string   // How source schemas are mapped: SchemasFlatten (the default), SchemasDrop or SchemasNamed.
+	RenameReserved bool     // Rename names that are Spanner reserved keywords by adding "_" e.g. "order" becomes "order_".
+}
+
+// Mappings of source schemas (e.g. PostgreSQL namespaces or MySQL databases)
+// to Spanner. Source tables outside the default schema have names of the
+// form "schema.table".
+const (
+	SchemasFlatten = "flatten" // Map "schema.table" to table "schema_table".
+	SchemasDrop    = "drop"    // Map "schema.table" to table "table".
+	SchemasNamed   = "named"   // Map "schema.table" to table "table" in Spanner named schema "schema".
+)
+
+// SplitSchema splits source table name srcTable into its schema and
+// unqualified table name. The schema is empty for tables in the default
+// schema.
+func SplitSchema(srcTable string) (string, string) {
+	i := strings.LastIndex(srcTable, ".")
+	if i < 0 {
+		return "", srcTable
+	}
+	return srcTable[:i], srcTable[i+1:]
+}
+
+// QualifyName places Spanner name id (e.g. an index name) in the named
+// schema of Spanner table spTable, if any.
+func QualifyName(spTable, id string) string {
+	schema, _ := SplitSchema(spTable)
+	if schema == "" {
+		return id
+	}
+	return schema + "." + id
+}
+
+// tableName applies policy p to source table name s.
+func (p NamingPolicy) tableName(s string) string {
+	// Prefixes are stripped from the unqualified table name, so that
+	// "sales.tbl_orders" becomes "sales.orders".
+	schema, name := SplitSchema(s)
+	for _, prefix := range p.StripPrefixes {
+		if strings.HasPrefix(name, prefix) && len(name) > len(prefix) {
+			name = name[len(prefix):]
+			break
+		}
+	}
+	switch {
+	case schema == "" || p.Schemas == SchemasDrop:
+		return p.TablePrefix + p.name(name)
+	case p.Schemas == SchemasNamed:
+		return p.name(schema) + "." + p.TablePrefix + p.name(name)
+	}
+	return p.TablePrefix + p.name(schema) + "." + p.name(name)
+}
+
+// spannerTable returns the legal (but not necessarily unique) Spanner
+// name for source table srcTable under policy p.
+func (p NamingPolicy) spannerTable(srcTable string) string {
+	id := p.tableName(srcTable)
+	schema, name := SplitSchema(id)
+	if schema == "" || p.Schemas != SchemasNamed {
+		id, _ = FixName(id)
+		return id
+	}
+	schema, _ = FixName(schema)
+	name, _ = FixName(name)
+	return schema + "." + name
+}
+
+// spannerTable returns the legal (but not necessarily unique) Spanner
+// name for source table srcTable under conv's naming policy, including the
+// renaming of reserved keywords.
+func (conv *Conv) spannerTable(srcTable string) string {
+	id := conv.NamingPolicy.spannerTable(srcTable)
+	if !conv.NamingPolicy.RenameReserved {
+		return id
+	}
+	parts := strings.Split(id, ".")
+	for i := range parts {
+		parts[i] = conv.renameReserved(parts[i])
+	}
+	return strings.Join(parts, ".")
+}
+
+// targetExperimentalPostgres is conversion.TARGET_EXPERIMENTAL_POSTGRES,
+// which can't be used here because of an import cycle.
+const targetExperimentalPostgres = "experimental_postgres"
+
+// isReserved returns true if id is a reserved keyword of conv's target
+// Spanner dialect.
+func (conv *Conv) isReserved(id string) bool {
+	if conv.TargetDb == targetExperimentalPostgres {
+		return ddl.IsPGReservedKeyword(id)
+	}
+	return ddl.IsReservedKeyword(id)
+}
+
+// renameReserved adds "_" to legal Spanner name id if it is a reserved
+// keyword and conv's naming policy renames reserved keywords, so that
+// queries can use the name without quoting it.
+func (conv *Conv) renameReserved(id string) string {
+	if conv.NamingPolicy.RenameReserved && conv.isReserved(id) {
+		return id + "_"
+	}
+	return id
+}
+
+// CheckSchemaCollisions returns an error if tables from different source
+// schemas are mapped to the same Spanner table name under conv's naming
+// policy e.g. "sales.orders" and "sales_orders" are both mapped to
+// "sales_orders" when schemas are flattened. Such collisions are rejected
+// rather than resolved by renaming tables, since renamed tables are easy to
+// miss.
+func (conv *Conv) CheckSchemaCollisions(srcTables []string) error {
+	sort.Strings(srcTables)
+	seen := make(map[string]string)
+	var l []string
+	for _, srcTable := range srcTables {
+		spTable := strings.ToLower(conv.spannerTable(srcTable))
+		other, ok := seen[spTable]
+		if !ok {
+			seen[spTable] = srcTable
+			continue
+		}
+		s1, _ := SplitSchema(other)
+		s2, _ := SplitSchema(srcTable)
+		if s1 != s2 {
+			l = append(l, fmt.Sprintf("%s and %s both map to %s", other, srcTable, conv.spannerTable(srcTable)))
+		}
+	}
+	if len(l) > 0 {
+		return fmt.Errorf("source tables from different schemas have the same Spanner name: %s; use a different schema mapping or table filters", strings.Join(l, ", "))
+	}
+	return nil
+}
+
+// name applies the case conversions of policy p to source name s.
+func (p NamingPolicy) name(s string) string {
+	if p.SnakeCase {
+		s = toSnakeCase(s)
+	}
+	if p.Lowercase {
+		s = strings.ToLower(s)
+	}
+	return s
+}
+
+// toSnakeCase converts s to snake_case: words are lower-cased and
+// separated by "_". Word boundaries are lower-to-upper case transitions
+// ("orderId" becomes "order_id"), the end of an acronym ("HTTPServer"
+// becomes "http_server") and runs of non-alphanumeric characters.
+func toSnakeCase(s string) string {
+	var b strings.Builder
+	r := []rune(s)
+	sep := false
+	for i, c := range r {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			sep = b.Len() > 0
+			continue
+		}
+		if unicode.IsUpper(c) && i > 0 {
+			prev := r[i-1]
+			next := i+1 < len(r) && unicode.IsLower(r[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && next) {
+				sep = b.Len() > 0
+			}
+		}
+		if sep {
+			b.WriteRune('_')
+			sep = false
+		}
+		b.WriteRune(unicode.ToLower(c))
+	}
+	if b.Len() == 0 {
+		return s
+	}
+	return b.String()
+}
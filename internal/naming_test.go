@@ -0,0 +1,162 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToSnakeCase(t *testing.T) {
+	tests := []struct {
+		in, out string
+	}{
+		{"orders", "orders"},
+		{"order_items", "order_items"},
+		{"OrderItems", "order_items"},
+		{"orderId", "order_id"},
+		{"HTTPServer", "http_server"},
+		{"userID2", "user_id2"},
+		{"Order Items", "order_items"},
+		{"sales.OrderItems", "sales_order_items"},
+		{"__id", "id"},
+		{"??", "??"},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.out, toSnakeCase(tc.in), tc.in)
+	}
+}
+
+func TestNamingPolicy(t *testing.T) {
+	conv := MakeConv()
+	conv.NamingPolicy = NamingPolicy{SnakeCase: true, StripPrefixes: []string{"tbl_", "t_"}, TablePrefix: "sales_"}
+	tests := []struct {
+		name     string // Name of test.
+		srcTable string // Source DB table name to test.
+		spTable  string // Expected Spanner table name.
+	}{
+		{"Snake case", "OrderItems", "sales_order_items"},
+		{"Strip prefix", "tbl_Customers", "sales_customers"},
+		{"Strip second prefix", "t_Products", "sales_products"},
+		{"Prefix is the whole name", "tbl_", "sales_tbl"},
+		{"Schema qualified", "shop.tbl_Carts", "sales_shop_carts"},
+		{"Collision", "order_items", "sales_order_items_5"},
+	}
+	for _, tc := range tests {
+		spTable, err := GetSpannerTable(conv, tc.srcTable)
+		assert.Nil(t, err, tc.name)
+		assert.Equal(t, tc.spTable, spTable, tc.name)
+		assert.Equal(t, tc.srcTable, conv.ToSource[spTable].Name, tc.name)
+	}
+	spCol, err := GetSpannerCol(conv, "OrderItems", "ProductID", false)
+	assert.Nil(t, err)
+	assert.Equal(t, "product_id", spCol)
+	spCol, err = GetSpannerCol(conv, "OrderItems", "product_id", false)
+	assert.Nil(t, err)
+	assert.Equal(t, "product_id_1", spCol)
+	assert.Equal(t, "ProductID", conv.ToSource["sales_order_items"].Cols["product_id"])
+	assert.Equal(t, "idx_order_items", ToSpannerIndexName(conv, "IdxOrderItems"))
+	assert.Equal(t, "fk_customer", ToSpannerForeignKey(conv, "FkCustomer"))
+
+	conv = MakeConv()
+	conv.NamingPolicy = NamingPolicy{Lowercase: true}
+	spTable, err := GetSpannerTable(conv, "OrderItems")
+	assert.Nil(t, err)
+	assert.Equal(t, "orderitems", spTable)
+}
+
+func TestNamingPolicy_Schemas(t *testing.T) {
+	tests := []struct {
+		name     string // Name of test.
+		schemas  string // Schema mapping.
+		srcTable string // Source DB table name to test.
+		spTable  string // Expected Spanner table name.
+	}{
+		{"Flatten by default", "", "sales.orders", "sales_orders"},
+		{"Flatten", SchemasFlatten, "sales.orders", "sales_orders"},
+		{"Drop", SchemasDrop, "sales.orders", "orders"},
+		{"Named", SchemasNamed, "sales.orders", "sales.orders"},
+		{"Named with illegal names", SchemasNamed, "sales-eu.order items", "sales_eu.order_items"},
+		{"Default schema", SchemasNamed, "orders", "orders"},
+	}
+	for _, tc := range tests {
+		conv := MakeConv()
+		conv.NamingPolicy = NamingPolicy{Schemas: tc.schemas}
+		spTable, err := GetSpannerTable(conv, tc.srcTable)
+		assert.Nil(t, err, tc.name)
+		assert.Equal(t, tc.spTable, spTable, tc.name)
+	}
+	assert.Equal(t, "sales.idx_orders", QualifyName("sales.orders", "idx_orders"))
+	assert.Equal(t, "idx_orders", QualifyName("orders", "idx_orders"))
+}
+
+func TestCheckSchemaCollisions(t *testing.T) {
+	conv := MakeConv()
+	tables := []string{"sales_orders", "sales.orders", "hr.orders", "Orders", "orders"}
+	err := conv.CheckSchemaCollisions(tables)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "sales.orders and sales_orders both map to sales_orders")
+	// Collisions within a schema are resolved by renaming.
+	assert.NotContains(t, err.Error(), "Orders and orders")
+
+	conv.NamingPolicy = NamingPolicy{Schemas: SchemasNamed}
+	assert.Nil(t, conv.CheckSchemaCollisions(tables))
+
+	conv.NamingPolicy = NamingPolicy{Schemas: SchemasDrop}
+	err = conv.CheckSchemaCollisions(tables)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "Orders and hr.orders both map to orders")
+}
+
+func TestNamingPolicy_RenameReserved(t *testing.T) {
+	conv := MakeConv()
+	conv.NamingPolicy = NamingPolicy{RenameReserved: true, Schemas: SchemasNamed}
+	spTable, err := GetSpannerTable(conv, "Order")
+	assert.Nil(t, err)
+	assert.Equal(t, "Order_", spTable)
+	assert.Equal(t, "Order", conv.ToSource["Order_"].Name)
+	spTable, err = GetSpannerTable(conv, "select.users")
+	assert.Nil(t, err)
+	assert.Equal(t, "select_.users", spTable)
+	spCol, err := GetSpannerCol(conv, "Order", "from", false)
+	assert.Nil(t, err)
+	assert.Equal(t, "from_", spCol)
+	assert.Equal(t, "from", conv.ToSource["Order_"].Cols["from_"])
+	assert.Equal(t, []SchemaIssue{ReservedKeyword}, conv.Issues["Order"]["from"])
+	// User is only reserved in the PostgreSQL dialect.
+	spCol, err = GetSpannerCol(conv, "Order", "user", false)
+	assert.Nil(t, err)
+	assert.Equal(t, "user", spCol)
+	assert.Equal(t, "limit_", ToSpannerIndexName(conv, "limit"))
+
+	conv = MakeConv()
+	conv.TargetDb = "experimental_postgres"
+	conv.NamingPolicy = NamingPolicy{RenameReserved: true}
+	_, err = GetSpannerTable(conv, "t")
+	assert.Nil(t, err)
+	spCol, err = GetSpannerCol(conv, "t", "user", false)
+	assert.Nil(t, err)
+	assert.Equal(t, "user_", spCol)
+	spCol, err = GetSpannerCol(conv, "t", "struct", false)
+	assert.Nil(t, err)
+	assert.Equal(t, "struct", spCol)
+
+	// Without the policy, names are kept.
+	conv = MakeConv()
+	spTable, err = GetSpannerTable(conv, "Order")
+	assert.Nil(t, err)
+	assert.Equal(t, "Order", spTable)
+}
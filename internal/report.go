@@ -39,6 +39,14 @@ func GenerateReport(driverName string, conv *Conv, w *bufio.Writer, badWrites ma
 			strings.Join(ignored, ", ")), 80, 0)
 		w.WriteString("\n\n")
 	}
+	if conv.DataMode() && conv.WriteMode() != "" {
+		justifyLines(w, fmt.Sprintf("Rows were written to Spanner using write mode %s.", conv.WriteMode()), 80, 0)
+		w.WriteString("\n\n")
+	}
+	if conv.DataMode() && conv.Snapshot() != nil {
+		justifyLines(w, fmt.Sprintf("Source data was read from a consistent snapshot (%s).", conv.Snapshot()), 80, 0)
+		w.WriteString("\n\n")
+	}
 	statementsMsg := ""
 	var isDump bool
 	if strings.Contains(driverName, "dump") {
@@ -160,6 +168,11 @@ func buildTableReportBody(conv *Conv, srcTable string, issues map[string][]Schem
 				l = append(l, fmt.Sprintf("Column '%s' was added because this table didn't have a primary key. Spanner requires a primary key for every table", *syntheticPK))
 			}
 		}
+		if p.severity == warning {
+			for _, v := range conv.LimitIssues[srcTable] {
+				l = append(l, "Spanner limit: "+v)
+			}
+		}
 		issueBatcher := make(map[SchemaIssue]bool)
 		for _, srcCol := range cols {
 			for _, i := range issues[srcCol] {
@@ -175,7 +188,7 @@ func buildTableReportBody(conv *Conv, srcTable string, issues map[string][]Schem
 					issueBatcher[i] = true
 				}
 				spCol, err := GetSpannerCol(conv, srcTable, srcCol, true)
-				if err != nil {
+				if err != nil && conv.IncludeColumn(srcTable, srcCol) {
 					conv.Unexpected(err.Error())
 				}
 				srcType := srcSchema.ColDefs[srcCol].Type.Print()
@@ -201,6 +214,16 @@ func buildTableReportBody(conv *Conv, srcTable string, issues map[string][]Schem
 					l = append(l, fmt.Sprintf("Some columns have source DB type 'timestamp without timezone' which is mapped to Spanner type timestamp e.g. column '%s'. %s", srcCol, IssueDB[i].Brief))
 				case Datetime:
 					l = append(l, fmt.Sprintf("Some columns have source DB type 'datetime' which is mapped to Spanner type timestamp e.g. column '%s'. %s", srcCol, IssueDB[i].Brief))
+				case ExcludedTableForeignKey, ExcludedColumn, ExcludedColumnIndex, ExcludedColumnForeignKey, ExcludedPrimaryKey:
+					l = append(l, fmt.Sprintf("Column '%s': %s", srcCol, IssueDB[i].Brief))
+				case SequentialKey, TimestampKey:
+					l = append(l, fmt.Sprintf("Column '%s': %s", srcCol, IssueDB[i].Brief))
+				case HotspotRemedied, HotspotInterleaved:
+					l = append(l, fmt.Sprintf("Column '%s': %s (remedy: %s)", srcCol, IssueDB[i].Brief, conv.HotspotRemedy))
+				case ReservedKeyword:
+					l = append(l, fmt.Sprintf("Column '%s': %s to %s", srcCol, IssueDB[i].Brief, spCol))
+				case TypeOverride, InvalidTypeOverride:
+					l = append(l, fmt.Sprintf("Column '%s': %s (source DB type %s, Spanner type %s)", srcCol, IssueDB[i].Brief, srcType, spType))
 				case Widened:
 					l = append(l, fmt.Sprintf("%s e.g. for column '%s', source DB type %s is mapped to Spanner type %s", IssueDB[i].Brief, srcCol, srcType, spType))
 				default:
@@ -250,20 +273,32 @@ var IssueDB = map[SchemaIssue]struct {
 	severity severity
 	batch    bool // Whether multiple instances of this issue are combined.
 }{
-	DefaultValue:          {Brief: "Some columns have default values which Spanner does not support", severity: warning, batch: true},
-	ForeignKey:            {Brief: "Spanner does not support foreign keys", severity: warning},
-	MultiDimensionalArray: {Brief: "Spanner doesn't support multi-dimensional arrays", severity: warning},
-	NoGoodType:            {Brief: "No appropriate Spanner type", severity: warning},
-	Numeric:               {Brief: "Spanner does not support numeric. This type mapping could lose precision and is not recommended for production use", severity: warning},
-	NumericThatFits:       {Brief: "Spanner does not support numeric, but this type mapping preserves the numeric's specified precision", severity: note},
-	Decimal:               {Brief: "Spanner does not support decimal. This type mapping could lose precision and is not recommended for production use", severity: warning},
-	DecimalThatFits:       {Brief: "Spanner does not support decimal, but this type mapping preserves the decimal's specified precision", severity: note},
-	Serial:                {Brief: "Spanner does not support autoincrementing types", severity: warning},
-	AutoIncrement:         {Brief: "Spanner does not support auto_increment attribute", severity: warning},
-	Timestamp:             {Brief: "Spanner timestamp is closer to PostgreSQL timestamptz", severity: note, batch: true},
-	Datetime:              {Brief: "Spanner timestamp is closer to MySQL timestamp", severity: note, batch: true},
-	Time:                  {Brief: "Spanner does not support time/year types", severity: note, batch: true},
-	Widened:               {Brief: "Some columns will consume more storage in Spanner", severity: note, batch: true},
+	DefaultValue:             {Brief: "Some columns have default values which Spanner does not support", severity: warning, batch: true},
+	ForeignKey:               {Brief: "Spanner does not support foreign keys", severity: warning},
+	MultiDimensionalArray:    {Brief: "Spanner doesn't support multi-dimensional arrays", severity: warning},
+	NoGoodType:               {Brief: "No appropriate Spanner type", severity: warning},
+	Numeric:                  {Brief: "Spanner does not support numeric. This type mapping could lose precision and is not recommended for production use", severity: warning},
+	NumericThatFits:          {Brief: "Spanner does not support numeric, but this type mapping preserves the numeric's specified precision", severity: note},
+	Decimal:                  {Brief: "Spanner does not support decimal. This type mapping could lose precision and is not recommended for production use", severity: warning},
+	DecimalThatFits:          {Brief: "Spanner does not support decimal, but this type mapping preserves the decimal's specified precision", severity: note},
+	Serial:                   {Brief: "Spanner does not support autoincrementing types", severity: warning},
+	AutoIncrement:            {Brief: "Spanner does not support auto_increment attribute", severity: warning},
+	Timestamp:                {Brief: "Spanner timestamp is closer to PostgreSQL timestamptz", severity: note, batch: true},
+	Datetime:                 {Brief: "Spanner timestamp is closer to MySQL timestamp", severity: note, batch: true},
+	Time:                     {Brief: "Spanner does not support time/year types", severity: note, batch: true},
+	Widened:                  {Brief: "Some columns will consume more storage in Spanner", severity: note, batch: true},
+	ExcludedTableForeignKey:  {Brief: "Foreign key references a table that is excluded from the conversion, so the constraint was dropped", severity: warning},
+	ExcludedColumn:           {Brief: "Column is excluded from the conversion", severity: note},
+	ExcludedColumnIndex:      {Brief: "Index uses a column that is excluded from the conversion, so the index was dropped", severity: warning},
+	ExcludedColumnForeignKey: {Brief: "Foreign key uses a column that is excluded from the conversion, so the constraint was dropped", severity: warning},
+	ExcludedPrimaryKey:       {Brief: "Column is filtered out by the column filters, but was kept because it is part of the primary key", severity: note},
+	TypeOverride:             {Brief: "Spanner type was set by the type overrides", severity: note},
+	InvalidTypeOverride:      {Brief: "Type override is not an allowed mapping for the source type, so it was ignored", severity: warning},
+	SequentialKey:            {Brief: "Leading primary key column has monotonically increasing values, which can cause hotspots in Spanner. Consider bit-reversing the key values, using a UUID, adding a hash-prefix shard column or reordering the primary key columns", severity: warning},
+	TimestampKey:             {Brief: "Leading primary key column is a timestamp, which can cause hotspots in Spanner. Consider adding a hash-prefix shard column or reordering the primary key columns", severity: warning},
+	HotspotRemedied:          {Brief: "Primary key was changed to avoid hotspots", severity: note},
+	HotspotInterleaved:       {Brief: "Primary key was not changed to avoid hotspots because the table is interleaved or has interleaved children", severity: warning},
+	ReservedKeyword:          {Brief: "Name is a Spanner reserved keyword, so it was renamed", severity: note},
 }
 
 type severity int
@@ -300,6 +335,7 @@ func analyzeCols(conv *Conv, srcTable, spTable string) (map[string][]SchemaIssue
 		}
 	}
 	warnings += int64(len(warningBatcher))
+	warnings += int64(len(conv.LimitIssues[srcTable]))
 	return m, int64(len(srcSchema.ColDefs)), warnings
 }
 
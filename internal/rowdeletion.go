@@ -0,0 +1,52 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/cloudspannerecosystem/harbourbridge/spanner/ddl"
+)
+
+// SetRowDeletionPolicies sets row deletion policies on conv's Spanner
+// schema. Policies are keyed by source table, and their Col is a source
+// column; both are mapped to Spanner names. Policies are validated by
+// ddl.Schema.CheckRowDeletionPolicies once they have all been set.
+func (conv *Conv) SetRowDeletionPolicies(policies map[string]ddl.RowDeletionPolicy) error {
+	var srcTables []string
+	for srcTable := range policies {
+		srcTables = append(srcTables, srcTable)
+	}
+	sort.Strings(srcTables)
+	for _, srcTable := range srcTables {
+		rdp := policies[srcTable]
+		sp, ok := conv.ToSpanner[srcTable]
+		if !ok {
+			return fmt.Errorf("can't set row deletion policy: unknown table %s", srcTable)
+		}
+		spCol, err := GetSpannerCol(conv, srcTable, rdp.Col, true)
+		if err != nil {
+			return fmt.Errorf("can't set row deletion policy: %v", err)
+		}
+		ct, ok := conv.SpSchema[sp.Name]
+		if !ok {
+			return fmt.Errorf("can't set row deletion policy: table %s is not in the Spanner schema", srcTable)
+		}
+		ct.RowDeletionPolicy = &ddl.RowDeletionPolicy{Col: spCol, Days: rdp.Days}
+		conv.SpSchema[sp.Name] = ct
+	}
+	return conv.SpSchema.CheckRowDeletionPolicies()
+}
@@ -0,0 +1,46 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudspannerecosystem/harbourbridge/spanner/ddl"
+)
+
+func TestSetRowDeletionPolicies(t *testing.T) {
+	conv := MakeConv()
+	conv.NamingPolicy = NamingPolicy{SnakeCase: true}
+	spTable, _ := GetSpannerTable(conv, "Events")
+	spCols, _ := GetSpannerCols(conv, "Events", []string{"id", "CreatedAt", "name"})
+	conv.SpSchema[spTable] = ddl.CreateTable{
+		Name:     spTable,
+		ColNames: spCols,
+		ColDefs: map[string]ddl.ColumnDef{
+			"id":         {Name: "id", T: ddl.Type{Name: ddl.Int64}},
+			"created_at": {Name: "created_at", T: ddl.Type{Name: ddl.Timestamp}},
+			"name":       {Name: "name", T: ddl.Type{Name: ddl.String, Len: ddl.MaxLength}},
+		},
+		Pks: []ddl.IndexKey{{Col: "id"}},
+	}
+	assert.Nil(t, conv.SetRowDeletionPolicies(map[string]ddl.RowDeletionPolicy{"Events": {Col: "CreatedAt", Days: 30}}))
+	assert.Equal(t, &ddl.RowDeletionPolicy{Col: "created_at", Days: 30}, conv.SpSchema["events"].RowDeletionPolicy)
+
+	assert.NotNil(t, conv.SetRowDeletionPolicies(map[string]ddl.RowDeletionPolicy{"Logs": {Col: "CreatedAt", Days: 30}}))
+	assert.NotNil(t, conv.SetRowDeletionPolicies(map[string]ddl.RowDeletionPolicy{"Events": {Col: "DeletedAt", Days: 30}}))
+	assert.NotNil(t, conv.SetRowDeletionPolicies(map[string]ddl.RowDeletionPolicy{"Events": {Col: "name", Days: 30}}))
+}
@@ -0,0 +1,71 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cloudspannerecosystem/harbourbridge/spanner/ddl"
+)
+
+// ReplaceSpSchema replaces conv's Spanner schema with s, typically a
+// hand-edited version of the generated schema. Data conversion relies on
+// the table and column mappings built during schema conversion, so s must
+// have the same tables and columns as the current Spanner schema. Column
+// types, NOT NULL constraints, primary keys, indexes, foreign keys and
+// interleaving can all be changed. Comments are preserved from the
+// current schema, since they aren't part of s.
+func (conv *Conv) ReplaceSpSchema(s ddl.Schema) error {
+	var l []string
+	for t := range s {
+		if _, ok := conv.SpSchema[t]; !ok {
+			l = append(l, fmt.Sprintf("table %s is not in the session's schema", t))
+		}
+	}
+	for t, old := range conv.SpSchema {
+		ct, ok := s[t]
+		if !ok {
+			l = append(l, fmt.Sprintf("table %s is missing", t))
+			continue
+		}
+		for _, c := range ct.ColNames {
+			if _, ok := old.ColDefs[c]; !ok {
+				l = append(l, fmt.Sprintf("column %s of table %s is not in the session's schema", c, t))
+			}
+		}
+		for _, c := range old.ColNames {
+			if _, ok := ct.ColDefs[c]; !ok {
+				l = append(l, fmt.Sprintf("column %s of table %s is missing", c, t))
+			}
+		}
+	}
+	if len(l) > 0 {
+		sort.Strings(l)
+		return fmt.Errorf("schema doesn't match the session's tables and columns: %s", strings.Join(l, ", "))
+	}
+	for t, ct := range s {
+		old := conv.SpSchema[t]
+		ct.Comment = old.Comment
+		for c, cd := range ct.ColDefs {
+			cd.Comment = old.ColDefs[c].Comment
+			ct.ColDefs[c] = cd
+		}
+		s[t] = ct
+	}
+	conv.SpSchema = s
+	return nil
+}
@@ -0,0 +1,73 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudspannerecosystem/harbourbridge/spanner/ddl"
+)
+
+func TestReplaceSpSchema(t *testing.T) {
+	makeConv := func() *Conv {
+		conv := MakeConv()
+		conv.SpSchema["users"] = ddl.CreateTable{
+			Name:     "users",
+			ColNames: []string{"id", "name"},
+			ColDefs: map[string]ddl.ColumnDef{
+				"id":   {Name: "id", T: ddl.Type{Name: ddl.Int64}, NotNull: true, Comment: "From: id bigint"},
+				"name": {Name: "name", T: ddl.Type{Name: ddl.String, Len: ddl.MaxLength}, Comment: "From: name text"},
+			},
+			Pks:     []ddl.IndexKey{{Col: "id"}},
+			Comment: "Spanner schema for source table users",
+		}
+		return conv
+	}
+	parse := func(s string) ddl.Schema {
+		schema, err := ddl.ParseDDL(s)
+		assert.Nil(t, err)
+		return schema
+	}
+
+	conv := makeConv()
+	err := conv.ReplaceSpSchema(parse("CREATE TABLE users (id INT64 NOT NULL, name STRING(100) NOT NULL) PRIMARY KEY (name, id);" +
+		"CREATE INDEX idx ON users (name)"))
+	assert.Nil(t, err)
+	assert.Equal(t, ddl.CreateTable{
+		Name:     "users",
+		ColNames: []string{"id", "name"},
+		ColDefs: map[string]ddl.ColumnDef{
+			"id":   {Name: "id", T: ddl.Type{Name: ddl.Int64}, NotNull: true, Comment: "From: id bigint"},
+			"name": {Name: "name", T: ddl.Type{Name: ddl.String, Len: 100}, NotNull: true, Comment: "From: name text"},
+		},
+		Pks:     []ddl.IndexKey{{Col: "name"}, {Col: "id"}},
+		Indexes: []ddl.CreateIndex{{Name: "idx", Table: "users", Keys: []ddl.IndexKey{{Col: "name"}}}},
+		Comment: "Spanner schema for source table users",
+	}, conv.SpSchema["users"])
+
+	errorCases := []string{
+		"CREATE TABLE users (id INT64, name STRING(MAX), age INT64) PRIMARY KEY (id)",
+		"CREATE TABLE users (id INT64) PRIMARY KEY (id)",
+		"CREATE TABLE users2 (id INT64, name STRING(MAX)) PRIMARY KEY (id)",
+		"CREATE TABLE users (id INT64, name STRING(MAX)) PRIMARY KEY (id); CREATE TABLE t (a INT64) PRIMARY KEY (a)",
+	}
+	for _, tc := range errorCases {
+		conv := makeConv()
+		assert.NotNil(t, conv.ReplaceSpSchema(parse(tc)), tc)
+		assert.Equal(t, makeConv().SpSchema, conv.SpSchema, tc)
+	}
+}
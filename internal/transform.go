@@ -0,0 +1,224 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/big"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/cloudspannerecosystem/harbourbridge/spanner/ddl"
+)
+
+// Transform is a data transformation rule that is applied to converted
+// values of the source columns matching Pattern, just before they are
+// written to Spanner. Rules are typically used to mask sensitive data.
+// The supported transformations are:
+//
+//	hash_sha256          replaces STRING values with the hex encoded SHA-256
+//	                     digest of the value, and BYTES values with the digest.
+//	redact               replaces the value with a fixed placeholder:
+//	                     "REDACTED" for STRING, {} for JSON, empty for BYTES,
+//	                     0 for INT64, FLOAT64 and NUMERIC, and false for BOOL.
+//	multiply(n)          multiplies INT64, FLOAT64 and NUMERIC values by n.
+//	shift_timezone(tz)   reinterprets the wall-clock time of TIMESTAMP values
+//	                     as a time in timezone tz (e.g. UTC).
+type Transform struct {
+	Pattern string // Source column pattern of the form "table.column" (see Filters).
+	Name    string // Name of the transformation e.g. "multiply".
+	Arg     string // Argument of the transformation, if any.
+	factor  *big.Rat
+	loc     *time.Location
+}
+
+// ParseTransforms reads transformation rules, one per line, of the form
+// "pattern: name" or "pattern: name(arg)" e.g.
+//
+//	users.email: hash_sha256
+//	orders.total: multiply(100)
+//	*.created_at: shift_timezone(UTC)
+//
+// Blank lines and lines starting with '#' are ignored.
+func ParseTransforms(r io.Reader) ([]Transform, error) {
+	var l []Transform
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		t, err := parseTransform(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+		l = append(l, t)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func parseTransform(line string) (Transform, error) {
+	i := strings.LastIndex(line, ":")
+	if i < 0 {
+		return Transform{}, fmt.Errorf("expected \"pattern: transformation\", got %q", line)
+	}
+	patterns, err := ParseColumnPatterns(line[:i])
+	if err != nil {
+		return Transform{}, err
+	}
+	if len(patterns) != 1 {
+		return Transform{}, fmt.Errorf("expected a single column pattern, got %q", line[:i])
+	}
+	t := Transform{Pattern: patterns[0], Name: strings.TrimSpace(line[i+1:])}
+	if j := strings.Index(t.Name, "("); j >= 0 {
+		if !strings.HasSuffix(t.Name, ")") {
+			return Transform{}, fmt.Errorf("missing ')' in %q", t.Name)
+		}
+		t.Name, t.Arg = strings.TrimSpace(t.Name[:j]), strings.TrimSpace(t.Name[j+1:len(t.Name)-1])
+	}
+	switch t.Name {
+	case "hash_sha256", "redact":
+		if t.Arg != "" {
+			return Transform{}, fmt.Errorf("%s doesn't take an argument", t.Name)
+		}
+	case "multiply":
+		r, ok := new(big.Rat).SetString(t.Arg)
+		if !ok {
+			return Transform{}, fmt.Errorf("invalid multiply factor %q", t.Arg)
+		}
+		t.factor = r
+	case "shift_timezone":
+		if t.Arg == "" {
+			return Transform{}, fmt.Errorf("shift_timezone requires a timezone")
+		}
+		loc, err := time.LoadLocation(t.Arg)
+		if err != nil {
+			return Transform{}, fmt.Errorf("invalid timezone %q: %v", t.Arg, err)
+		}
+		t.loc = loc
+	default:
+		return Transform{}, fmt.Errorf("unknown transformation %q", t.Name)
+	}
+	return t, nil
+}
+
+// SetTransforms configures conv to apply transformation rules ts to
+// all rows written by WriteRow.
+func (conv *Conv) SetTransforms(ts []Transform) {
+	conv.transforms = ts
+}
+
+// applyTransforms returns a copy of spVals with all matching
+// transformation rules applied.
+func (conv *Conv) applyTransforms(srcTable, spTable string, spCols []string, spVals []interface{}) ([]interface{}, error) {
+	if len(conv.transforms) == 0 {
+		return spVals, nil
+	}
+	vals := make([]interface{}, len(spVals))
+	copy(vals, spVals)
+	for i, spCol := range spCols {
+		// Synthetic primary keys have no source column, and so are
+		// never transformed.
+		srcCol, ok := conv.ToSource[spTable].Cols[spCol]
+		if !ok {
+			continue
+		}
+		for _, t := range conv.transforms {
+			tbl, col := splitColumnPattern(t.Pattern)
+			okT, _ := path.Match(tbl, srcTable)
+			okC, _ := path.Match(col, srcCol)
+			if !okT || !okC {
+				continue
+			}
+			v, err := t.apply(conv.SpSchema[spTable].ColDefs[spCol].T, vals[i])
+			if err != nil {
+				return nil, fmt.Errorf("can't apply %s to column %s of table %s: %v", t.Name, srcCol, srcTable, err)
+			}
+			vals[i] = v
+		}
+	}
+	return vals, nil
+}
+
+func (t Transform) apply(ty ddl.Type, v interface{}) (interface{}, error) {
+	if ty.IsArray {
+		return nil, fmt.Errorf("arrays are not supported")
+	}
+	switch t.Name {
+	case "hash_sha256":
+		switch x := v.(type) {
+		case string:
+			h := sha256.Sum256([]byte(x))
+			return hex.EncodeToString(h[:]), nil
+		case []byte:
+			h := sha256.Sum256(x)
+			return h[:], nil
+		}
+	case "redact":
+		switch v.(type) {
+		case string:
+			switch ty.Name {
+			case ddl.Numeric:
+				return "0", nil
+			case ddl.Json:
+				return "{}", nil
+			}
+			return "REDACTED", nil
+		case []byte:
+			return []byte{}, nil
+		case int64:
+			return int64(0), nil
+		case float64:
+			return float64(0), nil
+		case bool:
+			return false, nil
+		}
+	case "multiply":
+		switch x := v.(type) {
+		case int64:
+			r := new(big.Rat).Mul(new(big.Rat).SetInt64(x), t.factor)
+			if !r.IsInt() || !r.Num().IsInt64() {
+				return nil, fmt.Errorf("result %s is not a valid INT64", r.RatString())
+			}
+			return r.Num().Int64(), nil
+		case float64:
+			f, _ := t.factor.Float64()
+			return x * f, nil
+		case string:
+			if ty.Name != ddl.Numeric {
+				break
+			}
+			r, ok := new(big.Rat).SetString(x)
+			if !ok {
+				return nil, fmt.Errorf("can't convert %q to big.Rat", x)
+			}
+			// Same format as spanner.NumericString.
+			return r.Mul(r, t.factor).FloatString(9), nil
+		}
+	case "shift_timezone":
+		if x, ok := v.(time.Time); ok {
+			return time.Date(x.Year(), x.Month(), x.Day(), x.Hour(), x.Minute(), x.Second(), x.Nanosecond(), t.loc), nil
+		}
+	}
+	return nil, fmt.Errorf("type %s is not supported", ty.PrintColumnDefType())
+}
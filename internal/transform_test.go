@@ -0,0 +1,112 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudspannerecosystem/harbourbridge/spanner/ddl"
+)
+
+func TestParseTransforms(t *testing.T) {
+	rules := "# Mask production data.\n" +
+		"users.email: hash_sha256\n" +
+		"\n" +
+		"users.ssn : redact\n" +
+		"orders.total: multiply(100)\n" +
+		"*.created_at: shift_timezone(UTC)\n"
+	ts, err := ParseTransforms(strings.NewReader(rules))
+	assert.Nil(t, err)
+	var got []string
+	for _, tr := range ts {
+		got = append(got, tr.Pattern+" "+tr.Name+" "+tr.Arg)
+	}
+	assert.Equal(t, []string{
+		"users.email hash_sha256 ",
+		"users.ssn redact ",
+		"orders.total multiply 100",
+		"*.created_at shift_timezone UTC",
+	}, got)
+
+	errorCases := []string{
+		"users.email",
+		"email: redact",
+		"users.email: encrypt",
+		"users.email: redact(1)",
+		"orders.total: multiply(abc)",
+		"orders.total: multiply(100",
+		"*.created_at: shift_timezone()",
+		"*.created_at: shift_timezone(Not/AZone)",
+	}
+	for _, tc := range errorCases {
+		_, err := ParseTransforms(strings.NewReader(tc))
+		assert.NotNil(t, err, tc)
+	}
+}
+
+func TestWriteRow_Transforms(t *testing.T) {
+	conv := MakeConv()
+	ts, err := ParseTransforms(strings.NewReader("users.email: hash_sha256\n" +
+		"users.ssn: redact\n" +
+		"users.score: multiply(2.5)\n" +
+		"users.total: multiply(100)\n" +
+		"users.total: multiply(0.5)\n" +
+		"users.amount: multiply(2)\n" +
+		"*.created: shift_timezone(UTC)\n"))
+	assert.Nil(t, err)
+	conv.SetTransforms(ts)
+	conv.SetDataMode()
+	spTable, _ := GetSpannerTable(conv, "users")
+	spCols, _ := GetSpannerCols(conv, "users", []string{"email", "ssn", "score", "total", "amount", "created"})
+	conv.SpSchema[spTable] = ddl.CreateTable{
+		Name:     spTable,
+		ColNames: spCols,
+		ColDefs: map[string]ddl.ColumnDef{
+			"email":   {Name: "email", T: ddl.Type{Name: ddl.String, Len: ddl.MaxLength}},
+			"ssn":     {Name: "ssn", T: ddl.Type{Name: ddl.String, Len: ddl.MaxLength}},
+			"score":   {Name: "score", T: ddl.Type{Name: ddl.Float64}},
+			"total":   {Name: "total", T: ddl.Type{Name: ddl.Int64}},
+			"amount":  {Name: "amount", T: ddl.Type{Name: ddl.Numeric}},
+			"created": {Name: "created", T: ddl.Type{Name: ddl.Timestamp}},
+			"synth":   {Name: "synth", T: ddl.Type{Name: ddl.Int64}},
+		}}
+	var rows [][]interface{}
+	conv.SetDataSink(func(table string, cols []string, vals []interface{}) {
+		rows = append(rows, vals)
+	})
+	pst := time.FixedZone("PST", -8*60*60)
+	in := []interface{}{"a@b.com", "123-45-6789", 1.5, int64(42), "1.500000000", time.Date(2021, 1, 2, 3, 4, 5, 0, pst), int64(7)}
+	conv.WriteRow("users", spTable, append(spCols, "synth"), in)
+	assert.Equal(t, [][]interface{}{{
+		"fb98d44ad7501a959f3f4f4a3f004fe2d9e581ea6207e218c4b02c08a4d75adf",
+		"REDACTED",
+		3.75,
+		int64(2100),
+		"3.000000000",
+		time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC),
+		int64(7),
+	}}, rows)
+	// Input values are not modified.
+	assert.Equal(t, "a@b.com", in[0])
+
+	// Transformation errors generate bad rows.
+	conv.WriteRow("users", spTable, []string{"score"}, []interface{}{"abc"})
+	assert.Equal(t, 1, len(rows))
+	assert.Equal(t, int64(1), conv.BadRows())
+}
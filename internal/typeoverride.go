@@ -0,0 +1,134 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/cloudspannerecosystem/harbourbridge/spanner/ddl"
+)
+
+// TypeOverrides changes the Spanner type that source columns are mapped
+// to. Per-column overrides take precedence over per-table overrides,
+// which take precedence over global overrides. Overrides are only applied
+// if the Spanner type is one of the allowed mappings for the source type
+// (the same mappings that are offered by the web UI).
+type TypeOverrides struct {
+	Global  map[string]string            `yaml:"Global"`  // Maps source type to Spanner type.
+	Tables  map[string]map[string]string `yaml:"Tables"`  // Maps source table to source type to Spanner type.
+	Columns map[string]map[string]string `yaml:"Columns"` // Maps source table to source column to Spanner type.
+}
+
+// overrideTypes are the Spanner types that source types can be mapped to.
+var overrideTypes = []string{ddl.Bool, ddl.Bytes, ddl.Date, ddl.Float64, ddl.Int64, ddl.String, ddl.Timestamp, ddl.Numeric}
+
+// ParseTypeOverrides reads type overrides in JSON format e.g.
+//
+//	{
+//	  "Global": {"datetime": "STRING"},
+//	  "Tables": {"orders": {"decimal": "FLOAT64"}},
+//	  "Columns": {"users": {"zip": "INT64"}}
+//	}
+//
+// or the equivalent YAML format e.g.
+//
+//	Global:
+//	  datetime: STRING
+//	Tables:
+//	  orders:
+//	    decimal: FLOAT64
+//	Columns:
+//	  users:
+//	    zip: INT64
+//
+// Input that starts with '{' is parsed as JSON, anything else as YAML.
+// Spanner type names are case insensitive.
+func ParseTypeOverrides(r io.Reader) (TypeOverrides, error) {
+	var to TypeOverrides
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return to, fmt.Errorf("can't read type overrides: %v", err)
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("{")) {
+		d := json.NewDecoder(bytes.NewReader(b))
+		d.DisallowUnknownFields()
+		err = d.Decode(&to)
+	} else {
+		d := yaml.NewDecoder(bytes.NewReader(b))
+		d.KnownFields(true)
+		err = d.Decode(&to)
+		if err == io.EOF {
+			// Empty file: no overrides.
+			err = nil
+		}
+	}
+	if err != nil {
+		return to, fmt.Errorf("can't parse type overrides: %v", err)
+	}
+	if err := normalizeOverrideTypes(to.Global); err != nil {
+		return to, err
+	}
+	for _, m := range to.Tables {
+		if err := normalizeOverrideTypes(m); err != nil {
+			return to, err
+		}
+	}
+	for _, m := range to.Columns {
+		if err := normalizeOverrideTypes(m); err != nil {
+			return to, err
+		}
+	}
+	return to, nil
+}
+
+func normalizeOverrideTypes(m map[string]string) error {
+	for k, spType := range m {
+		spType = strings.ToUpper(strings.TrimSpace(spType))
+		found := false
+		for _, t := range overrideTypes {
+			found = found || t == spType
+		}
+		if !found {
+			return fmt.Errorf("invalid Spanner type %q for %s: expected one of %s", m[k], k, strings.Join(overrideTypes, ", "))
+		}
+		m[k] = spType
+	}
+	return nil
+}
+
+// Lookup returns the Spanner type that column srcCol of source table
+// srcTable (with source type srcType) is overridden to, if any.
+func (to TypeOverrides) Lookup(srcTable, srcCol, srcType string) (string, bool) {
+	if spType, ok := to.Columns[srcTable][srcCol]; ok {
+		return spType, true
+	}
+	if spType, ok := to.Tables[srcTable][srcType]; ok {
+		return spType, true
+	}
+	spType, ok := to.Global[srcType]
+	return spType, ok
+}
+
+// Empty returns true if to doesn't override any types.
+func (to TypeOverrides) Empty() bool {
+	return len(to.Global) == 0 && len(to.Tables) == 0 && len(to.Columns) == 0
+}
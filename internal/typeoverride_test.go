@@ -0,0 +1,90 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTypeOverrides(t *testing.T) {
+	to, err := ParseTypeOverrides(strings.NewReader(`{
+		"Global": {"datetime": "string"},
+		"Tables": {"orders": {"decimal": " FLOAT64 "}},
+		"Columns": {"users": {"zip": "INT64"}}
+	}`))
+	assert.Nil(t, err)
+	assert.Equal(t, TypeOverrides{
+		Global:  map[string]string{"datetime": "STRING"},
+		Tables:  map[string]map[string]string{"orders": {"decimal": "FLOAT64"}},
+		Columns: map[string]map[string]string{"users": {"zip": "INT64"}},
+	}, to)
+	assert.False(t, to.Empty())
+	assert.True(t, TypeOverrides{}.Empty())
+
+	yamlTo, err := ParseTypeOverrides(strings.NewReader(`
+Global:
+  datetime: string
+Tables:
+  orders:
+    decimal: " FLOAT64 "
+Columns:
+  users:
+    zip: INT64
+`))
+	assert.Nil(t, err)
+	assert.Equal(t, to, yamlTo)
+
+	errorCases := []string{
+		`{"Global": {"datetime": "VARCHAR"}}`,
+		`{"Columns": {"users": {"zip": "ARRAY<INT64>"}}}`,
+		`{"Types": {"datetime": "STRING"}}`,
+		`{"Global": `,
+		"Global:\n  datetime: VARCHAR\n",
+		"Types:\n  datetime: STRING\n",
+		"Global: [STRING]\n",
+	}
+	for _, tc := range errorCases {
+		_, err := ParseTypeOverrides(strings.NewReader(tc))
+		assert.NotNil(t, err, tc)
+	}
+}
+
+func TestTypeOverridesLookup(t *testing.T) {
+	to := TypeOverrides{
+		Global:  map[string]string{"int": "STRING", "datetime": "STRING"},
+		Tables:  map[string]map[string]string{"orders": {"int": "FLOAT64"}},
+		Columns: map[string]map[string]string{"orders": {"id": "INT64"}},
+	}
+	tests := []struct {
+		name                string
+		table, col, srcType string
+		expectedType        string
+		expectedFound       bool
+	}{
+		{"Column", "orders", "id", "int", "INT64", true},
+		{"Table", "orders", "qty", "int", "FLOAT64", true},
+		{"Global", "users", "age", "int", "STRING", true},
+		{"Global in overridden table", "orders", "created", "datetime", "STRING", true},
+		{"No override", "users", "name", "text", "", false},
+	}
+	for _, tc := range tests {
+		spType, found := to.Lookup(tc.table, tc.col, tc.srcType)
+		assert.Equal(t, tc.expectedType, spType, tc.name)
+		assert.Equal(t, tc.expectedFound, found, tc.name)
+	}
+}
@@ -107,6 +107,7 @@ func main() {
 		subcommands.Register(&cmd.SchemaCmd{}, "")
 		subcommands.Register(&cmd.DataCmd{}, "")
 		subcommands.Register(&cmd.EvalCmd{}, "")
+		subcommands.Register(&cmd.DiffCmd{}, "")
 		flag.Parse()
 		os.Exit(int(subcommands.Execute(ctx)))
 	}
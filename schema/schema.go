@@ -92,8 +92,10 @@ type Index struct {
 // Type represents the type of a column.
 type Type struct {
 	Name        string
-	Mods        []int64 // List of modifiers (aka type parameters e.g. varchar(8) or numeric(6, 4).
-	ArrayBounds []int64 // Empty for scalar types.
+	Mods        []int64  // List of modifiers (aka type parameters e.g. varchar(8) or numeric(6, 4).
+	ArrayBounds []int64  // Empty for scalar types.
+	Unsigned    bool     // Numeric type declared UNSIGNED (MySQL).
+	Values      []string // Allowed values of ENUM and SET types (MySQL).
 }
 
 // Ignored represents column properties/constraints that are not
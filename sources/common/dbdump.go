@@ -33,7 +33,9 @@ func ProcessDbDump(conv *internal.Conv, r *internal.Reader, dbDump DbDump) error
 		return err
 	}
 	if conv.SchemaMode() {
-		SchemaToSpannerDDL(conv, dbDump.GetToDdl())
+		if err := SchemaToSpannerDDL(conv, dbDump.GetToDdl()); err != nil {
+			return err
+		}
 		conv.AddPrimaryKeys()
 	}
 	return nil
@@ -17,6 +17,8 @@ package common
 import (
 	"database/sql"
 	"fmt"
+	"sort"
+	"sync"
 
 	"github.com/cloudspannerecosystem/harbourbridge/internal"
 	"github.com/cloudspannerecosystem/harbourbridge/schema"
@@ -29,8 +31,19 @@ type InfoSchema interface {
 	GetTables(db *sql.DB) ([]SchemaAndName, error)
 	GetColumns(table SchemaAndName, db *sql.DB) (*sql.Rows, error) //TODO - merge this method and ProcessColumns for cleaner interface
 	ProcessColumns(conv *internal.Conv, cols *sql.Rows, constraints map[string][]string) (map[string]schema.Column, []string)
-	GetRowsFromTable(conv *internal.Conv, db *sql.DB, table SchemaAndName) (*sql.Rows, error)
+	GetRowsFromTable(conv *internal.Conv, q Queryer, table SchemaAndName, kr KeyRange, page Page) (*sql.Rows, error)
+	// StartSnapshot opens n connections to db, each with a transaction that
+	// reads the same consistent snapshot of the database, and returns them
+	// along with the position of the snapshot in the database's change
+	// history. Replaying changes from the position may replay changes that
+	// are already in the snapshot, but never misses any. Sources that can't
+	// share a snapshot between connections may return fewer connections.
+	StartSnapshot(db *sql.DB, n int) ([]*SnapshotConn, internal.SourcePosition, error)
 	GetRowCount(db *sql.DB, table SchemaAndName) (int64, error)
+	// GetKeyStats returns the range of values of the leading primary key
+	// column col of table, and the estimated number of rows in table,
+	// without scanning table.
+	GetKeyStats(db *sql.DB, table SchemaAndName, col string) (min, max, count int64, err error)
 	GetConstraints(conv *internal.Conv, db *sql.DB, table SchemaAndName) ([]string, map[string][]string, error)
 	GetForeignKeys(conv *internal.Conv, db *sql.DB, table SchemaAndName) (foreignKeys []schema.ForeignKey, err error)
 	GetIndexes(conv *internal.Conv, db *sql.DB, table SchemaAndName) ([]schema.Index, error)
@@ -58,15 +71,62 @@ func ProcessInfoSchema(conv *internal.Conv, db *sql.DB, infoSchema InfoSchema) e
 		return err
 	}
 	for _, t := range tables {
+		if !conv.IncludeTable(infoSchema.GetTableName(t.Schema, t.Name)) {
+			continue
+		}
 		if err := processTable(conv, db, t, infoSchema); err != nil {
 			return err
 		}
 	}
-	SchemaToSpannerDDL(conv, infoSchema.GetToDdl())
+	if err := SchemaToSpannerDDL(conv, infoSchema.GetToDdl()); err != nil {
+		return err
+	}
+	if conv.HotspotRemedy != "" {
+		detectDenseKeys(conv, db, tables, infoSchema)
+	}
 	conv.AddPrimaryKeys()
 	return nil
 }
 
+// minDenseKeyRows is the minimum number of rows a table must have for
+// detectDenseKeys to consider its key distribution.
+const minDenseKeyRows = 1000
+
+// detectDenseKeys checks the values of the leading primary key column of
+// each table, and marks it as a hotspot if it is an integer column whose
+// values are densely packed (as is typical of values generated by
+// sequences), even if the source schema doesn't say so e.g. keys that
+// were generated by the application. It only uses the key's range and
+// the catalog's row count estimate (see InfoSchema.GetKeyStats), so it
+// doesn't scan tables; even so, it only runs when a hotspot remedy is
+// chosen.
+func detectDenseKeys(conv *internal.Conv, db *sql.DB, tables []SchemaAndName, infoSchema InfoSchema) {
+	for _, t := range tables {
+		srcTable, ok := conv.SrcSchema[infoSchema.GetTableName(t.Schema, t.Name)]
+		if !ok || len(srcTable.PrimaryKeys) == 0 {
+			continue
+		}
+		srcCol := srcTable.PrimaryKeys[0].Column
+		if conv.IsHotspot(srcTable.Name, srcCol) {
+			continue
+		}
+		spTable, err1 := internal.GetSpannerTable(conv, srcTable.Name)
+		spCol, err2 := internal.GetSpannerCol(conv, srcTable.Name, srcCol, false)
+		if err1 != nil || err2 != nil || conv.SpSchema[spTable].ColDefs[spCol].T.Name != ddl.Int64 {
+			continue
+		}
+		min, max, count, err := infoSchema.GetKeyStats(db, t, srcCol)
+		if err != nil {
+			internal.VerbosePrintf("Couldn't sample key %s of table %s: %s\n", srcCol, srcTable.Name, err)
+			continue
+		}
+		// Keys are dense if at least half of the values in [min, max] are used.
+		if count >= minDenseKeyRows && max-min < 2*count {
+			conv.MarkHotspot(srcTable.Name, srcCol, spTable, internal.SequentialKey)
+		}
+	}
+}
+
 // ProcessSQLData performs data conversion for source database
 // 'db'. For each table, we extract and convert the data to Spanner data
 // (based on the source and Spanner schemas), and write it to Spanner.
@@ -75,48 +135,252 @@ func ProcessInfoSchema(conv *internal.Conv, db *sql.DB, infoSchema InfoSchema) e
 //
 // Using database/sql library we pass *sql.RawBytes to rows.scan.
 // RawBytes is a byte slice and values can be easily converted to string.
-func ProcessSQLData(conv *internal.Conv, db *sql.DB, infoSchema InfoSchema) {
+//
+// Tables are read by up to opts.Readers concurrent readers, each of which
+// uses its own connection to the source database (from db's connection
+// pool). Large tables with a single integer primary key are split into key
+// ranges that are read in parallel, so that one big table doesn't hold up
+// the migration. Readers must not share conv, so each converts data with a
+// worker copy of conv (see internal.Conv.NewDataWorker), and conv's
+// dataSink must be safe for concurrent use. Tables with a primary key are
+// read in pages of opts.PageSize rows (see processTableData).
+//
+// If opts.Snapshot is set, all readers read the same consistent snapshot of
+// the source database (see InfoSchema.StartSnapshot), whose position is
+// recorded in conv (and its checkpoint) for replaying later changes.
+// ProcessSQLData returns an error if the snapshot can't be started.
+func ProcessSQLData(conv *internal.Conv, db *sql.DB, infoSchema InfoSchema, opts ReadOptions) error {
+	readers := opts.Readers
+	if readers < 1 {
+		readers = 1
+	}
 	// TODO: refactor to use the set of tables computed by
 	// ProcessInfoSchema instead of computing them again.
 	tables, err := infoSchema.GetTables(db)
 	if err != nil {
 		conv.Unexpected(fmt.Sprintf("Couldn't get list of table: %s", err))
-		return
+		return nil
 	}
+	var reads []tableRead
 	for _, t := range tables {
 		srcTable := infoSchema.GetTableName(t.Schema, t.Name)
-		srcSchema, ok := conv.SrcSchema[srcTable]
-		if !ok {
-			conv.Stats.BadRows[srcTable] += conv.Stats.Rows[srcTable]
-			conv.Unexpected(fmt.Sprintf("Can't get schemas for table %s", srcTable))
+		if !conv.IncludeTable(srcTable) {
 			continue
 		}
-		rows, err := infoSchema.GetRowsFromTable(conv, db, t)
-		if err != nil {
-			conv.Unexpected(fmt.Sprintf("Couldn't get data for table %s : err = %s", t.Name, err))
+		if conv.Checkpoint().TableComplete(srcTable, "") {
+			internal.VerbosePrintf("Skipping table %s: data already migrated\n", srcTable)
 			continue
 		}
-		defer rows.Close()
-		srcCols, _ := rows.Columns()
-		spTable, err := internal.GetSpannerTable(conv, srcTable)
-		if err != nil {
-			conv.Unexpected(fmt.Sprintf("Couldn't get spanner table : %s", err))
-			continue
+		krs := keyRanges(conv, db, t, srcTable, infoSchema, readers)
+		for _, kr := range krs {
+			if conv.Checkpoint().TableComplete(srcTable, kr.Name()) {
+				continue
+			}
+			reads = append(reads, tableRead{table: t, srcTable: srcTable, kr: kr, rows: conv.Stats.Rows[srcTable] / int64(len(krs))})
 		}
-		spCols, err := internal.GetSpannerCols(conv, srcTable, srcCols)
+	}
+	queryers := []Queryer{db}
+	for i := 1; i < readers; i++ {
+		queryers = append(queryers, db)
+	}
+	if opts.Snapshot {
+		conns, pos, err := infoSchema.StartSnapshot(db, readers)
 		if err != nil {
-			conv.Unexpected(fmt.Sprintf("Couldn't get spanner columns for table %s : err = %s", t.Name, err))
-			continue
+			return fmt.Errorf("can't start consistent snapshot of source database: %v", err)
 		}
-		spSchema, ok := conv.SpSchema[spTable]
+		defer CloseSnapshot(conns)
+		internal.VerbosePrintf("Reading data from a consistent snapshot (%s) with %d readers\n", pos, len(conns))
+		conv.SetSnapshot(pos)
+		conv.Checkpoint().SetSnapshot(pos)
+		queryers = nil
+		for _, c := range conns {
+			queryers = append(queryers, c)
+		}
+	}
+	if len(queryers) == 1 {
+		for _, r := range reads {
+			processTableData(conv, queryers[0], r, opts.PageSize, infoSchema)
+		}
+		return nil
+	}
+	// Start the biggest reads first, so that they don't end up running on
+	// their own at the end of the migration.
+	sort.SliceStable(reads, func(i, j int) bool { return reads[i].rows > reads[j].rows })
+	ch := make(chan tableRead)
+	workers := make([]*internal.Conv, len(queryers))
+	var wg sync.WaitGroup
+	for i := range workers {
+		workers[i] = conv.NewDataWorker()
+		wg.Add(1)
+		go func(w *internal.Conv, q Queryer) {
+			defer wg.Done()
+			for r := range ch {
+				processTableData(w, q, r, opts.PageSize, infoSchema)
+			}
+		}(workers[i], queryers[i])
+	}
+	for _, r := range reads {
+		ch <- r
+	}
+	close(ch)
+	wg.Wait()
+	for _, w := range workers {
+		conv.MergeDataWorker(w)
+	}
+	return nil
+}
+
+// ReadOptions configures how ProcessSQLData reads source tables.
+type ReadOptions struct {
+	Readers  int   // Number of tables (or key ranges of tables) read concurrently. Zero means 1.
+	PageSize int64 // Number of rows read by each query of a table with a primary key. Zero means tables are read with a single query.
+	Snapshot bool  // If true, data is read from a consistent snapshot of the source database.
+}
+
+// tableRead describes the read of a table (or a key range of a table) by
+// ProcessSQLData.
+type tableRead struct {
+	table    SchemaAndName
+	srcTable string
+	kr       KeyRange
+	rows     int64 // Estimated number of rows.
+}
+
+// minSplitRows is the minimum number of rows a table must have for
+// ProcessSQLData to split it into key ranges.
+const minSplitRows = 1000 * 1000
+
+// keyRanges returns the key ranges that ProcessSQLData reads srcTable in.
+// Tables are read whole unless there are several readers, and the table is
+// large and has a single Int64 key column, in which case it is split into
+// one range per reader. Key ranges recorded in conv's checkpoint are
+// reused, so that a resumed migration reads the same ranges.
+func keyRanges(conv *internal.Conv, db *sql.DB, t SchemaAndName, srcTable string, infoSchema InfoSchema, readers int) []KeyRange {
+	whole := []KeyRange{{}}
+	if names := conv.Checkpoint().Ranges(srcTable); names != nil {
+		var l []KeyRange
+		for _, name := range names {
+			kr, err := ParseKeyRange(name)
+			if err != nil {
+				conv.Unexpected(fmt.Sprintf("Checkpoint for table %s has %s", srcTable, err))
+				return whole
+			}
+			l = append(l, kr)
+		}
+		return l
+	}
+	srcSchema := conv.SrcSchema[srcTable]
+	if readers <= 1 || conv.Stats.Rows[srcTable] < minSplitRows || len(srcSchema.PrimaryKeys) != 1 {
+		return whole
+	}
+	srcCol := srcSchema.PrimaryKeys[0].Column
+	spTable, err1 := internal.GetSpannerTable(conv, srcTable)
+	spCol, err2 := internal.GetSpannerCol(conv, srcTable, srcCol, false)
+	if err1 != nil || err2 != nil || conv.SpSchema[spTable].ColDefs[spCol].T.Name != ddl.Int64 {
+		return whole
+	}
+	min, max, _, err := infoSchema.GetKeyStats(db, t, srcCol)
+	if err != nil {
+		internal.VerbosePrintf("Couldn't get range of key %s of table %s: %s\n", srcCol, srcTable, err)
+		return whole
+	}
+	l := SplitKeyRange(min, max, readers)
+	if len(l) > 1 {
+		var names []string
+		for _, kr := range l {
+			names = append(names, kr.Name())
+		}
+		conv.Checkpoint().SetRanges(srcTable, names)
+	}
+	return l
+}
+
+// processTableData reads and converts the data of r. Tables that can be
+// read in primary key order are read in pages of pageSize rows (if
+// pageSize isn't zero), using keyset pagination: each page starts after
+// the last row of the previous one. This avoids holding a long-running
+// cursor (and transaction) open on the source database.
+func processTableData(conv *internal.Conv, q Queryer, r tableRead, pageSize int64, infoSchema InfoSchema) {
+	srcTable, name := r.srcTable, r.srcTable
+	if !r.kr.Whole() {
+		name = fmt.Sprintf("%s (key range %s)", srcTable, r.kr.Name())
+	}
+	srcSchema, ok := conv.SrcSchema[srcTable]
+	if !ok {
+		conv.Stats.BadRows[srcTable] += r.rows
+		conv.Unexpected(fmt.Sprintf("Can't get schemas for table %s", srcTable))
+		return
+	}
+	spTable, err := internal.GetSpannerTable(conv, srcTable)
+	if err != nil {
+		conv.Unexpected(fmt.Sprintf("Couldn't get spanner table : %s", err))
+		return
+	}
+	spSchema, ok := conv.SpSchema[spTable]
+	if !ok {
+		//TODO - check why Bad rows are not being added in above conditions
+		conv.Stats.BadRows[srcTable] += r.rows
+		conv.Unexpected(fmt.Sprintf("Can't get schemas for table %s", srcTable))
+		return
+	}
+	internal.VerbosePrintf("Reading table %s\n", name)
+	good, bad := conv.Stats.GoodRows[srcTable], conv.Stats.BadRows[srcTable]
+	page := Page{Limit: pageSize}
+	for {
+		last, ok := processPage(conv, q, r, page, srcSchema, spTable, spSchema, infoSchema)
 		if !ok {
-			//TODO - check why Bad rows are not being added in above conditions
-			conv.Stats.BadRows[srcTable] += conv.Stats.Rows[srcTable]
-			conv.Unexpected(fmt.Sprintf("Can't get schemas for table %s", srcTable))
-			continue
+			return
+		}
+		// The read is done once a page has no new rows. Tables that can't
+		// be read in key order (last is nil) are read in a single query.
+		if page.Limit == 0 || last == nil || equalKeys(last, page.After) {
+			break
+		}
+		page.After = last
+	}
+	internal.VerbosePrintf("Finished reading table %s: %d rows converted, %d bad rows\n", name, conv.Stats.GoodRows[srcTable]-good, conv.Stats.BadRows[srcTable]-bad)
+	conv.Checkpoint().TableRead(srcTable, r.kr.Name())
+}
+
+// processPage reads and converts a page of the rows of r. It returns the
+// primary key values of the last row read (nil if there are none, or the
+// table can't be read in key order), and false if the rows couldn't be
+// read.
+func processPage(conv *internal.Conv, q Queryer, r tableRead, page Page, srcSchema schema.Table, spTable string, spSchema ddl.CreateTable, infoSchema InfoSchema) ([]string, bool) {
+	rows, err := infoSchema.GetRowsFromTable(conv, q, r.table, r.kr, page)
+	if err != nil {
+		conv.Unexpected(fmt.Sprintf("Couldn't get data for table %s : err = %s", r.table.Name, err))
+		return nil, false
+	}
+	if rows == nil {
+		return nil, false
+	}
+	defer rows.Close()
+	srcCols, _ := rows.Columns()
+	spCols, err := internal.GetSpannerCols(conv, r.srcTable, srcCols)
+	if err != nil {
+		conv.Unexpected(fmt.Sprintf("Couldn't get spanner columns for table %s : err = %s", r.table.Name, err))
+		return nil, false
+	}
+	conv.SetRowSource(r.srcTable, r.kr.Name()) // ProcessDataRows sets the key of each row.
+	infoSchema.ProcessDataRows(conv, r.srcTable, srcCols, srcSchema, spTable, spCols, spSchema, rows)
+	if err := rows.Err(); err != nil {
+		conv.Unexpected(fmt.Sprintf("Couldn't read all data for table %s : err = %s", r.table.Name, err))
+		return nil, false
+	}
+	return conv.RowPosition().Key, true
+}
+
+func equalKeys(k1, k2 []string) bool {
+	if len(k1) != len(k2) {
+		return false
+	}
+	for i := range k1 {
+		if k1[i] != k2[i] {
+			return false
 		}
-		infoSchema.ProcessDataRows(conv, srcTable, srcCols, srcSchema, spTable, spCols, spSchema, rows)
 	}
+	return true
 }
 
 // SetRowStats populates conv with the number of rows in each table.
@@ -128,6 +392,9 @@ func SetRowStats(conv *internal.Conv, db *sql.DB, infoSchema InfoSchema) {
 	}
 	for _, t := range tables {
 		tableName := infoSchema.GetTableName(t.Schema, t.Name)
+		if !conv.IncludeTable(tableName) {
+			continue
+		}
 		count, err := infoSchema.GetRowCount(db, t)
 		if err != nil {
 			conv.Unexpected(fmt.Sprintf("Couldn't get number of rows for table %s", tableName))
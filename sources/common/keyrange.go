@@ -0,0 +1,103 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// KeyRange is a range [Start, End) of values of the primary key of a
+// table, used to split the read of a large table between several readers.
+// Tables are only split if they have a single integer key column. A nil
+// bound means the range is unbounded on that side, so the zero KeyRange
+// covers the whole table.
+type KeyRange struct {
+	Start *int64
+	End   *int64
+}
+
+// Whole returns true if kr covers the whole table.
+func (kr KeyRange) Whole() bool {
+	return kr.Start == nil && kr.End == nil
+}
+
+// Name returns the name of kr used in checkpoints and progress messages:
+// "start:end", with an empty bound for an unbounded side, or the empty
+// string for the whole table.
+func (kr KeyRange) Name() string {
+	if kr.Whole() {
+		return ""
+	}
+	bound := func(b *int64) string {
+		if b == nil {
+			return ""
+		}
+		return strconv.FormatInt(*b, 10)
+	}
+	return bound(kr.Start) + ":" + bound(kr.End)
+}
+
+// ParseKeyRange parses a key range name returned by KeyRange.Name.
+func ParseKeyRange(name string) (KeyRange, error) {
+	if name == "" {
+		return KeyRange{}, nil
+	}
+	l := strings.Split(name, ":")
+	if len(l) != 2 {
+		return KeyRange{}, fmt.Errorf("bad key range %q", name)
+	}
+	var b [2]*int64
+	for i, s := range l {
+		if s == "" {
+			continue
+		}
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return KeyRange{}, fmt.Errorf("bad key range %q: %v", name, err)
+		}
+		b[i] = &v
+	}
+	return KeyRange{Start: b[0], End: b[1]}, nil
+}
+
+// SplitKeyRange splits the key values [min, max] into n ranges of roughly
+// equal width. The first and last ranges are unbounded, so that rows whose
+// keys fall outside [min, max] (e.g. rows inserted after min and max were
+// computed) are still read.
+func SplitKeyRange(min, max int64, n int) []KeyRange {
+	if n <= 1 || max <= min {
+		return []KeyRange{{}}
+	}
+	// Use unsigned arithmetic: max-min can overflow int64.
+	width := uint64(max) - uint64(min)
+	step := width/uint64(n) + 1
+	var bounds []int64
+	for i := 1; i < n; i++ {
+		d := step * uint64(i)
+		if d > width {
+			break
+		}
+		bounds = append(bounds, int64(uint64(min)+d))
+	}
+	l := make([]KeyRange, len(bounds)+1)
+	for i, b := range bounds {
+		b := b
+		l[i].End = &b
+		l[i+1].Start = &b
+	}
+	return l
+}
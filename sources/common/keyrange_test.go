@@ -0,0 +1,57 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitKeyRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		min, max int64
+		n        int
+		expected []string // Names of the key ranges.
+	}{
+		{"One reader", 1, 100, 1, []string{""}},
+		{"Single key", 5, 5, 4, []string{""}},
+		{"Even split", 0, 99, 4, []string{":25", "25:50", "50:75", "75:"}},
+		{"Negative keys", -100, -1, 2, []string{":-50", "-50:"}},
+		{"More readers than keys", 1, 3, 8, []string{":2", "2:3", "3:"}},
+		{"Full int64 range", math.MinInt64, math.MaxInt64, 2, []string{":0", "0:"}},
+	}
+	for _, tc := range tests {
+		var names []string
+		for _, kr := range SplitKeyRange(tc.min, tc.max, tc.n) {
+			names = append(names, kr.Name())
+		}
+		assert.Equal(t, tc.expected, names, tc.name)
+	}
+}
+
+func TestParseKeyRange(t *testing.T) {
+	for _, name := range []string{"", ":25", "25:50", "-50:", "-9:-3"} {
+		kr, err := ParseKeyRange(name)
+		assert.Nil(t, err, name)
+		assert.Equal(t, name, kr.Name())
+	}
+	for _, name := range []string{"25", "a:b", "1:2:3"} {
+		_, err := ParseKeyRange(name)
+		assert.NotNil(t, err, name)
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudspannerecosystem/harbourbridge/internal"
+	"github.com/cloudspannerecosystem/harbourbridge/schema"
+)
+
+// KeyIndexes returns the positions in srcCols of the primary key columns
+// of srcSchema, or nil if the table has no primary key or one of its key
+// columns isn't in srcCols.
+func KeyIndexes(srcSchema schema.Table, srcCols []string) []int {
+	if len(srcSchema.PrimaryKeys) == 0 {
+		return nil
+	}
+	pos := make(map[string]int)
+	for i, c := range srcCols {
+		pos[c] = i
+	}
+	var l []int
+	for _, k := range srcSchema.PrimaryKeys {
+		i, ok := pos[k.Column]
+		if !ok {
+			return nil
+		}
+		l = append(l, i)
+	}
+	return l
+}
+
+// Page selects the rows of a table (or key range) read in primary key
+// order: those after the row with key values After (nil means from the
+// start), up to Limit rows (zero means no limit).
+type Page struct {
+	After []string
+	Limit int64
+}
+
+// ReadClauses returns the WHERE, ORDER BY and LIMIT clauses (and the
+// arguments of the WHERE clause) for reading page of key range kr of
+// srcTable. Tables are read in primary key order if they are read in
+// pages or conv tracks data migration progress. If page.After is nil, the
+// read starts after the last row that conv's checkpoint records as done.
+// Pages are ignored for tables that can't be read in key order: they are
+// read in a single query. Function quote quotes an identifier, and param
+// returns the placeholder for the i'th query argument (starting from 1).
+func ReadClauses(conv *internal.Conv, srcTable string, kr KeyRange, page Page, srcSchema schema.Table, srcCols []string, quote func(string) string, param func(int) string) (string, []interface{}) {
+	var args []interface{}
+	var where []string
+	if !kr.Whole() {
+		// Tables are only split into key ranges if they have a single
+		// key column.
+		col := quote(srcSchema.PrimaryKeys[0].Column)
+		if kr.Start != nil {
+			args = append(args, *kr.Start)
+			where = append(where, fmt.Sprintf("%s >= %s", col, param(len(args))))
+		}
+		if kr.End != nil {
+			args = append(args, *kr.End)
+			where = append(where, fmt.Sprintf("%s < %s", col, param(len(args))))
+		}
+	}
+	var order string
+	if KeyIndexes(srcSchema, srcCols) != nil && (page.Limit > 0 || conv.Checkpoint() != nil) {
+		after := page.After
+		if after == nil {
+			after = conv.Checkpoint().LastKey(srcTable, kr.Name())
+		}
+		var cond string
+		order, cond, args = keyClauses(conv, srcTable, srcSchema, after, quote, param, args)
+		if cond != "" {
+			where = append(where, cond)
+		}
+		if page.Limit > 0 {
+			order += fmt.Sprintf(" LIMIT %d", page.Limit)
+		}
+	}
+	s := order
+	if len(where) > 0 {
+		s = " WHERE " + strings.Join(where, " AND ") + s
+	}
+	return s, args
+}
+
+// keyClauses returns the ORDER BY clause for reading srcTable in primary
+// key order, and the condition (appending its arguments to args) that
+// selects the rows after the row with key values after (if not nil).
+func keyClauses(conv *internal.Conv, srcTable string, srcSchema schema.Table, after []string, quote func(string) string, param func(int) string, args []interface{}) (string, string, []interface{}) {
+	keys := srcSchema.PrimaryKeys
+	var order []string
+	for _, k := range keys {
+		if k.Desc {
+			order = append(order, quote(k.Column)+" DESC")
+		} else {
+			order = append(order, quote(k.Column))
+		}
+	}
+	s := " ORDER BY " + strings.Join(order, ", ")
+	if after == nil {
+		return s, "", args
+	}
+	if len(after) != len(keys) {
+		conv.Unexpected(fmt.Sprintf("Can't read table %s after key %v: the table has %d key columns", srcTable, after, len(keys)))
+		return s, "", args
+	}
+	// Rows after 'after' are those that match it on the first i key
+	// columns, and come after it on the next one, for some i.
+	var or []string
+	for i, k := range keys {
+		var and []string
+		for j := 0; j < i; j++ {
+			args = append(args, after[j])
+			and = append(and, fmt.Sprintf("%s = %s", quote(keys[j].Column), param(len(args))))
+		}
+		op := ">"
+		if k.Desc {
+			op = "<"
+		}
+		args = append(args, after[i])
+		and = append(and, fmt.Sprintf("%s %s %s", quote(k.Column), op, param(len(args))))
+		or = append(or, "("+strings.Join(and, " AND ")+")")
+	}
+	return s, "(" + strings.Join(or, " OR ") + ")", args
+}
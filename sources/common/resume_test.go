@@ -0,0 +1,118 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudspannerecosystem/harbourbridge/internal"
+	"github.com/cloudspannerecosystem/harbourbridge/schema"
+)
+
+func TestKeyIndexes(t *testing.T) {
+	tbl := schema.Table{PrimaryKeys: []schema.Key{{Column: "b"}, {Column: "a"}}}
+	assert.Equal(t, []int{2, 0}, KeyIndexes(tbl, []string{"a", "c", "b"}))
+	assert.Nil(t, KeyIndexes(tbl, []string{"a", "c"}))
+	assert.Nil(t, KeyIndexes(schema.Table{}, []string{"a"}))
+}
+
+func TestReadClauses(t *testing.T) {
+	quote := func(s string) string { return `"` + s + `"` }
+	param := func(i int) string { return fmt.Sprintf("$%d", i) }
+	tbl := schema.Table{PrimaryKeys: []schema.Key{{Column: "a"}, {Column: "b", Desc: true}}}
+	cols := []string{"a", "b", "c"}
+
+	// Progress isn't tracked.
+	conv := internal.MakeConv()
+	s, args := ReadClauses(conv, "t", KeyRange{}, Page{}, tbl, cols, quote, param)
+	assert.Equal(t, "", s)
+	assert.Nil(t, args)
+
+	// Nothing done yet.
+	cp := internal.NewCheckpoint("")
+	conv.SetCheckpoint(cp)
+	s, args = ReadClauses(conv, "t", KeyRange{}, Page{}, tbl, cols, quote, param)
+	assert.Equal(t, ` ORDER BY "a", "b" DESC`, s)
+	assert.Nil(t, args)
+
+	// Resume after the last row done.
+	cp.Add(internal.RowPosition{Table: "t", Key: []string{"1", "x"}})()
+	s, args = ReadClauses(conv, "t", KeyRange{}, Page{}, tbl, cols, quote, param)
+	assert.Equal(t, ` WHERE (("a" > $1) OR ("a" = $2 AND "b" < $3)) ORDER BY "a", "b" DESC`, s)
+	assert.Equal(t, []interface{}{"1", "1", "x"}, args)
+
+	// Tables without a primary key (or whose key columns aren't read)
+	// can't be read in key order.
+	s, _ = ReadClauses(conv, "t", KeyRange{}, Page{}, tbl, []string{"a", "c"}, quote, param)
+	assert.Equal(t, "", s)
+	s, _ = ReadClauses(conv, "u", KeyRange{}, Page{}, schema.Table{}, cols, quote, param)
+	assert.Equal(t, "", s)
+}
+
+func TestReadClauses_KeyRange(t *testing.T) {
+	quote := func(s string) string { return "`" + s + "`" }
+	param := func(int) string { return "?" }
+	tbl := schema.Table{PrimaryKeys: []schema.Key{{Column: "id"}}}
+	cols := []string{"id", "c"}
+	start, end := int64(10), int64(20)
+	kr := KeyRange{Start: &start, End: &end}
+
+	// Key ranges are read even if progress isn't tracked.
+	conv := internal.MakeConv()
+	s, args := ReadClauses(conv, "t", kr, Page{}, tbl, cols, quote, param)
+	assert.Equal(t, " WHERE `id` >= ? AND `id` < ?", s)
+	assert.Equal(t, []interface{}{int64(10), int64(20)}, args)
+
+	// Each key range resumes from its own last row.
+	cp := internal.NewCheckpoint("")
+	conv.SetCheckpoint(cp)
+	cp.Add(internal.RowPosition{Table: "t", Range: kr.Name(), Key: []string{"12"}})()
+	cp.Add(internal.RowPosition{Table: "t", Range: ":10", Key: []string{"3"}})()
+	s, args = ReadClauses(conv, "t", KeyRange{Start: &end}, Page{}, tbl, cols, quote, param)
+	assert.Equal(t, " WHERE `id` >= ? ORDER BY `id`", s)
+	assert.Equal(t, []interface{}{int64(20)}, args)
+	s, args = ReadClauses(conv, "t", kr, Page{}, tbl, cols, quote, param)
+	assert.Equal(t, " WHERE `id` >= ? AND `id` < ? AND ((`id` > ?)) ORDER BY `id`", s)
+	assert.Equal(t, []interface{}{int64(10), int64(20), "12"}, args)
+}
+
+func TestReadClauses_Page(t *testing.T) {
+	quote := func(s string) string { return `"` + s + `"` }
+	param := func(i int) string { return fmt.Sprintf("$%d", i) }
+	tbl := schema.Table{PrimaryKeys: []schema.Key{{Column: "a"}, {Column: "b"}}}
+	cols := []string{"a", "b", "c"}
+	conv := internal.MakeConv()
+
+	// First page.
+	s, args := ReadClauses(conv, "t", KeyRange{}, Page{Limit: 100}, tbl, cols, quote, param)
+	assert.Equal(t, ` ORDER BY "a", "b" LIMIT 100`, s)
+	assert.Nil(t, args)
+
+	// Later pages start after the last row of the previous page, even if
+	// progress is tracked.
+	cp := internal.NewCheckpoint("")
+	conv.SetCheckpoint(cp)
+	cp.Add(internal.RowPosition{Table: "t", Key: []string{"1", "x"}})()
+	s, args = ReadClauses(conv, "t", KeyRange{}, Page{After: []string{"5", "y"}, Limit: 100}, tbl, cols, quote, param)
+	assert.Equal(t, ` WHERE (("a" > $1) OR ("a" = $2 AND "b" > $3)) ORDER BY "a", "b" LIMIT 100`, s)
+	assert.Equal(t, []interface{}{"5", "5", "y"}, args)
+
+	// Tables that can't be read in key order are read with a single query.
+	s, _ = ReadClauses(conv, "u", KeyRange{}, Page{Limit: 100}, schema.Table{}, cols, quote, param)
+	assert.Equal(t, "", s)
+}
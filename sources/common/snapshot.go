@@ -0,0 +1,81 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Queryer runs queries on the source database: either *sql.DB, or a
+// SnapshotConn that reads a consistent snapshot.
+type Queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// SnapshotConn is a connection to the source database with an open
+// transaction that reads a consistent snapshot of the database. Queries
+// are run one at a time: the rows of a query must be closed before the
+// next query is run.
+type SnapshotConn struct {
+	conn *sql.Conn
+}
+
+// OpenSnapshotConn opens a new connection to db and runs stmts on it,
+// which must start a transaction that reads a snapshot.
+func OpenSnapshotConn(db *sql.DB, stmts ...string) (*SnapshotConn, error) {
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	c := &SnapshotConn{conn}
+	for _, s := range stmts {
+		if err := c.Exec(s); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("can't start snapshot transaction: %s: %v", s, err)
+		}
+	}
+	return c, nil
+}
+
+// Query runs query in c's transaction.
+func (c *SnapshotConn) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return c.conn.QueryContext(context.Background(), query, args...)
+}
+
+// Exec runs a statement that returns no rows in c's transaction.
+func (c *SnapshotConn) Exec(stmt string, args ...interface{}) error {
+	_, err := c.conn.ExecContext(context.Background(), stmt, args...)
+	return err
+}
+
+// Close ends c's transaction, and returns the connection to its pool.
+func (c *SnapshotConn) Close() error {
+	// The transaction only reads data, so there's nothing to commit, but
+	// ending it lets the source database release the snapshot.
+	err := c.Exec("COMMIT")
+	if cerr := c.conn.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// CloseSnapshot closes the connections in l.
+func CloseSnapshot(l []*SnapshotConn) {
+	for _, c := range l {
+		c.Close()
+	}
+}
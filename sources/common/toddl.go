@@ -45,12 +45,23 @@ import (
 // supported, an error is to be returned by the corresponding method.
 type ToDdl interface {
 	ToSpannerType(conv *internal.Conv, columnType schema.Type) (ddl.Type, []internal.SchemaIssue)
+	// OverrideType maps columnType to Spanner type spType (see
+	// internal.TypeOverrides). It returns an error if spType is not an
+	// allowed mapping for columnType.
+	OverrideType(conv *internal.Conv, columnType schema.Type, spType string) (ddl.Type, []internal.SchemaIssue, error)
 }
 
 // schemaToSpannerDDL performs schema conversion from the source DB schema to
 // Spanner. It uses the source schema in conv.SrcSchema, and writes
 // the Spanner schema to conv.SpSchema.
 func SchemaToSpannerDDL(conv *internal.Conv, toddl ToDdl) error {
+	var srcTables []string
+	for t := range conv.SrcSchema {
+		srcTables = append(srcTables, t)
+	}
+	if err := conv.CheckSchemaCollisions(srcTables); err != nil {
+		return err
+	}
 	for _, srcTable := range conv.SrcSchema {
 		spTableName, err := internal.GetSpannerTable(conv, srcTable.Name)
 		if err != nil {
@@ -59,10 +70,17 @@ func SchemaToSpannerDDL(conv *internal.Conv, toddl ToDdl) error {
 		}
 		var spColNames []string
 		spColDef := make(map[string]ddl.ColumnDef)
-		conv.Issues[srcTable.Name] = make(map[string][]internal.SchemaIssue)
+		// Issues may already have been added while mapping names.
+		if conv.Issues[srcTable.Name] == nil {
+			conv.Issues[srcTable.Name] = make(map[string][]internal.SchemaIssue)
+		}
 		// Iterate over columns using ColNames order.
 		for _, srcColName := range srcTable.ColNames {
 			srcCol := srcTable.ColDefs[srcColName]
+			if !conv.IncludeColumn(srcTable.Name, srcCol.Name) {
+				conv.Issues[srcTable.Name][srcCol.Name] = []internal.SchemaIssue{internal.ExcludedColumn}
+				continue
+			}
 			colName, err := internal.GetSpannerCol(conv, srcTable.Name, srcCol.Name, false)
 			if err != nil {
 				conv.Unexpected(fmt.Sprintf("Couldn't map source column %s of table %s to Spanner: %s", srcTable.Name, srcCol.Name, err))
@@ -70,6 +88,14 @@ func SchemaToSpannerDDL(conv *internal.Conv, toddl ToDdl) error {
 			}
 			spColNames = append(spColNames, colName)
 			ty, issues := toddl.ToSpannerType(conv, srcCol.Type)
+			if spType, ok := conv.TypeOverrides.Lookup(srcTable.Name, srcCol.Name, srcCol.Type.Name); ok {
+				if oty, oissues, err := toddl.OverrideType(conv, srcCol.Type, spType); err != nil {
+					internal.VerbosePrintf("Ignoring type override for column %s of table %s: %s\n", srcCol.Name, srcTable.Name, err)
+					issues = append(issues, internal.InvalidTypeOverride)
+				} else {
+					ty, issues = oty, append(oissues, internal.TypeOverride)
+				}
+			}
 			// TODO(hengfeng): add issues for all elements of srcCol.Ignored.
 			if srcCol.Ignored.ForeignKey {
 				issues = append(issues, internal.ForeignKey)
@@ -80,8 +106,11 @@ func SchemaToSpannerDDL(conv *internal.Conv, toddl ToDdl) error {
 			if srcCol.Ignored.AutoIncrement { //TODO(adibh) - check why this is not there in postgres
 				issues = append(issues, internal.AutoIncrement)
 			}
+			if conv.Filters.DropsColumn(srcTable.Name, srcCol.Name) {
+				issues = append(issues, internal.ExcludedPrimaryKey)
+			}
 			if len(issues) > 0 {
-				conv.Issues[srcTable.Name][srcCol.Name] = issues
+				conv.Issues[srcTable.Name][srcCol.Name] = append(conv.Issues[srcTable.Name][srcCol.Name], issues...)
 			}
 			spColDef[colName] = ddl.ColumnDef{
 				Name:    colName,
@@ -99,11 +128,39 @@ func SchemaToSpannerDDL(conv *internal.Conv, toddl ToDdl) error {
 			Fks:      cvtForeignKeys(conv, srcTable.Name, srcTable.ForeignKeys),
 			Indexes:  cvtIndexes(conv, spTableName, srcTable.Name, srcTable.Indexes),
 			Comment:  comment}
+		detectHotspot(conv, srcTable, spTableName)
 	}
 	internal.ResolveRefs(conv)
 	return nil
 }
 
+// detectHotspot checks whether the leading primary key column of srcTable
+// is likely to cause hotspots in Spanner: values of auto-increment, serial
+// and timestamp columns are monotonically increasing, and so all inserts go
+// to the same split.
+func detectHotspot(conv *internal.Conv, srcTable schema.Table, spTable string) {
+	if len(srcTable.PrimaryKeys) == 0 {
+		return
+	}
+	srcCol := srcTable.PrimaryKeys[0].Column
+	spCol, err := internal.GetSpannerCol(conv, srcTable.Name, srcCol, false)
+	if err != nil {
+		return
+	}
+	serial := srcTable.ColDefs[srcCol].Ignored.AutoIncrement
+	for _, issue := range conv.Issues[srcTable.Name][srcCol] {
+		serial = serial || issue == internal.Serial
+	}
+	switch conv.SpSchema[spTable].ColDefs[spCol].T.Name {
+	case ddl.Int64:
+		if serial {
+			conv.MarkHotspot(srcTable.Name, srcCol, spTable, internal.SequentialKey)
+		}
+	case ddl.Timestamp, ddl.Date:
+		conv.MarkHotspot(srcTable.Name, srcCol, spTable, internal.TimestampKey)
+	}
+}
+
 func quoteIfNeeded(s string) string {
 	for _, r := range s {
 		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsPunct(r) {
@@ -134,6 +191,18 @@ func cvtForeignKeys(conv *internal.Conv, srcTable string, srcKeys []schema.Forei
 			conv.Unexpected(fmt.Sprintf("ConvertForeignKeys: columns and referColumns don't have the same lengths: len(columns)=%d, len(referColumns)=%d for source table: %s, referenced table: %s", len(key.Columns), len(key.ReferColumns), srcTable, key.ReferTable))
 			continue
 		}
+		if !conv.IncludeTable(key.ReferTable) {
+			// Drop foreign keys that reference excluded tables: the
+			// referenced table won't exist in Spanner.
+			for _, col := range key.Columns {
+				conv.Issues[srcTable][col] = append(conv.Issues[srcTable][col], internal.ExcludedTableForeignKey)
+			}
+			continue
+		}
+		if col, ok := excludedColumn(conv, srcTable, key.Columns, key.ReferTable, key.ReferColumns); ok {
+			conv.Issues[srcTable][col] = append(conv.Issues[srcTable][col], internal.ExcludedColumnForeignKey)
+			continue
+		}
 		spReferTable, err := internal.GetSpannerTable(conv, key.ReferTable)
 		if err != nil {
 			conv.Unexpected(fmt.Sprintf("Can't map foreign key for source table: %s, referenced table: %s", srcTable, key.ReferTable))
@@ -151,6 +220,10 @@ func cvtForeignKeys(conv *internal.Conv, srcTable string, srcKeys []schema.Forei
 			spReferCols = append(spReferCols, spReferCol)
 		}
 		spKeyName := internal.ToSpannerForeignKey(conv, key.Name)
+		if spKeyName != "" {
+			spTable, _ := internal.GetSpannerTable(conv, srcTable)
+			spKeyName = internal.QualifyName(spTable, spKeyName)
+		}
 		spKey := ddl.Foreignkey{
 			Name:         spKeyName,
 			Columns:      spCols,
@@ -164,6 +237,15 @@ func cvtForeignKeys(conv *internal.Conv, srcTable string, srcKeys []schema.Forei
 func cvtIndexes(conv *internal.Conv, spTableName string, srcTable string, srcIndexes []schema.Index) []ddl.CreateIndex {
 	var spIndexes []ddl.CreateIndex
 	for _, srcIndex := range srcIndexes {
+		var cols []string
+		for _, k := range srcIndex.Keys {
+			cols = append(cols, k.Column)
+		}
+		if col, ok := excludedColumn(conv, srcTable, cols, "", nil); ok {
+			// Drop indexes that use excluded columns.
+			conv.Issues[srcTable][col] = append(conv.Issues[srcTable][col], internal.ExcludedColumnIndex)
+			continue
+		}
 		var spKeys []ddl.IndexKey
 		for _, k := range srcIndex.Keys {
 			spCol, err := internal.GetSpannerCol(conv, srcTable, k.Column, true)
@@ -178,7 +260,7 @@ func cvtIndexes(conv *internal.Conv, spTableName string, srcTable string, srcInd
 			// Collision of index name will be handled by ToSpannerIndexName.
 			srcIndex.Name = fmt.Sprintf("Index_%s", srcTable)
 		}
-		spIndexName := internal.ToSpannerIndexName(conv, srcIndex.Name)
+		spIndexName := internal.QualifyName(spTableName, internal.ToSpannerIndexName(conv, srcIndex.Name))
 		spIndex := ddl.CreateIndex{
 			Name:   spIndexName,
 			Table:  spTableName,
@@ -189,3 +271,18 @@ func cvtIndexes(conv *internal.Conv, spTableName string, srcTable string, srcInd
 	}
 	return spIndexes
 }
+
+// excludedColumn checks whether any of cols (columns of srcTable) or
+// referCols (columns of referTable) is excluded by conv's column filters.
+// If so, it returns the column of srcTable to attach the issue to.
+func excludedColumn(conv *internal.Conv, srcTable string, cols []string, referTable string, referCols []string) (string, bool) {
+	for i, col := range cols {
+		if !conv.IncludeColumn(srcTable, col) {
+			return col, true
+		}
+		if i < len(referCols) && !conv.IncludeColumn(referTable, referCols[i]) {
+			return col, true
+		}
+	}
+	return "", false
+}
@@ -33,6 +33,15 @@ import (
 // tables.
 func ProcessData(conv *internal.Conv, client dynamoClient) error {
 	for srcTable, srcSchema := range conv.SrcSchema {
+		// Progress of DynamoDB tables is only tracked at the level of
+		// whole tables: incomplete tables are migrated again from scratch.
+		if conv.Checkpoint().TableComplete(srcTable, "") {
+			internal.VerbosePrintf("Skipping table %s: data already migrated\n", srcTable)
+			continue
+		}
+		// Drop columns excluded by conv's column filters, so that their
+		// values are never converted.
+		srcSchema.ColNames = conv.IncludedColumns(srcTable, srcSchema.ColNames)
 		spTable, err1 := internal.GetSpannerTable(conv, srcTable)
 		spCols, err2 := internal.GetSpannerCols(conv, srcTable, srcSchema.ColNames)
 		spSchema, ok := conv.SpSchema[spTable]
@@ -43,6 +52,7 @@ func ProcessData(conv *internal.Conv, client dynamoClient) error {
 			continue
 		}
 
+		conv.SetRowSource(srcTable, "")
 		err := scan(srcTable, client, func(m map[string]*dynamodb.AttributeValue) {
 			spVals, badCols, srcStrVals := cvtRow(m, srcSchema, spSchema, spCols)
 			if len(badCols) == 0 {
@@ -56,7 +66,9 @@ func ProcessData(conv *internal.Conv, client dynamoClient) error {
 		if err != nil {
 			conv.Stats.BadRows[srcTable] += conv.Stats.Rows[srcTable]
 			conv.Unexpected(fmt.Sprintf("Can't scan the data for table %s: %s", srcTable, err))
+			continue
 		}
+		conv.Checkpoint().TableRead(srcTable, "")
 	}
 	return nil
 }
@@ -57,7 +57,7 @@ type dynamoClient interface {
 func ProcessSchema(conv *internal.Conv, client dynamoClient, tables []string, sampleSize int64) error {
 	if len(tables) == 0 {
 		var err error
-		tables, err = listTables(client)
+		tables, err = listTables(conv, client)
 		if err != nil {
 			return err
 		}
@@ -75,7 +75,9 @@ func ProcessSchema(conv *internal.Conv, client dynamoClient, tables []string, sa
 	return nil
 }
 
-func listTables(client dynamoClient) ([]string, error) {
+// listTables returns the names of the DynamoDB tables that pass conv's
+// table filters.
+func listTables(conv *internal.Conv, client dynamoClient) ([]string, error) {
 	var tables []string
 	input := &dynamodb.ListTablesInput{}
 	for {
@@ -84,7 +86,9 @@ func listTables(client dynamoClient) ([]string, error) {
 			return nil, err
 		}
 		for _, t := range result.TableNames {
-			tables = append(tables, *t)
+			if conv.IncludeTable(*t) {
+				tables = append(tables, *t)
+			}
 		}
 
 		if result.LastEvaluatedTableName == nil {
@@ -348,7 +352,7 @@ func numericParsable(n string) bool {
 // there have been huge changes in the number of rows in a table over the last
 // six hours, the progress calculation could be inaccurate.
 func SetRowStats(conv *internal.Conv, client dynamoClient) {
-	tables, err := listTables(client)
+	tables, err := listTables(conv, client)
 	if err != nil {
 		conv.Unexpected(fmt.Sprintf("Couldn't get list of table: %s", err))
 		return
@@ -460,11 +460,32 @@ func TestListTables(t *testing.T) {
 		listTableOutputs: listTableOutputs,
 	}
 
-	tables, err := listTables(client)
+	tables, err := listTables(internal.MakeConv(), client)
 	assert.Nil(t, err)
 	assert.Equal(t, []string{"table-a", "table-b"}, tables)
 }
 
+func TestListTables_Filters(t *testing.T) {
+	tableNameA := "table-a"
+	tableNameB := "table-b"
+	tableNameC := "other-c"
+
+	listTableOutputs := []dynamodb.ListTablesOutput{
+		{TableNames: []*string{&tableNameA, &tableNameC}, LastEvaluatedTableName: &tableNameC},
+		{TableNames: []*string{&tableNameB}},
+	}
+
+	client := &mockDynamoClient{
+		listTableOutputs: listTableOutputs,
+	}
+
+	conv := internal.MakeConv()
+	conv.Filters = internal.Filters{Tables: []string{"table-*"}, ExcludeTables: []string{"*-b"}}
+	tables, err := listTables(conv, client)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"table-a"}, tables)
+}
+
 func stripSchemaComments(spSchema map[string]ddl.CreateTable) map[string]ddl.CreateTable {
 	for t, ct := range spSchema {
 		for c, cd := range ct.ColDefs {
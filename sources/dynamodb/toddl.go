@@ -36,10 +36,17 @@ func schemaToDDL(conv *internal.Conv) error {
 		}
 		var spColNames []string
 		spColDef := make(map[string]ddl.ColumnDef)
-		conv.Issues[srcTable.Name] = make(map[string][]internal.SchemaIssue)
+		// Issues may already have been added while mapping names.
+		if conv.Issues[srcTable.Name] == nil {
+			conv.Issues[srcTable.Name] = make(map[string][]internal.SchemaIssue)
+		}
 		// Iterate over columns using ColNames order.
 		for _, srcColName := range srcTable.ColNames {
 			srcCol := srcTable.ColDefs[srcColName]
+			if !conv.IncludeColumn(srcTable.Name, srcCol.Name) {
+				conv.Issues[srcTable.Name][srcCol.Name] = []internal.SchemaIssue{internal.ExcludedColumn}
+				continue
+			}
 			colName, err := internal.GetSpannerCol(conv, srcTable.Name, srcCol.Name, false)
 			if err != nil {
 				conv.Unexpected(fmt.Sprintf("Couldn't map source column %s of table %s to Spanner: %s", srcTable.Name, srcCol.Name, err))
@@ -47,9 +54,12 @@ func schemaToDDL(conv *internal.Conv) error {
 			}
 			spColNames = append(spColNames, colName)
 			ty, issues := toSpannerType(conv, srcCol.Type.Name, srcCol.Type.Mods)
+			if conv.Filters.DropsColumn(srcTable.Name, srcCol.Name) {
+				issues = append(issues, internal.ExcludedPrimaryKey)
+			}
 
 			if len(issues) > 0 {
-				conv.Issues[srcTable.Name][srcCol.Name] = issues
+				conv.Issues[srcTable.Name][srcCol.Name] = append(conv.Issues[srcTable.Name][srcCol.Name], issues...)
 			}
 			spColDef[colName] = ddl.ColumnDef{
 				Name:    colName,
@@ -122,7 +132,14 @@ func cvtIndexes(conv *internal.Conv, spTableName string, srcTable string, srcInd
 	var spIndexes []ddl.CreateIndex
 	for _, srcIndex := range srcIndexes {
 		var spKeys []ddl.IndexKey
+		dropped := false
 		for _, k := range srcIndex.Keys {
+			if !conv.IncludeColumn(srcTable, k.Column) {
+				// Drop indexes that use excluded columns.
+				conv.Issues[srcTable][k.Column] = append(conv.Issues[srcTable][k.Column], internal.ExcludedColumnIndex)
+				dropped = true
+				break
+			}
 			spCol, err := internal.GetSpannerCol(conv, srcTable, k.Column, true)
 			if err != nil {
 				conv.Unexpected(fmt.Sprintf("Can't map index key column name for table %s, column %s", srcTable, k.Column))
@@ -130,6 +147,9 @@ func cvtIndexes(conv *internal.Conv, spTableName string, srcTable string, srcInd
 			}
 			spKeys = append(spKeys, ddl.IndexKey{Col: spCol, Desc: k.Desc})
 		}
+		if dropped {
+			continue
+		}
 		spIndexName := internal.ToSpannerIndexName(conv, srcIndex.Name)
 		spIndex := ddl.CreateIndex{
 			Name:   spIndexName,
@@ -0,0 +1,615 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudspannerecosystem/harbourbridge/internal"
+	"github.com/cloudspannerecosystem/harbourbridge/schema"
+	"github.com/cloudspannerecosystem/harbourbridge/spanner/ddl"
+)
+
+// binlogEvent is a change to a row, as printed by mysqlbinlog --verbose
+// for binary logs in ROW format. For example, an update is printed as
+//
+//	### UPDATE `shop`.`orders`
+//	### WHERE
+//	###   @1=42
+//	###   @2='pending'
+//	### SET
+//	###   @1=42
+//	###   @2='shipped'
+//
+// where @N is the Nth column of the table.
+type binlogEvent struct {
+	kind   string                  // One of "INSERT", "UPDATE" or "DELETE".
+	db     string                  // Database of the table changed.
+	table  string                  // Table changed.
+	before []binlogValue           // Column values before the change (UPDATE and DELETE).
+	after  []binlogValue           // Column values after the change (INSERT and UPDATE).
+	pos    internal.SourcePosition // Position of the binlog event containing the change.
+}
+
+// binlogValue is a column value of a binlogEvent. Quoted strings are
+// unescaped, and bit values are converted to bytes.
+type binlogValue struct {
+	val      string
+	null     bool
+	quoted   bool
+	unsigned string // Value of an integer that is negative when read as signed, if the column is unsigned.
+}
+
+var (
+	binlogAtRegexp       = regexp.MustCompile(`^# at (\d+)\s*$`)
+	binlogEndPosRegexp   = regexp.MustCompile(`\send_log_pos (\d+)\s`)
+	binlogRotateRegexp   = regexp.MustCompile(`\sRotate to (\S+)\s+pos: (\d+)`)
+	binlogRowRegexp      = regexp.MustCompile("^### (INSERT INTO|UPDATE|DELETE FROM) `((?:[^`]|``)*)`\\.`((?:[^`]|``)*)`\\s*$")
+	binlogColRegexp      = regexp.MustCompile(`^###   @(\d+)=(.*)$`)
+	binlogUnsignedRegexp = regexp.MustCompile(`^\((\d+)\)`)
+)
+
+// binlogParser reads row changes from the output of mysqlbinlog, keeping
+// track of positions in the binary logs as it goes. Since mysqlbinlog
+// doesn't print the name of the binary log it reads, the output must start
+// with file (later files are identified by their rotate events).
+type binlogParser struct {
+	r       *bufio.Reader
+	line    int            // Number of lines read.
+	file    string         // Binary log being read.
+	at      uint64         // Position of the event being read.
+	endPos  uint64         // Position of the event after the one being read.
+	event   *binlogEvent   // Change being read (nil if none).
+	image   *[]binlogValue // Column values being read (event.before or event.after).
+	commits []internal.SourcePosition
+}
+
+func newBinlogParser(r io.Reader, file string) *binlogParser {
+	return &binlogParser{r: bufio.NewReader(r), file: file}
+}
+
+// next returns the next row change, along with the positions of
+// transactions committed before it, in order. At the end of the input
+// it returns a nil event.
+func (p *binlogParser) next() (*binlogEvent, []internal.SourcePosition, error) {
+	for {
+		s, err := p.r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, nil, err
+		}
+		if s == "" && err == io.EOF {
+			return p.flush(), p.takeCommits(), nil
+		}
+		p.line++
+		s = strings.TrimRight(s, "\r\n")
+		e, parseErr := p.parseLine(s)
+		if parseErr != nil {
+			return nil, nil, fmt.Errorf("line %d of binlog: %v", p.line, parseErr)
+		}
+		if e != nil {
+			return e, p.takeCommits(), nil
+		}
+	}
+}
+
+// parseLine processes a line of mysqlbinlog output, returning the change
+// completed by the line (if any).
+func (p *binlogParser) parseLine(s string) (*binlogEvent, error) {
+	switch {
+	case strings.HasPrefix(s, "###"):
+		return p.parseRowLine(s)
+	case strings.HasPrefix(s, "#"):
+		if m := binlogAtRegexp.FindStringSubmatch(s); m != nil {
+			e := p.flush()
+			at, err := strconv.ParseUint(m[1], 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			p.at = at
+			return e, nil
+		}
+		if m := binlogEndPosRegexp.FindStringSubmatch(s); m != nil {
+			endPos, err := strconv.ParseUint(m[1], 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			p.endPos = endPos
+		}
+		if m := binlogRotateRegexp.FindStringSubmatch(s); m != nil {
+			pos, err := strconv.ParseUint(m[2], 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			// Rotate events are written between transactions, so
+			// everything before them is committed.
+			e := p.flush()
+			p.file = m[1]
+			p.commits = append(p.commits, internal.SourcePosition{BinlogFile: p.file, BinlogPos: pos})
+			return e, nil
+		}
+	case strings.HasPrefix(s, "COMMIT"):
+		e := p.flush()
+		p.commits = append(p.commits, internal.SourcePosition{BinlogFile: p.file, BinlogPos: p.endPos})
+		return e, nil
+	}
+	return nil, nil
+}
+
+func (p *binlogParser) parseRowLine(s string) (*binlogEvent, error) {
+	if m := binlogRowRegexp.FindStringSubmatch(s); m != nil {
+		e := p.flush()
+		kind := strings.Fields(m[1])[0]
+		p.event = &binlogEvent{
+			kind:  kind,
+			db:    strings.Replace(m[2], "``", "`", -1),
+			table: strings.Replace(m[3], "``", "`", -1),
+			pos:   internal.SourcePosition{BinlogFile: p.file, BinlogPos: p.at},
+		}
+		if kind == "INSERT" {
+			p.image = &p.event.after
+		} else {
+			p.image = &p.event.before
+		}
+		return e, nil
+	}
+	if p.event == nil {
+		return nil, fmt.Errorf("unexpected row data: %s", s)
+	}
+	switch strings.TrimSpace(strings.TrimPrefix(s, "###")) {
+	case "WHERE":
+		p.image = &p.event.before
+		return nil, nil
+	case "SET":
+		p.image = &p.event.after
+		return nil, nil
+	}
+	m := binlogColRegexp.FindStringSubmatch(s)
+	if m == nil {
+		return nil, fmt.Errorf("unexpected row data: %s", s)
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil || n != len(*p.image)+1 {
+		return nil, fmt.Errorf("unexpected column @%s (want @%d)", m[1], len(*p.image)+1)
+	}
+	v, err := parseBinlogValue(m[2])
+	if err != nil {
+		return nil, fmt.Errorf("can't parse value of column @%d: %v", n, err)
+	}
+	*p.image = append(*p.image, v)
+	return nil, nil
+}
+
+// flush returns the change being read (if any), which is complete.
+func (p *binlogParser) flush() *binlogEvent {
+	e := p.event
+	p.event = nil
+	p.image = nil
+	return e
+}
+
+func (p *binlogParser) takeCommits() []internal.SourcePosition {
+	l := p.commits
+	p.commits = nil
+	return l
+}
+
+// parseBinlogValue parses a column value printed by mysqlbinlog, which may
+// be followed by a comment describing its type (with -vv). Integers that
+// are negative when read as signed are printed as e.g. "-1 (4294967295)":
+// the binlog doesn't record whether columns are unsigned, so we keep both
+// values and pick one from the source schema (see normalizeBinlogValue).
+func parseBinlogValue(s string) (binlogValue, error) {
+	switch {
+	case strings.HasPrefix(s, "'"):
+		v, err := unquoteBinlogString(s)
+		return binlogValue{val: v, quoted: true}, err
+	case strings.HasPrefix(s, "b'"):
+		v, err := unquoteBinlogString(s[1:])
+		if err != nil {
+			return binlogValue{}, err
+		}
+		b, err := bitsToBytes(v)
+		return binlogValue{val: b, quoted: true}, err
+	}
+	v, rest := s, ""
+	if i := strings.IndexAny(v, " \t"); i >= 0 {
+		v, rest = v[:i], strings.TrimSpace(v[i:])
+	}
+	if v == "" {
+		return binlogValue{}, fmt.Errorf("missing value")
+	}
+	if v == "NULL" {
+		return binlogValue{null: true}, nil
+	}
+	if m := binlogUnsignedRegexp.FindStringSubmatch(rest); m != nil {
+		return binlogValue{val: v, unsigned: m[1]}, nil
+	}
+	return binlogValue{val: v}, nil
+}
+
+// unquoteBinlogString unquotes a string printed by mysqlbinlog. Quotes,
+// backslashes and control characters are printed as \xNN escapes, and all
+// other bytes are printed as is.
+func unquoteBinlogString(s string) (string, error) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\'':
+			return b.String(), nil
+		case '\\':
+			if i+1 < len(s) && (s[i+1] == '\'' || s[i+1] == '\\') {
+				b.WriteByte(s[i+1])
+				i++
+				continue
+			}
+			if i+3 >= len(s) || s[i+1] != 'x' {
+				return "", fmt.Errorf("bad escape in string %s", s)
+			}
+			x, err := strconv.ParseUint(s[i+2:i+4], 16, 8)
+			if err != nil {
+				return "", fmt.Errorf("bad escape in string %s", s)
+			}
+			b.WriteByte(byte(x))
+			i += 3
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", fmt.Errorf("unterminated string %s", s)
+}
+
+// bitsToBytes converts a string of binary digits to the big-endian bytes
+// MySQL uses to store BIT values.
+func bitsToBytes(bits string) (string, error) {
+	b := make([]byte, (len(bits)+7)/8)
+	for i, c := range bits {
+		if c != '0' && c != '1' {
+			return "", fmt.Errorf("bad bit value %s", bits)
+		}
+		if c == '1' {
+			j := len(bits) - 1 - i
+			b[len(b)-1-j/8] |= 1 << uint(j%8)
+		}
+	}
+	return string(b), nil
+}
+
+// ReplayBinlog replays the row changes in r, the output of mysqlbinlog
+// --verbose for binary logs written in ROW format (with
+// binlog_row_image=FULL), starting with binary log start.BinlogFile.
+// Changes are applied in order: inserts and updates are written with
+// conv's data sink, and deletes use conv's delete sink. Changes made before
+// start, and changes to tables that aren't being migrated, are skipped.
+// Tables of database dbName (or of any database, if dbName is empty) match
+// source tables with the same name. ReplayBinlog returns the position
+// after the last transaction read, from which later changes can be
+// replayed.
+func ReplayBinlog(conv *internal.Conv, r io.Reader, dbName string, start internal.SourcePosition) (internal.SourcePosition, error) {
+	p := newBinlogParser(r, start.BinlogFile)
+	last := start
+	for {
+		e, commits, err := p.next()
+		if err != nil {
+			return last, err
+		}
+		for _, pos := range commits {
+			if !binlogBefore(pos, last) {
+				last = pos
+			}
+		}
+		if e == nil {
+			return last, nil
+		}
+		if binlogBefore(e.pos, start) {
+			continue
+		}
+		replayEvent(conv, e, dbName)
+	}
+}
+
+// binlogBefore returns true if position a is before position b. Binary log
+// file names have a base name and a sequence number (e.g. mysql-bin.000042),
+// which can grow past its zero padding, so sequence numbers are compared as
+// integers.
+func binlogBefore(a, b internal.SourcePosition) bool {
+	if a.BinlogFile != b.BinlogFile {
+		baseA, seqA := splitBinlogFile(a.BinlogFile)
+		baseB, seqB := splitBinlogFile(b.BinlogFile)
+		if baseA != baseB || seqA == seqB {
+			return a.BinlogFile < b.BinlogFile
+		}
+		return seqA < seqB
+	}
+	return a.BinlogPos < b.BinlogPos
+}
+
+// splitBinlogFile splits a binary log file name into its base name and
+// sequence number (-1 if the name has no numeric extension).
+func splitBinlogFile(file string) (string, int64) {
+	i := strings.LastIndex(file, ".")
+	if i < 0 {
+		return file, -1
+	}
+	n, err := strconv.ParseInt(file[i+1:], 10, 64)
+	if err != nil {
+		return file, -1
+	}
+	return file[:i], n
+}
+
+// binlogTable returns the source table changed by e, or false if the table
+// isn't being migrated.
+func binlogTable(conv *internal.Conv, e *binlogEvent, dbName string) (string, bool) {
+	// Tables of databases other than the default database of a dump
+	// are qualified with their database.
+	if t := e.db + "." + e.table; hasTable(conv, t) {
+		return t, conv.IncludeTable(t)
+	}
+	if dbName != "" && e.db != dbName {
+		return "", false
+	}
+	if !hasTable(conv, e.table) {
+		return "", false
+	}
+	return e.table, conv.IncludeTable(e.table)
+}
+
+func hasTable(conv *internal.Conv, srcTable string) bool {
+	_, ok := conv.SrcSchema[srcTable]
+	return ok
+}
+
+// replayEvent converts a row change and writes it to Spanner.
+func replayEvent(conv *internal.Conv, e *binlogEvent, dbName string) {
+	srcTable, ok := binlogTable(conv, e, dbName)
+	if !ok {
+		return
+	}
+	spTable, err := internal.GetSpannerTable(conv, srcTable)
+	if err != nil {
+		conv.Unexpected(fmt.Sprintf("Can't get spanner table name for source table '%s' : err=%s", srcTable, err))
+		conv.StatsAddRow(srcTable, conv.DataMode())
+		conv.StatsAddBadRow(srcTable, conv.DataMode())
+		return
+	}
+	// Rows of tables with synthetic primary keys can't be identified, so
+	// their changes are skipped (and listed in the report) rather than
+	// reported as bad rows.
+	if _, ok := conv.SyntheticPKeys[spTable]; ok {
+		conv.Unexpected(fmt.Sprintf("Skipped changes to table %s: tables without a primary key can't be replayed", srcTable))
+		return
+	}
+	conv.StatsAddRow(srcTable, conv.DataMode())
+	srcSchema := conv.SrcSchema[srcTable]
+	spSchema := conv.SpSchema[spTable]
+	// Deletes only need the primary key of the row they delete.
+	before, err1 := convertBinlogRow(conv, srcTable, srcSchema, spTable, spSchema, keyOnly(srcSchema, e.before))
+	after, err2 := convertBinlogRow(conv, srcTable, srcSchema, spTable, spSchema, e.after)
+	if err1 != nil || err2 != nil {
+		err := err1
+		if err == nil {
+			err = err2
+		}
+		conv.Unexpected(fmt.Sprintf("Error while converting data: %s\n", err))
+		conv.StatsAddBadRow(srcTable, conv.DataMode())
+		vals := e.after
+		if e.kind == "DELETE" {
+			vals = e.before
+		}
+		n := len(vals)
+		if n > len(srcSchema.ColNames) {
+			n = len(srcSchema.ColNames)
+		}
+		conv.CollectBadRow(srcTable, srcSchema.ColNames[:n], binlogStrings(vals))
+		return
+	}
+	switch e.kind {
+	case "INSERT":
+		conv.WriteRow(srcTable, spTable, after.cols, after.vals)
+	case "UPDATE":
+		// An update that changes the primary key moves the row.
+		if keyChanged(srcSchema, e) {
+			conv.StatsAddRow(srcTable, conv.DataMode())
+			conv.DeleteRow(srcTable, spTable, before.cols, before.vals)
+		}
+		conv.WriteRow(srcTable, spTable, after.cols, after.vals)
+	case "DELETE":
+		conv.DeleteRow(srcTable, spTable, before.cols, before.vals)
+	}
+}
+
+type binlogRow struct {
+	cols []string
+	vals []interface{}
+}
+
+// convertBinlogRow converts the column values of a row change to Spanner
+// columns and values. Unlike ConvertData, NULL values are returned as nil,
+// so that updates overwrite the previous values.
+func convertBinlogRow(conv *internal.Conv, srcTable string, srcSchema schema.Table, spTable string, spSchema ddl.CreateTable, vals []binlogValue) (binlogRow, error) {
+	if len(vals) == 0 {
+		return binlogRow{}, nil
+	}
+	if len(vals) > len(srcSchema.ColNames) {
+		return binlogRow{}, fmt.Errorf("row has %d columns, but table %s has %d", len(vals), srcTable, len(srcSchema.ColNames))
+	}
+	srcCols := srcSchema.ColNames[:len(vals)]
+	spCols, err := internal.GetSpannerCols(conv, srcTable, srcCols)
+	if err != nil {
+		return binlogRow{}, err
+	}
+	var nonNull []string
+	var nulls []string
+	for i, v := range vals {
+		if v.null {
+			if conv.IncludeColumn(srcTable, srcCols[i]) {
+				nulls = append(nulls, spCols[i])
+			}
+			nonNull = append(nonNull, "NULL")
+			continue
+		}
+		s, err := normalizeBinlogValue(conv, srcSchema.ColDefs[srcCols[i]].Type, v)
+		if err != nil {
+			return binlogRow{}, fmt.Errorf("column %s: %v", srcCols[i], err)
+		}
+		nonNull = append(nonNull, s)
+	}
+	_, cols, cvtVals, err := ConvertData(conv, srcTable, srcCols, srcSchema, spTable, spCols, spSchema, nonNull)
+	if err != nil {
+		return binlogRow{}, err
+	}
+	for _, c := range nulls {
+		cols = append(cols, c)
+		cvtVals = append(cvtVals, nil)
+	}
+	return binlogRow{cols, cvtVals}, nil
+}
+
+// normalizeBinlogValue converts a value printed by mysqlbinlog into the
+// format used by mysqldump, which ConvertData expects. Integers printed as
+// both signed and unsigned use the value matching srcType.
+func normalizeBinlogValue(conv *internal.Conv, srcType schema.Type, v binlogValue) (string, error) {
+	if v.unsigned != "" && srcType.Unsigned {
+		return v.unsigned, nil
+	}
+	switch srcType.Name {
+	case "date":
+		// Dates are printed as 'YYYY:MM:DD'.
+		return strings.Replace(v.val, ":", "-", -1), nil
+	case "timestamp":
+		// Timestamps are printed as seconds since the epoch (in UTC).
+		if v.quoted {
+			return v.val, nil
+		}
+		return binlogTimestamp(v.val, conv.TimezoneOffset)
+	case "enum", "set":
+		return binlogEnumValue(srcType, v.val)
+	}
+	return v.val, nil
+}
+
+// binlogEnumValue converts the index of an ENUM value (starting at 1, with
+// 0 for the empty string of invalid values) or the bitmask of a SET value,
+// as printed by mysqlbinlog, into the value itself. SET values are
+// comma-separated, as mysqldump prints them.
+func binlogEnumValue(srcType schema.Type, s string) (string, error) {
+	if len(srcType.Values) == 0 {
+		return "", fmt.Errorf("can't replay %s values: the source schema has no list of values", strings.ToUpper(srcType.Name))
+	}
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("bad %s value %q", strings.ToUpper(srcType.Name), s)
+	}
+	if srcType.Name == "enum" {
+		if n == 0 {
+			return "", nil
+		}
+		if n > uint64(len(srcType.Values)) {
+			return "", fmt.Errorf("ENUM index %d out of range", n)
+		}
+		return srcType.Values[n-1], nil
+	}
+	var l []string
+	for i, val := range srcType.Values {
+		if i < 64 && n&(1<<uint(i)) != 0 {
+			l = append(l, val)
+			n &^= 1 << uint(i)
+		}
+	}
+	if n != 0 {
+		return "", fmt.Errorf("SET bitmask %s has bits out of range", s)
+	}
+	return strings.Join(l, ","), nil
+}
+
+// binlogTimestamp formats a timestamp printed as seconds since the epoch
+// in the timezone with offset tzOffset, as mysqldump would.
+func binlogTimestamp(s, tzOffset string) (string, error) {
+	if tzOffset == "" {
+		tzOffset = "+00:00"
+	}
+	tz, err := time.Parse("-07:00", tzOffset)
+	if err != nil {
+		return "", fmt.Errorf("bad timezone offset %s", tzOffset)
+	}
+	_, offset := tz.Zone()
+	secs, frac := s, ""
+	if i := strings.Index(s, "."); i >= 0 {
+		secs, frac = s[:i], s[i+1:]
+	}
+	sec, err := strconv.ParseInt(secs, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("can't convert %s to timestamp", s)
+	}
+	var nsec int64
+	if frac != "" {
+		if len(frac) > 9 {
+			return "", fmt.Errorf("can't convert %s to timestamp", s)
+		}
+		nsec, err = strconv.ParseInt(frac+strings.Repeat("0", 9-len(frac)), 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("can't convert %s to timestamp", s)
+		}
+	}
+	t := time.Unix(sec, nsec).In(time.FixedZone("", offset))
+	return t.Format("2006-01-02 15:04:05.999999999"), nil
+}
+
+// keyChanged returns true if the primary key of a row is changed by e.
+func keyChanged(srcSchema schema.Table, e *binlogEvent) bool {
+	for _, k := range srcSchema.PrimaryKeys {
+		for i, c := range srcSchema.ColNames {
+			if c == k.Column && i < len(e.before) && i < len(e.after) && e.before[i] != e.after[i] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// keyOnly returns vals with all but the primary key columns set to NULL.
+func keyOnly(srcSchema schema.Table, vals []binlogValue) []binlogValue {
+	keys := make(map[string]bool)
+	for _, k := range srcSchema.PrimaryKeys {
+		keys[k.Column] = true
+	}
+	l := make([]binlogValue, len(vals))
+	for i, v := range vals {
+		if i < len(srcSchema.ColNames) && keys[srcSchema.ColNames[i]] {
+			l[i] = v
+		} else {
+			l[i] = binlogValue{null: true}
+		}
+	}
+	return l
+}
+
+func binlogStrings(vals []binlogValue) []string {
+	var l []string
+	for _, v := range vals {
+		if v.null {
+			l = append(l, "NULL")
+		} else {
+			l = append(l, v.val)
+		}
+	}
+	return l
+}
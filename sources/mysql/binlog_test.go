@@ -0,0 +1,312 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudspannerecosystem/harbourbridge/internal"
+)
+
+func TestParseBinlogValue(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected binlogValue
+	}{
+		{"42", binlogValue{val: "42"}},
+		{"42 /* INT meta=0 nullable=0 is_null=0 */", binlogValue{val: "42"}},
+		{"-1 (4294967295)", binlogValue{val: "-1", unsigned: "4294967295"}},
+		{"-1 (18446744073709551615) /* LONGINT meta=0 nullable=0 is_null=0 */", binlogValue{val: "-1", unsigned: "18446744073709551615"}},
+		{"1.5", binlogValue{val: "1.5"}},
+		{"NULL", binlogValue{null: true}},
+		{"'abc'", binlogValue{val: "abc", quoted: true}},
+		{`'it\x27s a \x5c\x0a' /* VARSTRING(80) meta=80 nullable=1 is_null=0 */`, binlogValue{val: "it's a \\\n", quoted: true}},
+		{"'héllo'", binlogValue{val: "héllo", quoted: true}},
+		{"'2021:01:02'", binlogValue{val: "2021:01:02", quoted: true}},
+		{"b'0000000100000011'", binlogValue{val: "\x01\x03", quoted: true}},
+		{"b'101'", binlogValue{val: "\x05", quoted: true}},
+	}
+	for _, tc := range tests {
+		v, err := parseBinlogValue(tc.in)
+		assert.Nil(t, err, tc.in)
+		assert.Equal(t, tc.expected, v, tc.in)
+	}
+	for _, s := range []string{"", "'abc", `'a\x2'`, "b'102'"} {
+		_, err := parseBinlogValue(s)
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestBinlogBefore(t *testing.T) {
+	pos := func(file string, p uint64) internal.SourcePosition {
+		return internal.SourcePosition{BinlogFile: file, BinlogPos: p}
+	}
+	assert.True(t, binlogBefore(pos("mysql-bin.000001", 900), pos("mysql-bin.000002", 4)))
+	assert.True(t, binlogBefore(pos("mysql-bin.000002", 4), pos("mysql-bin.000002", 125)))
+	assert.False(t, binlogBefore(pos("mysql-bin.000002", 125), pos("mysql-bin.000002", 125)))
+	// Sequence numbers can outgrow their zero padding.
+	assert.True(t, binlogBefore(pos("mysql-bin.999999", 4), pos("mysql-bin.1000000", 4)))
+	assert.False(t, binlogBefore(pos("mysql-bin.1000000", 4), pos("mysql-bin.999999", 4)))
+}
+
+// binlogOp is a write or delete made by ReplayBinlog.
+type binlogOp struct {
+	kind  string
+	table string
+	cols  []string
+	vals  []interface{}
+}
+
+func TestReplayBinlog(t *testing.T) {
+	dump := "CREATE TABLE orders (\n" +
+		"  id int NOT NULL,\n" +
+		"  status varchar(20),\n" +
+		"  placed date,\n" +
+		"  updated timestamp NULL,\n" +
+		"  note text,\n" +
+		"  PRIMARY KEY (id)\n" +
+		");\n" +
+		"CREATE TABLE nokey (x int);\n"
+	binlog := `/*!50530 SET @@SESSION.PSEUDO_SLAVE_MODE=1*/;
+DELIMITER /*!*/;
+# at 4
+#210102  3:04:05 server id 1  end_log_pos 125 CRC32 0x1d2f3e4a 	Start: binlog v 4, server v 8.0.23 created 210102  3:04:05
+# at 125
+#210102  3:04:05 server id 1  end_log_pos 200 CRC32 0x5d5e6b48 	Query	thread_id=8	exec_time=0	error_code=0
+BEGIN
+/*!*/;
+# at 200
+#210102  3:04:05 server id 1  end_log_pos 250 CRC32 0x2b3c4d5e 	Table_map: ` + "`shop`.`orders`" + ` mapped to number 90
+# at 250
+#210102  3:04:05 server id 1  end_log_pos 300 CRC32 0x3c4d5e6f 	Write_rows: table id 90 flags: STMT_END_F
+### INSERT INTO ` + "`shop`.`orders`" + `
+### SET
+###   @1=1
+###   @2='old'
+###   @3=NULL
+###   @4=NULL
+###   @5=NULL
+# at 300
+#210102  3:04:05 server id 1  end_log_pos 331 CRC32 0x4d5e6f70 	Xid = 10
+COMMIT/*!*/;
+# at 331
+#210102  3:04:06 server id 1  end_log_pos 400 CRC32 0x5e6f7081 	Query	thread_id=8	exec_time=0	error_code=0
+BEGIN
+/*!*/;
+# at 400
+#210102  3:04:06 server id 1  end_log_pos 700 CRC32 0x6f708192 	Write_rows: table id 90 flags: STMT_END_F
+
+BINLOG '
+AAAAAAAAAAAAAAAAAAAAAAAA
+'/*!*/;
+### INSERT INTO ` + "`shop`.`orders`" + `
+### SET
+###   @1=2
+###   @2='new'
+###   @3='2021:01:02'
+###   @4=1609556645
+###   @5='it\x27s'
+# at 700
+#210102  3:04:06 server id 1  end_log_pos 800 CRC32 0x708192a3 	Update_rows: table id 90 flags: STMT_END_F
+### UPDATE ` + "`shop`.`orders`" + `
+### WHERE
+###   @1=2
+###   @2='new'
+###   @3='2021:01:02'
+###   @4=1609556645
+###   @5='it\x27s'
+### SET
+###   @1=2
+###   @2='shipped'
+###   @3='2021:01:02'
+###   @4=1609556645
+###   @5=NULL
+### UPDATE ` + "`shop`.`orders`" + `
+### WHERE
+###   @1=2
+###   @2='shipped'
+###   @3='2021:01:02'
+###   @4=1609556645
+###   @5=NULL
+### SET
+###   @1=3
+###   @2='shipped'
+###   @3='2021:01:02'
+###   @4=1609556645
+###   @5=NULL
+# at 800
+#210102  3:04:06 server id 1  end_log_pos 850 CRC32 0x8192a3b4 	Delete_rows: table id 90 flags: STMT_END_F
+### DELETE FROM ` + "`shop`.`orders`" + `
+### WHERE
+###   @1=1
+###   @2='old'
+###   @3=NULL
+###   @4=NULL
+###   @5=NULL
+# at 850
+#210102  3:04:06 server id 1  end_log_pos 870 CRC32 0x92a3b4c5 	Write_rows: table id 91 flags: STMT_END_F
+### INSERT INTO ` + "`crm`.`orders`" + `
+### SET
+###   @1=7
+### INSERT INTO ` + "`shop`.`nokey`" + `
+### SET
+###   @1=5
+# at 870
+#210102  3:04:06 server id 1  end_log_pos 900 CRC32 0xa3b4c5d6 	Xid = 11
+COMMIT/*!*/;
+# at 900
+#210102  3:04:07 server id 1  end_log_pos 950 CRC32 0xb4c5d6e7 	Rotate to mysql-bin.000002  pos: 4
+# at 4
+#210102  3:04:07 server id 1  end_log_pos 125 CRC32 0xc5d6e7f8 	Start: binlog v 4, server v 8.0.23 created 210102  3:04:07
+# at 125
+#210102  3:04:07 server id 1  end_log_pos 200 CRC32 0xd6e7f809 	Query	thread_id=8	exec_time=0	error_code=0
+BEGIN
+/*!*/;
+# at 200
+#210102  3:04:07 server id 1  end_log_pos 300 CRC32 0xe7f8091a 	Write_rows: table id 90 flags: STMT_END_F
+### INSERT INTO ` + "`shop`.`orders`" + `
+### SET
+###   @1=4 /* INT meta=0 nullable=0 is_null=0 */
+###   @2='a' /* VARSTRING(80) meta=80 nullable=1 is_null=0 */
+###   @3=NULL /* DATE meta=0 nullable=1 is_null=1 */
+###   @4=1609556645.5 /* TIMESTAMP(1) meta=1 nullable=1 is_null=0 */
+###   @5=NULL /* BLOB/TEXT meta=2 nullable=1 is_null=1 */
+# at 300
+#210102  3:04:07 server id 1  end_log_pos 500 CRC32 0xf8091a2b 	Xid = 12
+COMMIT/*!*/;
+# at 500
+#210102  3:04:08 server id 1  end_log_pos 575 CRC32 0x091a2b3c 	Query	thread_id=8	exec_time=0	error_code=0
+BEGIN
+/*!*/;
+# at 575
+#210102  3:04:08 server id 1  end_log_pos 640 CRC32 0x1a2b3c4d 	Delete_rows: table id 90 flags: STMT_END_F
+### DELETE FROM ` + "`shop`.`orders`" + `
+### WHERE
+###   @1=4
+###   @2='a'
+###   @3=NULL
+###   @4=1609556645.5
+###   @5=NULL
+DELIMITER ;
+# End of log file
+`
+	conv, _ := runProcessMySQLDump(dump)
+	var ops []binlogOp
+	conv.SetDataSink(func(table string, cols []string, vals []interface{}) {
+		ops = append(ops, binlogOp{"write", table, cols, vals})
+	})
+	conv.SetDeleteSink(func(table string, keyCols []string, key []interface{}) {
+		ops = append(ops, binlogOp{"delete", table, keyCols, key})
+	})
+	start := internal.SourcePosition{BinlogFile: "mysql-bin.000001", BinlogPos: 331}
+	pos, err := ReplayBinlog(conv, strings.NewReader(binlog), "shop", start)
+	assert.Nil(t, err)
+	// The last transaction isn't committed, so it must be replayed again.
+	assert.Equal(t, internal.SourcePosition{BinlogFile: "mysql-bin.000002", BinlogPos: 500}, pos)
+
+	cols := []string{"id", "status", "placed", "updated", "note"}
+	date := civil.Date{Year: 2021, Month: 1, Day: 2}
+	ts := getTime(t, "2021-01-02T03:04:05+00:00")
+	expected := []binlogOp{
+		{"write", "orders", cols, []interface{}{int64(2), "new", date, ts, "it's"}},
+		{"write", "orders", cols, []interface{}{int64(2), "shipped", date, ts, nil}},
+		{"delete", "orders", []string{"id"}, []interface{}{int64(2)}},
+		{"write", "orders", cols, []interface{}{int64(3), "shipped", date, ts, nil}},
+		{"delete", "orders", []string{"id"}, []interface{}{int64(1)}},
+		{"write", "orders", []string{"id", "status", "updated", "placed", "note"}, []interface{}{int64(4), "a", ts.Add(500 * time.Millisecond), nil, nil}},
+		{"delete", "orders", []string{"id"}, []interface{}{int64(4)}},
+	}
+	assert.Equal(t, len(expected), len(ops))
+	for i := range expected {
+		if i < len(ops) {
+			assert.Equal(t, expected[i].kind, ops[i].kind, "op %d", i)
+			assert.Equal(t, expected[i].cols, ops[i].cols, "op %d", i)
+			assert.Equal(t, expected[i].vals, ops[i].vals, "op %d", i)
+		}
+	}
+	// Changes to tables without a primary key can't be replayed: they are
+	// skipped and reported, but aren't bad rows.
+	assert.Equal(t, int64(0), conv.BadRows())
+	assert.Equal(t, int64(0), conv.Stats.Rows["nokey"])
+	assert.Equal(t, int64(1), conv.Stats.Unexpected["Skipped changes to table nokey: tables without a primary key can't be replayed"])
+}
+
+func TestReplayBinlog_Unsigned(t *testing.T) {
+	conv, _ := runProcessMySQLDump("CREATE TABLE t (id int unsigned NOT NULL, n int, PRIMARY KEY (id));\n")
+	assert.True(t, conv.SrcSchema["t"].ColDefs["id"].Type.Unsigned)
+	assert.False(t, conv.SrcSchema["t"].ColDefs["n"].Type.Unsigned)
+	var vals [][]interface{}
+	conv.SetDataSink(func(table string, cols []string, v []interface{}) {
+		vals = append(vals, v)
+	})
+	conv.SetDeleteSink(func(table string, keyCols []string, key []interface{}) {})
+	start := internal.SourcePosition{BinlogFile: "mysql-bin.000001", BinlogPos: 4}
+	// Negative values are only negative in signed columns.
+	_, err := ReplayBinlog(conv, strings.NewReader("# at 10\n### INSERT INTO `db`.`t`\n### SET\n###   @1=-1 (4294967295)\n###   @2=-1 (4294967295)\n"), "", start)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]interface{}{{int64(4294967295), int64(-1)}}, vals)
+	assert.Equal(t, int64(0), conv.BadRows())
+}
+
+func TestReplayBinlog_EnumAndSet(t *testing.T) {
+	conv, _ := runProcessMySQLDump("CREATE TABLE t (id int NOT NULL, kind enum('a','b''s'), tags set('x','y','z'), PRIMARY KEY (id));\n")
+	assert.Equal(t, []string{"a", "b's"}, conv.SrcSchema["t"].ColDefs["kind"].Type.Values)
+	var vals [][]interface{}
+	conv.SetDataSink(func(table string, cols []string, v []interface{}) {
+		vals = append(vals, v)
+	})
+	conv.SetDeleteSink(func(table string, keyCols []string, key []interface{}) {})
+	start := internal.SourcePosition{BinlogFile: "mysql-bin.000001", BinlogPos: 4}
+	// mysqlbinlog prints the index of ENUM values, and the bitmask of SET
+	// values.
+	_, err := ReplayBinlog(conv, strings.NewReader("# at 10\n### INSERT INTO `db`.`t`\n### SET\n###   @1=1\n###   @2=2\n###   @3=5\n"), "", start)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]interface{}{{int64(1), "b's", []spanner.NullString{{StringVal: "x", Valid: true}, {StringVal: "z", Valid: true}}}}, vals)
+	assert.Equal(t, int64(0), conv.BadRows())
+}
+
+func TestEnumValues(t *testing.T) {
+	assert.Equal(t, []string{"a", "b's", "c,d"}, enumValues("enum('a','b''s','c,d')"))
+	assert.Equal(t, []string{"x", ""}, enumValues("set('x','')"))
+	assert.Nil(t, enumValues("int(11)"))
+}
+
+func TestReplayBinlog_Errors(t *testing.T) {
+	conv, _ := runProcessMySQLDump("CREATE TABLE t (id int NOT NULL, kind enum('a','b'), PRIMARY KEY (id));\n")
+	conv.SetDataSink(func(table string, cols []string, vals []interface{}) {})
+	conv.SetDeleteSink(func(table string, keyCols []string, key []interface{}) {})
+	start := internal.SourcePosition{BinlogFile: "mysql-bin.000001", BinlogPos: 4}
+
+	// ENUM indexes must be in range.
+	_, err := ReplayBinlog(conv, strings.NewReader("# at 10\n### INSERT INTO `db`.`t`\n### SET\n###   @1=1\n###   @2=3\n"), "", start)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), conv.Stats.BadRows["t"])
+	// But rows can still be deleted, since only their key is needed.
+	_, err = ReplayBinlog(conv, strings.NewReader("# at 10\n### DELETE FROM `db`.`t`\n### WHERE\n###   @1=1\n###   @2=3\n"), "", start)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), conv.Stats.BadRows["t"])
+
+	// Malformed output is an error.
+	_, err = ReplayBinlog(conv, strings.NewReader("# at 10\n### INSERT INTO `db`.`t`\n### SET\n###   @2=1\n"), "", start)
+	assert.NotNil(t, err)
+	_, err = ReplayBinlog(conv, strings.NewReader("###   @1=1\n"), "", start)
+	assert.NotNil(t, err)
+}
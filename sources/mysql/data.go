@@ -17,7 +17,6 @@ package mysql
 import (
 	"fmt"
 	"math/big"
-	"math/bits"
 	"strconv"
 	"strings"
 	"time"
@@ -56,6 +55,9 @@ func ConvertData(conv *internal.Conv, srcTable string, srcCols []string, srcSche
 	}
 	for i, spCol := range spCols {
 		srcCol := srcCols[i]
+		if !conv.IncludeColumn(srcTable, srcCol) {
+			continue
+		}
 		// Skip columns with 'NULL' values. When processing data rows from mysqldump, these values
 		// are represented as nil (by pingcap/tidb/types/parser_driver's ValueExpr), which is
 		// converted to the string '<nil>'. When processing data rows obtained from the MySQL driver,
@@ -83,9 +85,7 @@ func ConvertData(conv *internal.Conv, srcTable string, srcCols []string, srcSche
 	}
 	if aux, ok := conv.SyntheticPKeys[spTable]; ok {
 		c = append(c, aux.Col)
-		v = append(v, int64(bits.Reverse64(uint64(aux.Sequence))))
-		aux.Sequence++
-		conv.SyntheticPKeys[spTable] = aux
+		v = append(v, conv.NextSyntheticPKey(spTable))
 	}
 	return spTable, c, v, nil
 }
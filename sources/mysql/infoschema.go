@@ -42,9 +42,10 @@ func (isi InfoSchemaImpl) GetTableName(dbName string, tableName string) string {
 	return tableName
 }
 
-func (isi InfoSchemaImpl) GetRowsFromTable(conv *internal.Conv, db *sql.DB, table common.SchemaAndName) (*sql.Rows, error) {
+func (isi InfoSchemaImpl) GetRowsFromTable(conv *internal.Conv, q common.Queryer, table common.SchemaAndName, kr common.KeyRange, page common.Page) (*sql.Rows, error) {
 	srcSchema := conv.SrcSchema[table.Name]
-	srcCols := srcSchema.ColNames
+	// Only read columns that pass conv's column filters.
+	srcCols := conv.IncludedColumns(table.Name, srcSchema.ColNames)
 	if len(srcCols) == 0 {
 		conv.Unexpected(fmt.Sprintf("Couldn't get source columns for table %s ", table.Name))
 		return nil, nil
@@ -53,8 +54,14 @@ func (isi InfoSchemaImpl) GetRowsFromTable(conv *internal.Conv, db *sql.DB, tabl
 	// Ideally we would pass schema/name as a query parameter,
 	// but MySQL doesn't support this. So we quote it instead.
 	colNameList := buildColNameList(srcSchema, srcCols)
-	q := fmt.Sprintf("SELECT %s FROM `%s`.`%s`;", colNameList, table.Schema, table.Name)
-	rows, err := db.Query(q)
+	// We read the given page of key range kr of the table. When the table
+	// is read in pages, or data migration progress is tracked, we read rows
+	// in primary key order (skipping those already read or migrated).
+	quote := func(s string) string { return "`" + s + "`" }
+	param := func(int) string { return "?" }
+	clauses, args := common.ReadClauses(conv, table.Name, kr, page, srcSchema, srcCols, quote, param)
+	query := fmt.Sprintf("SELECT %s FROM `%s`.`%s`%s;", colNameList, table.Schema, table.Name, clauses)
+	rows, err := q.Query(query, args...)
 	return rows, err
 }
 
@@ -80,6 +87,8 @@ func buildColNameList(srcSchema schema.Table, srcColName []string) string {
 
 func (isi InfoSchemaImpl) ProcessDataRows(conv *internal.Conv, srcTable string, srcCols []string, srcSchema schema.Table, spTable string, spCols []string, spSchema ddl.CreateTable, rows *sql.Rows) {
 	v, scanArgs := buildVals(len(srcCols))
+	// Keys are recorded for paginated reads and data migration progress.
+	keys := common.KeyIndexes(srcSchema, srcCols)
 	for rows.Next() {
 		// get RawBytes from data.
 		err := rows.Scan(scanArgs...)
@@ -90,6 +99,13 @@ func (isi InfoSchemaImpl) ProcessDataRows(conv *internal.Conv, srcTable string,
 			continue
 		}
 		values := valsToStrings(v)
+		if keys != nil {
+			var key []string
+			for _, i := range keys {
+				key = append(key, values[i])
+			}
+			conv.SetRowKey(key)
+		}
 		ProcessDataRow(conv, srcTable, srcCols, srcSchema, spTable, spCols, spSchema, values)
 	}
 }
@@ -113,6 +129,96 @@ func (isi InfoSchemaImpl) GetRowCount(db *sql.DB, table common.SchemaAndName) (i
 	return 0, nil //Check if 0 is ok to return
 }
 
+// GetKeyStats returns the minimum and maximum values of integer column
+// col of table, along with the estimated number of rows in table. col
+// must be the leading primary key column, so that MySQL reads its minimum
+// and maximum from the index, and the number of rows comes from the
+// catalog statistics: table isn't scanned.
+func (isi InfoSchemaImpl) GetKeyStats(db *sql.DB, table common.SchemaAndName, col string) (int64, int64, int64, error) {
+	// See GetRowCount for why we quote names instead of passing them as
+	// query parameters.
+	q := fmt.Sprintf("SELECT MIN(`%s`), MAX(`%s`) FROM `%s`.`%s`;", col, col, table.Schema, table.Name)
+	var min, max, count sql.NullInt64
+	if err := db.QueryRow(q).Scan(&min, &max); err != nil {
+		return 0, 0, 0, err
+	}
+	q = "SELECT table_rows FROM information_schema.tables WHERE table_schema = ? AND table_name = ?;"
+	if err := db.QueryRow(q, table.Schema, table.Name).Scan(&count); err != nil {
+		return 0, 0, 0, err
+	}
+	return min.Int64, max.Int64, count.Int64, nil
+}
+
+// StartSnapshot opens n connections to db that read the same consistent
+// snapshot of the database. MySQL can't share a snapshot between
+// connections, so we hold a global read lock while the connections start
+// their transactions (which requires the RELOAD privilege). If we can't
+// take the lock, we return a single connection. The position of the
+// snapshot is the binary log position when the snapshot is started (empty
+// if binary logging is disabled).
+func (isi InfoSchemaImpl) StartSnapshot(db *sql.DB, n int) ([]*common.SnapshotConn, internal.SourcePosition, error) {
+	// Connection lock takes the global read lock, and doesn't read the
+	// snapshot itself.
+	lock, err := common.OpenSnapshotConn(db)
+	if err != nil {
+		return nil, internal.SourcePosition{}, err
+	}
+	defer lock.Close()
+	if n > 1 {
+		if err := lock.Exec("FLUSH TABLES WITH READ LOCK"); err != nil {
+			internal.VerbosePrintf("Can't lock tables to share a snapshot between readers (%s): using a single reader\n", err)
+			n = 1
+		} else {
+			defer lock.Exec("UNLOCK TABLES")
+		}
+	}
+	// Without the lock, the binary log position is read before the
+	// snapshot is started, so it may precede the snapshot.
+	pos, err := binlogPosition(lock)
+	if err != nil {
+		internal.VerbosePrintf("Can't get binary log position: %s\n", err)
+	}
+	var conns []*common.SnapshotConn
+	for i := 0; i < n; i++ {
+		c, err := common.OpenSnapshotConn(db, "SET TRANSACTION ISOLATION LEVEL REPEATABLE READ", "START TRANSACTION WITH CONSISTENT SNAPSHOT, READ ONLY")
+		if err != nil {
+			common.CloseSnapshot(conns)
+			return nil, internal.SourcePosition{}, err
+		}
+		conns = append(conns, c)
+	}
+	return conns, pos, nil
+}
+
+// binlogPosition returns the current binary log position of the database
+// of connection c.
+func binlogPosition(c *common.SnapshotConn) (internal.SourcePosition, error) {
+	rows, err := c.Query("SHOW MASTER STATUS")
+	if err != nil {
+		return internal.SourcePosition{}, err
+	}
+	defer rows.Close()
+	// The number of columns returned depends on the MySQL version: the
+	// first two are the binary log file and position.
+	cols, err := rows.Columns()
+	if err != nil {
+		return internal.SourcePosition{}, err
+	}
+	var pos internal.SourcePosition
+	if !rows.Next() || len(cols) < 2 {
+		return pos, fmt.Errorf("binary logging is disabled")
+	}
+	vals := make([]interface{}, len(cols))
+	vals[0], vals[1] = &pos.BinlogFile, &pos.BinlogPos
+	for i := 2; i < len(vals); i++ {
+		vals[i] = new(sql.RawBytes)
+	}
+	if err := rows.Scan(vals...); err != nil {
+		return internal.SourcePosition{}, err
+	}
+	return pos, rows.Err()
+}
+
 // getTables return list of tables in the selected database.
 // Note that sql.DB already effectively has the dbName
 // embedded within it (dbName is part of the DSN passed to sql.Open),
@@ -314,7 +420,9 @@ func (isi InfoSchemaImpl) GetIndexes(conv *internal.Conv, db *sql.DB, table comm
 func toType(dataType string, columnType string, charLen sql.NullInt64, numericPrecision, numericScale sql.NullInt64) schema.Type {
 	switch {
 	case dataType == "set":
-		return schema.Type{Name: dataType, ArrayBounds: []int64{-1}}
+		return schema.Type{Name: dataType, ArrayBounds: []int64{-1}, Values: enumValues(columnType)}
+	case dataType == "enum":
+		return schema.Type{Name: dataType, Values: enumValues(columnType)}
 	case charLen.Valid:
 		return schema.Type{Name: dataType, Mods: []int64{charLen.Int64}}
 	case dataType == "decimal" && numericPrecision.Valid && numericScale.Valid && numericScale.Int64 != 0:
@@ -322,8 +430,39 @@ func toType(dataType string, columnType string, charLen sql.NullInt64, numericPr
 	case dataType == "decimal" && numericPrecision.Valid:
 		return schema.Type{Name: dataType, Mods: []int64{numericPrecision.Int64}}
 	default:
-		return schema.Type{Name: dataType}
+		return schema.Type{Name: dataType, Unsigned: strings.Contains(columnType, "unsigned")}
+	}
+}
+
+// enumValues returns the values of an ENUM or SET column type e.g.
+// "enum('a','b')". Quotes in values are doubled.
+func enumValues(columnType string) []string {
+	i, j := strings.Index(columnType, "("), strings.LastIndex(columnType, ")")
+	if i < 0 || j < i {
+		return nil
+	}
+	var l []string
+	s := columnType[i+1 : j]
+	for len(s) > 0 && s[0] == '\'' {
+		var v strings.Builder
+		k := 1
+		for ; k < len(s); k++ {
+			if s[k] == '\'' {
+				if k+1 < len(s) && s[k+1] == '\'' {
+					k++
+				} else {
+					break
+				}
+			}
+			v.WriteByte(s[k])
+		}
+		l = append(l, v.String())
+		if k < len(s) {
+			k++ // Skip the closing quote.
+		}
+		s = strings.TrimPrefix(s[k:], ",")
 	}
+	return l
 }
 
 // buildVals constructs []sql.RawBytes value containers to scan row
@@ -17,6 +17,8 @@ package mysql
 import (
 	"database/sql"
 	"database/sql/driver"
+	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
@@ -81,7 +83,7 @@ func TestProcessInfoSchemaMYSQL(t *testing.T) {
 			rows: [][]driver.Value{
 				{"productid", "text", "text", "NO", nil, nil, nil, nil, nil},
 				{"userid", "text", "text", "NO", nil, nil, nil, nil, nil},
-				{"quantity", "bigint", "bigint", "YES", nil, nil, 64, 0, nil}},
+				{"quantity", "bigint", "bigint unsigned", "YES", nil, nil, 64, 0, nil}},
 		}, {
 			query: "SELECT (.+) FROM INFORMATION_SCHEMA.TABLE_CONSTRAINTS (.+)",
 			args:  []driver.Value{"test", "cart"},
@@ -268,6 +270,8 @@ func TestProcessInfoSchemaMYSQL(t *testing.T) {
 	}
 	assert.Equal(t, expectedSchema, stripSchemaComments(conv.SpSchema))
 	assert.Equal(t, len(conv.Issues["cart"]), 0)
+	assert.True(t, conv.SrcSchema["cart"].ColDefs["quantity"].Type.Unsigned)
+	assert.False(t, conv.SrcSchema["cart"].ColDefs["productid"].Type.Unsigned)
 	expectedIssues := map[string][]internal.SchemaIssue{
 		"bs": []internal.SchemaIssue{internal.DefaultValue},
 		"f4": []internal.SchemaIssue{internal.Widened},
@@ -280,6 +284,62 @@ func TestProcessInfoSchemaMYSQL(t *testing.T) {
 	assert.Equal(t, int64(0), conv.Unexpecteds())
 }
 
+func TestProcessInfoSchema_DenseKeys(t *testing.T) {
+	ms := []mockSpec{
+		{
+			query: "SELECT (.+) FROM information_schema.tables where table_type = 'BASE TABLE'  and (.+)",
+			args:  []driver.Value{"test"},
+			cols:  []string{"table_name"},
+			rows:  [][]driver.Value{{"orders"}},
+		}, {
+			query: "SELECT (.+) FROM information_schema.COLUMNS (.+)",
+			args:  []driver.Value{"test", "orders"},
+			cols:  []string{"column_name", "data_type", "column_type", "is_nullable", "column_default", "character_maximum_length", "numeric_precision", "numeric_scale", "extra"},
+			rows: [][]driver.Value{
+				{"id", "bigint", "bigint", "NO", nil, nil, 64, 0, nil},
+				{"item", "text", "text", "YES", nil, nil, nil, nil, nil}},
+		}, {
+			query: "SELECT (.+) FROM INFORMATION_SCHEMA.TABLE_CONSTRAINTS (.+)",
+			args:  []driver.Value{"test", "orders"},
+			cols:  []string{"column_name", "constraint_type"},
+			rows:  [][]driver.Value{{"id", "PRIMARY KEY"}},
+		}, {
+			query: "SELECT (.+) FROM INFORMATION_SCHEMA.TABLE_CONSTRAINTS (.+)",
+			args:  []driver.Value{"test", "orders"},
+			cols:  []string{"REFERENCED_TABLE_NAME", "COLUMN_NAME", "REFERENCED_COLUMN_NAME", "CONSTRAINT_NAME"},
+		}, {
+			query: "SELECT (.+) FROM INFORMATION_SCHEMA.STATISTICS (.+)",
+			args:  []driver.Value{"test", "orders"},
+			cols:  []string{"INDEX_NAME", "COLUMN_NAME", "SEQ_IN_INDEX", "COLLATION", "NON_UNIQUE"},
+		}, {
+			// The key's range is read from its index, and the number of
+			// rows is estimated from the catalog, rather than scanning
+			// the table.
+			query: "SELECT MIN\\(`id`\\), MAX\\(`id`\\) FROM `test`.`orders`",
+			cols:  []string{"min", "max"},
+			rows:  [][]driver.Value{{1, 5000}},
+		}, {
+			query: "SELECT table_rows FROM information_schema.tables (.+)",
+			args:  []driver.Value{"test", "orders"},
+			cols:  []string{"table_rows"},
+			rows:  [][]driver.Value{{4000}},
+		},
+	}
+	// Key values are only checked if a hotspot remedy is chosen.
+	conv := internal.MakeConv()
+	err := common.ProcessInfoSchema(conv, mkMockDB(t, ms), InfoSchemaImpl{"test"})
+	assert.Nil(t, err)
+	assert.Empty(t, conv.Issues["orders"]["id"])
+
+	conv = internal.MakeConv()
+	conv.HotspotRemedy = internal.HotspotRemedyShard
+	err = common.ProcessInfoSchema(conv, mkMockDB(t, ms), InfoSchemaImpl{"test"})
+	assert.Nil(t, err)
+	assert.Equal(t, []internal.SchemaIssue{internal.SequentialKey, internal.HotspotRemedied}, conv.Issues["orders"]["id"])
+	assert.Equal(t, []ddl.IndexKey{{Col: "shard_id"}, {Col: "id"}}, conv.SpSchema["orders"].Pks)
+	assert.Equal(t, []string{"shard_id", "id", "item"}, conv.SpSchema["orders"].ColNames)
+}
+
 func TestProcessSQLData(t *testing.T) {
 	ms := []mockSpec{
 		{
@@ -321,7 +381,7 @@ func TestProcessSQLData(t *testing.T) {
 		func(table string, cols []string, vals []interface{}) {
 			rows = append(rows, spannerData{table: table, cols: cols, vals: vals})
 		})
-	common.ProcessSQLData(conv, db, InfoSchemaImpl{"test"})
+	common.ProcessSQLData(conv, db, InfoSchemaImpl{"test"}, common.ReadOptions{})
 	assert.Equal(t,
 		[]spannerData{
 			spannerData{table: "te_st", cols: []string{"a_a", "Ab", "Ac_"}, vals: []interface{}{float64(42.3), int64(3), "cat"}},
@@ -333,6 +393,283 @@ func TestProcessSQLData(t *testing.T) {
 	assert.Equal(t, int64(1), conv.Unexpecteds()) // Bad row generates an entry in unexpected.
 }
 
+func TestProcessSQLData_Resume(t *testing.T) {
+	ms := []mockSpec{
+		{
+			query: "SELECT table_name FROM information_schema.tables where table_type = 'BASE TABLE' and (.+)",
+			args:  []driver.Value{"test"},
+			cols:  []string{"table_name"},
+			rows:  [][]driver.Value{{"done"}, {"t"}},
+		}, {
+			// Table "done" is skipped, and "t" is read after the last key done.
+			query: "SELECT `id`,`name` FROM `test`.`t` WHERE \\(\\(`id` > \\?\\)\\) ORDER BY `id`",
+			args:  []driver.Value{"2"},
+			cols:  []string{"id", "name"},
+			rows: [][]driver.Value{
+				{3, "cat"},
+				{4, "dog"}},
+		},
+	}
+	db := mkMockDB(t, ms)
+	conv := buildConv(
+		ddl.CreateTable{
+			Name:     "t",
+			ColNames: []string{"id", "name"},
+			ColDefs: map[string]ddl.ColumnDef{
+				"id":   ddl.ColumnDef{Name: "id", T: ddl.Type{Name: ddl.Int64}},
+				"name": ddl.ColumnDef{Name: "name", T: ddl.Type{Name: ddl.String, Len: ddl.MaxLength}},
+			},
+			Pks: []ddl.IndexKey{{Col: "id"}}},
+		schema.Table{
+			Name:     "t",
+			ColNames: []string{"id", "name"},
+			ColDefs: map[string]schema.Column{
+				"id":   schema.Column{Name: "id", Type: schema.Type{Name: "int"}},
+				"name": schema.Column{Name: "name", Type: schema.Type{Name: "text"}},
+			},
+			PrimaryKeys: []schema.Key{{Column: "id"}}})
+	cp := internal.NewCheckpoint("")
+	cp.TableRead("done", "")
+	cp.Add(internal.RowPosition{Table: "t", Key: []string{"2"}})()
+	conv.SetCheckpoint(cp)
+
+	conv.SetDataMode()
+	var rows []spannerData
+	conv.SetDataSink(
+		func(table string, cols []string, vals []interface{}) {
+			t.Errorf("Rows must be acknowledged when progress is tracked")
+		})
+	conv.SetAckDataSink(
+		func(table string, cols []string, vals []interface{}, ack func()) {
+			rows = append(rows, spannerData{table: table, cols: cols, vals: vals})
+			ack()
+		})
+	common.ProcessSQLData(conv, db, InfoSchemaImpl{"test"}, common.ReadOptions{})
+	assert.Equal(t,
+		[]spannerData{
+			spannerData{table: "t", cols: []string{"id", "name"}, vals: []interface{}{int64(3), "cat"}},
+			spannerData{table: "t", cols: []string{"id", "name"}, vals: []interface{}{int64(4), "dog"}},
+		},
+		rows)
+	assert.Equal(t, []string{"4"}, cp.LastKey("t", ""))
+	assert.True(t, cp.TableComplete("t", ""))
+	assert.Equal(t, int64(0), conv.Unexpecteds())
+}
+
+func TestProcessSQLData_Parallel(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+	// Tables (and key ranges) are read concurrently, so queries can be
+	// issued in any order.
+	mock.MatchExpectationsInOrder(false)
+	mock.ExpectQuery("SELECT table_name FROM information_schema.tables where table_type = 'BASE TABLE' and (.+)").
+		WithArgs("test").WillReturnRows(sqlmock.NewRows([]string{"table_name"}).AddRow("t").AddRow("u"))
+	// Table "t" is big, so it is split into a key range per reader.
+	mock.ExpectQuery("SELECT MIN\\(`id`\\), MAX\\(`id`\\) FROM `test`.`t`").
+		WillReturnRows(sqlmock.NewRows([]string{"min", "max"}).AddRow(1, 4))
+	mock.ExpectQuery("SELECT table_rows FROM information_schema.tables (.+)").
+		WithArgs("test", "t").WillReturnRows(sqlmock.NewRows([]string{"table_rows"}).AddRow(4))
+	mock.ExpectQuery("SELECT `id`,`name` FROM `test`.`t` WHERE `id` < \\? ORDER BY `id`").
+		WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "ant").AddRow(2, "bat"))
+	mock.ExpectQuery("SELECT `id`,`name` FROM `test`.`t` WHERE `id` >= \\? ORDER BY `id`").
+		WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(3, "cat").AddRow(4, "dog"))
+	mock.ExpectQuery("SELECT `id`,`name` FROM `test`.`u` ORDER BY `id`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "eel").AddRow("x", "fox"))
+
+	conv := internal.MakeConv()
+	for _, name := range []string{"t", "u"} {
+		c := buildConv(
+			ddl.CreateTable{
+				Name:     name,
+				ColNames: []string{"id", "name"},
+				ColDefs: map[string]ddl.ColumnDef{
+					"id":   ddl.ColumnDef{Name: "id", T: ddl.Type{Name: ddl.Int64}, NotNull: true},
+					"name": ddl.ColumnDef{Name: "name", T: ddl.Type{Name: ddl.String, Len: ddl.MaxLength}, NotNull: true},
+				},
+				Pks: []ddl.IndexKey{{Col: "id"}}},
+			schema.Table{
+				Name:     name,
+				ColNames: []string{"id", "name"},
+				ColDefs: map[string]schema.Column{
+					"id":   schema.Column{Name: "id", Type: schema.Type{Name: "int"}},
+					"name": schema.Column{Name: "name", Type: schema.Type{Name: "text"}},
+				},
+				PrimaryKeys: []schema.Key{{Column: "id"}}})
+		conv.SpSchema[name], conv.SrcSchema[name] = c.SpSchema[name], c.SrcSchema[name]
+		conv.ToSpanner[name], conv.ToSource[name] = c.ToSpanner[name], c.ToSource[name]
+	}
+	conv.Stats.Rows["t"] = 10 * 1000 * 1000
+	conv.Stats.Rows["u"] = 2
+	cp := internal.NewCheckpoint("")
+	conv.SetCheckpoint(cp)
+
+	conv.SetDataMode()
+	var lock sync.Mutex
+	var rows []spannerData
+	conv.SetDataSink(func(table string, cols []string, vals []interface{}) {})
+	conv.SetAckDataSink(
+		func(table string, cols []string, vals []interface{}, ack func()) {
+			lock.Lock()
+			rows = append(rows, spannerData{table: table, cols: cols, vals: vals})
+			lock.Unlock()
+			ack()
+		})
+	common.ProcessSQLData(conv, db, InfoSchemaImpl{"test"}, common.ReadOptions{Readers: 2})
+	assert.Nil(t, mock.ExpectationsWereMet())
+	assert.ElementsMatch(t,
+		[]spannerData{
+			spannerData{table: "t", cols: []string{"id", "name"}, vals: []interface{}{int64(1), "ant"}},
+			spannerData{table: "t", cols: []string{"id", "name"}, vals: []interface{}{int64(2), "bat"}},
+			spannerData{table: "t", cols: []string{"id", "name"}, vals: []interface{}{int64(3), "cat"}},
+			spannerData{table: "t", cols: []string{"id", "name"}, vals: []interface{}{int64(4), "dog"}},
+			spannerData{table: "u", cols: []string{"id", "name"}, vals: []interface{}{int64(1), "eel"}},
+		},
+		rows)
+	// Stats of the readers are merged back into conv.
+	assert.Equal(t, int64(4), conv.Stats.GoodRows["t"])
+	assert.Equal(t, int64(1), conv.Stats.GoodRows["u"])
+	assert.Equal(t, int64(1), conv.Stats.BadRows["u"])
+	assert.Equal(t, 1, len(conv.SampleBadRows(10)))
+	assert.Equal(t, []string{"3:", ":3"}, cp.Ranges("t"))
+	assert.Equal(t, []string{"4"}, cp.LastKey("t", "3:"))
+	assert.True(t, cp.TableComplete("t", ""))
+	assert.True(t, cp.TableComplete("u", ""))
+}
+
+func TestProcessSQLData_Pages(t *testing.T) {
+	ms := []mockSpec{
+		{
+			query: "SELECT table_name FROM information_schema.tables where table_type = 'BASE TABLE' and (.+)",
+			args:  []driver.Value{"test"},
+			cols:  []string{"table_name"},
+			rows:  [][]driver.Value{{"t"}},
+		}, {
+			query: "SELECT `a`,`b` FROM `test`.`t` ORDER BY `a`, `b` LIMIT 2",
+			cols:  []string{"a", "b"},
+			rows:  [][]driver.Value{{1, "x"}, {1, "y"}},
+		}, {
+			// Each page starts after the last row of the previous page.
+			query: "SELECT `a`,`b` FROM `test`.`t` WHERE \\(\\(`a` > \\?\\) OR \\(`a` = \\? AND `b` > \\?\\)\\) ORDER BY `a`, `b` LIMIT 2",
+			args:  []driver.Value{"1", "1", "y"},
+			cols:  []string{"a", "b"},
+			rows:  [][]driver.Value{{2, "x"}},
+		}, {
+			query: "SELECT `a`,`b` FROM `test`.`t` WHERE (.+) ORDER BY `a`, `b` LIMIT 2",
+			args:  []driver.Value{"2", "2", "x"},
+			cols:  []string{"a", "b"},
+		},
+	}
+	db := mkMockDB(t, ms)
+	conv := buildConv(
+		ddl.CreateTable{
+			Name:     "t",
+			ColNames: []string{"a", "b"},
+			ColDefs: map[string]ddl.ColumnDef{
+				"a": ddl.ColumnDef{Name: "a", T: ddl.Type{Name: ddl.Int64}},
+				"b": ddl.ColumnDef{Name: "b", T: ddl.Type{Name: ddl.String, Len: ddl.MaxLength}},
+			},
+			Pks: []ddl.IndexKey{{Col: "a"}, {Col: "b"}}},
+		schema.Table{
+			Name:     "t",
+			ColNames: []string{"a", "b"},
+			ColDefs: map[string]schema.Column{
+				"a": schema.Column{Name: "a", Type: schema.Type{Name: "int"}},
+				"b": schema.Column{Name: "b", Type: schema.Type{Name: "varchar"}},
+			},
+			PrimaryKeys: []schema.Key{{Column: "a"}, {Column: "b"}}})
+	conv.SetDataMode()
+	var rows []spannerData
+	conv.SetDataSink(
+		func(table string, cols []string, vals []interface{}) {
+			rows = append(rows, spannerData{table: table, cols: cols, vals: vals})
+		})
+	common.ProcessSQLData(conv, db, InfoSchemaImpl{"test"}, common.ReadOptions{PageSize: 2})
+	assert.Equal(t,
+		[]spannerData{
+			spannerData{table: "t", cols: []string{"a", "b"}, vals: []interface{}{int64(1), "x"}},
+			spannerData{table: "t", cols: []string{"a", "b"}, vals: []interface{}{int64(1), "y"}},
+			spannerData{table: "t", cols: []string{"a", "b"}, vals: []interface{}{int64(2), "x"}},
+		},
+		rows)
+	assert.Equal(t, int64(0), conv.Unexpecteds())
+}
+
+func TestStartSnapshot(t *testing.T) {
+	status := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"File", "Position", "Binlog_Do_DB", "Binlog_Ignore_DB", "Executed_Gtid_Set"}).
+			AddRow("mysql-bin.000003", 154, "", "", "")
+	}
+	// Connections start their transactions while tables are locked, so
+	// they all read the same snapshot.
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+	mock.ExpectExec("FLUSH TABLES WITH READ LOCK").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SHOW MASTER STATUS").WillReturnRows(status())
+	for i := 0; i < 2; i++ {
+		mock.ExpectExec("SET TRANSACTION ISOLATION LEVEL REPEATABLE READ").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("START TRANSACTION WITH CONSISTENT SNAPSHOT, READ ONLY").WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+	mock.ExpectExec("UNLOCK TABLES").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("COMMIT").WillReturnResult(sqlmock.NewResult(0, 0))
+	conns, pos, err := InfoSchemaImpl{"test"}.StartSnapshot(db, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(conns))
+	assert.Equal(t, internal.SourcePosition{BinlogFile: "mysql-bin.000003", BinlogPos: 154}, pos)
+	assert.Nil(t, mock.ExpectationsWereMet())
+
+	// Without the privilege to lock tables, a single connection is used.
+	db, mock, err = sqlmock.New()
+	assert.Nil(t, err)
+	mock.ExpectExec("FLUSH TABLES WITH READ LOCK").WillReturnError(fmt.Errorf("access denied"))
+	mock.ExpectQuery("SHOW MASTER STATUS").WillReturnRows(status())
+	mock.ExpectExec("SET TRANSACTION ISOLATION LEVEL REPEATABLE READ").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("START TRANSACTION WITH CONSISTENT SNAPSHOT, READ ONLY").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("COMMIT").WillReturnResult(sqlmock.NewResult(0, 0))
+	conns, pos, err = InfoSchemaImpl{"test"}.StartSnapshot(db, 4)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(conns))
+	assert.Equal(t, "mysql-bin.000003", pos.BinlogFile)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestProcessSQLData_Snapshot(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+	mock.ExpectQuery("SELECT table_name FROM information_schema.tables where table_type = 'BASE TABLE' and (.+)").
+		WithArgs("test").WillReturnRows(sqlmock.NewRows([]string{"table_name"}).AddRow("t"))
+	mock.ExpectQuery("SHOW MASTER STATUS").
+		WillReturnRows(sqlmock.NewRows([]string{"File", "Position"}).AddRow("mysql-bin.000001", 42))
+	mock.ExpectExec("SET TRANSACTION ISOLATION LEVEL REPEATABLE READ").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("START TRANSACTION WITH CONSISTENT SNAPSHOT, READ ONLY").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("COMMIT").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT `id` FROM `test`.`t`").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	// The snapshot transaction ends once all data is read.
+	mock.ExpectExec("COMMIT").WillReturnResult(sqlmock.NewResult(0, 0))
+	conv := buildConv(
+		ddl.CreateTable{
+			Name:     "t",
+			ColNames: []string{"id"},
+			ColDefs:  map[string]ddl.ColumnDef{"id": ddl.ColumnDef{Name: "id", T: ddl.Type{Name: ddl.Int64}}},
+			Pks:      []ddl.IndexKey{{Col: "id"}}},
+		schema.Table{
+			Name:        "t",
+			ColNames:    []string{"id"},
+			ColDefs:     map[string]schema.Column{"id": schema.Column{Name: "id", Type: schema.Type{Name: "int"}}},
+			PrimaryKeys: []schema.Key{{Column: "id"}}})
+	cp := internal.NewCheckpoint("")
+	conv.SetCheckpoint(cp)
+	conv.SetDataMode()
+	conv.SetDataSink(func(table string, cols []string, vals []interface{}) {})
+	conv.SetAckDataSink(func(table string, cols []string, vals []interface{}, ack func()) { ack() })
+	assert.Nil(t, common.ProcessSQLData(conv, db, InfoSchemaImpl{"test"}, common.ReadOptions{Snapshot: true}))
+	assert.Nil(t, mock.ExpectationsWereMet())
+	assert.Equal(t, int64(2), conv.Stats.GoodRows["t"])
+	pos := internal.SourcePosition{BinlogFile: "mysql-bin.000001", BinlogPos: 42}
+	assert.Equal(t, &pos, conv.Snapshot())
+	assert.Equal(t, &pos, cp.Snapshot)
+}
+
 func TestProcessSQLData_MultiCol(t *testing.T) {
 	// Tests multi-column behavior of ProcessSQLData (including
 	// handling of null columns and synthetic keys). Also tests
@@ -411,7 +748,7 @@ func TestProcessSQLData_MultiCol(t *testing.T) {
 		func(table string, cols []string, vals []interface{}) {
 			rows = append(rows, spannerData{table: table, cols: cols, vals: vals})
 		})
-	common.ProcessSQLData(conv, db, InfoSchemaImpl{"test"})
+	common.ProcessSQLData(conv, db, InfoSchemaImpl{"test"}, common.ReadOptions{})
 	assert.Equal(t, []spannerData{
 		{table: "test", cols: []string{"a", "b", "synth_id"}, vals: []interface{}{"cat", float64(42.3), int64(0)}},
 		{table: "test", cols: []string{"a", "c", "synth_id"}, vals: []interface{}{"dog", int64(22), int64(-9223372036854775808)}}},
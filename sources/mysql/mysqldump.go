@@ -26,6 +26,8 @@ import (
 	"github.com/cloudspannerecosystem/harbourbridge/sources/common"
 	"github.com/pingcap/parser"
 	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/model"
+	pmysql "github.com/pingcap/parser/mysql"
 	"github.com/pingcap/parser/opcode"
 	"github.com/pingcap/tidb/types"
 	driver "github.com/pingcap/tidb/types/parser_driver"
@@ -64,6 +66,7 @@ func (ddi DbDumpImpl) ProcessDump(conv *internal.Conv, r *internal.Reader) error
 // In data mode, ProcessMySQLDump uses this schema to convert MySQL data
 // and writes it to Spanner, using the data sink specified in conv.
 func processMySQLDump(conv *internal.Conv, r *internal.Reader) error {
+	db := "" // Current database, as set by USE statements.
 	for {
 		startLine := r.LineNumber
 		startOffset := r.Offset
@@ -71,7 +74,23 @@ func processMySQLDump(conv *internal.Conv, r *internal.Reader) error {
 		if err != nil {
 			return err
 		}
+		// Rows are identified by the offset of the statements they
+		// come from, so that data conversion can be resumed.
+		conv.SetDumpOffset(int64(startOffset))
 		for _, stmt := range stmts {
+			if use, ok := stmt.(*ast.UseStmt); ok {
+				db = use.DBName
+				if conv.DefaultSchema == "" && !systemDatabases[db] {
+					conv.DefaultSchema = db
+				}
+			}
+			if systemDatabases[db] {
+				conv.SkipStatement(NodeType(stmt))
+				continue
+			}
+			if db != "" {
+				stmt.Accept(&tableQualifier{db: db, defaultDb: conv.DefaultSchema})
+			}
 			isInsert := processStatement(conv, stmt)
 			internal.VerbosePrintf("Parsed SQL command at line=%d/fpos=%d: %d stmts (%d lines, %d bytes) Insert Statement=%v\n", startLine, startOffset, 1, r.LineNumber-startLine, len(b), isInsert)
 		}
@@ -82,6 +101,56 @@ func processMySQLDump(conv *internal.Conv, r *internal.Reader) error {
 	return nil
 }
 
+// systemDatabases are MySQL's internal databases, which are included in
+// dumps created with mysqldump --all-databases. We skip their tables.
+var systemDatabases = map[string]bool{"mysql": true, "information_schema": true, "performance_schema": true, "sys": true}
+
+// tableQualifier qualifies table names in a statement with the database
+// they belong to, so that tables with the same name in different databases
+// (from dumps created with mysqldump --databases or --all-databases) have
+// different names. Unqualified names belong to the current database db.
+// Tables in the default database (the first database of the dump) are left
+// unqualified, so that single database dumps have the same table names
+// whether or not they use USE statements.
+type tableQualifier struct {
+	db        string
+	defaultDb string
+}
+
+func (tq *tableQualifier) Enter(n ast.Node) (ast.Node, bool) {
+	switch x := n.(type) {
+	case *ast.InsertStmt:
+		// Don't visit the (potentially many) inserted values.
+		if x.Table != nil {
+			x.Table.Accept(tq)
+		}
+		return n, true
+	case *ast.TableName:
+		tq.qualify(x)
+	case *ast.ColumnOption:
+		// Column options don't visit the table of REFERENCES clauses.
+		if x.Refer != nil && x.Refer.Table != nil {
+			tq.qualify(x.Refer.Table)
+		}
+	}
+	return n, false
+}
+
+func (tq *tableQualifier) Leave(n ast.Node) (ast.Node, bool) {
+	return n, true
+}
+
+func (tq *tableQualifier) qualify(t *ast.TableName) {
+	db := t.Schema.O
+	if db == "" {
+		db = tq.db
+	}
+	if db == tq.defaultDb {
+		db = ""
+	}
+	t.Schema = model.NewCIStr(db)
+}
+
 // readAndParseChunk parses a chunk of mysqldump data, returning the bytes read,
 // the parsed AST (nil if nothing read), error and whether we've hit end-of-file.
 // In effect, we proceed through the file, statement by statement. Many
@@ -133,6 +202,10 @@ func readAndParseChunk(conv *internal.Conv, r *internal.Reader) ([]byte, []ast.S
 // statements, updating Conv with new schema information, and returning
 // true if INSERT statement is encountered.
 func processStatement(conv *internal.Conv, stmt ast.StmtNode) bool {
+	if excludedTable(conv, stmt) {
+		conv.SkipStatement(NodeType(stmt))
+		return false
+	}
 	switch s := stmt.(type) {
 	case *ast.CreateTableStmt:
 		if conv.SchemaMode() {
@@ -159,6 +232,31 @@ func processStatement(conv *internal.Conv, stmt ast.StmtNode) bool {
 	return false
 }
 
+// excludedTable returns true if stmt operates on a table that is excluded
+// by conv's table filters.
+func excludedTable(conv *internal.Conv, stmt ast.StmtNode) bool {
+	var table *ast.TableName
+	switch s := stmt.(type) {
+	case *ast.CreateTableStmt:
+		table = s.Table
+	case *ast.AlterTableStmt:
+		table = s.Table
+	case *ast.CreateIndexStmt:
+		table = s.Table
+	case *ast.InsertStmt:
+		if s.Table == nil {
+			return false
+		}
+		name, err := getTableNameInsert(s.Table)
+		return err == nil && !conv.IncludeTable(name)
+	}
+	if table == nil {
+		return false
+	}
+	name, err := getTableName(table)
+	return err == nil && !conv.IncludeTable(name)
+}
+
 func processCreateIndex(conv *internal.Conv, stmt *ast.CreateIndexStmt) {
 	if stmt.Table == nil {
 		logStmtError(conv, stmt, fmt.Errorf("cannot process index statement with nil table."))
@@ -427,7 +525,11 @@ func processColumn(conv *internal.Conv, tableName string, col *ast.ColumnDef) (s
 	ty := schema.Type{
 		Name:        tid,
 		Mods:        mods,
-		ArrayBounds: getArrayBounds(col.Tp.String(), col.Tp.Elems)}
+		ArrayBounds: getArrayBounds(col.Tp.String(), col.Tp.Elems),
+		Unsigned:    pmysql.HasUnsignedFlag(col.Tp.Flag)}
+	if tid == "enum" || tid == "set" {
+		ty.Values = col.Tp.Elems
+	}
 	column := schema.Column{Name: name, Type: ty}
 	return name, column, updateColsByOption(conv, tableName, col, &column), nil
 }
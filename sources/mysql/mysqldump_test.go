@@ -850,6 +850,30 @@ func TestProcessMySQLDump_Rows(t *testing.T) {
 	assert.Equal(t, int64(1), conv.Rows())
 }
 
+func TestProcessMySQLDump_Resume(t *testing.T) {
+	conv, _ := runProcessMySQLDump("CREATE TABLE t (id bigint NOT NULL, PRIMARY KEY (id));\n")
+	// A data-only dump, whose first statement is an INSERT.
+	data := "INSERT INTO t (id) VALUES (1),(2);\n" +
+		"INSERT INTO t (id) VALUES (3);\n"
+	cp := internal.NewCheckpoint("")
+	conv.SetCheckpoint(cp)
+	var ids []interface{}
+	conv.SetAckDataSink(func(table string, cols []string, vals []interface{}, ack func()) {
+		ids = append(ids, vals[0])
+		// Only the first row is written before the migration stops.
+		if vals[0] == int64(1) {
+			ack()
+		}
+	})
+	common.ProcessDbDump(conv, internal.NewReader(bufio.NewReader(strings.NewReader(data)), nil), DbDumpImpl{})
+	assert.Equal(t, []interface{}{int64(1), int64(2), int64(3)}, ids)
+
+	// Resuming skips the row that was written.
+	ids = nil
+	common.ProcessDbDump(conv, internal.NewReader(bufio.NewReader(strings.NewReader(data)), nil), DbDumpImpl{})
+	assert.Equal(t, []interface{}{int64(2), int64(3)}, ids)
+}
+
 func TestProcessMySQLDump_BadRows(t *testing.T) {
 	conv, _ := runProcessMySQLDump("CREATE TABLE cart (a text, n bigint);\n" +
 		"INSERT INTO cart (a, n) VALUES ('a42', 'not_a_number');")
@@ -910,8 +934,112 @@ func TestProcessMySQLDump_AddPrimaryKeys(t *testing.T) {
 	}
 }
 
+func TestProcessMySQLDump_Filters(t *testing.T) {
+	input := "CREATE TABLE cart (productid bigint, userid bigint, PRIMARY KEY (productid, userid));\n" +
+		"CREATE TABLE cart_tmp (a bigint PRIMARY KEY);\n" +
+		"CREATE TABLE product (id bigint PRIMARY KEY);\n" +
+		"CREATE TABLE orders (id bigint PRIMARY KEY, productid bigint, CONSTRAINT fk_product FOREIGN KEY (productid) REFERENCES product (id));\n" +
+		"CREATE INDEX idx ON product (id);\n" +
+		"INSERT INTO cart (productid, userid) VALUES (1, 2);\n" +
+		"INSERT INTO cart_tmp (a) VALUES (3);\n" +
+		"INSERT INTO product (id) VALUES (4);\n" +
+		"INSERT INTO orders (id, productid) VALUES (5, 4);\n"
+	conv, rows := runProcessMySQLDumpWithFilters(input, internal.Filters{Tables: []string{"cart*", "orders"}, ExcludeTables: []string{"*_tmp"}})
+	var tables []string
+	for t := range conv.SrcSchema {
+		tables = append(tables, t)
+	}
+	assert.ElementsMatch(t, []string{"cart", "orders"}, tables)
+	assert.Equal(t, []spannerData{
+		spannerData{table: "cart", cols: []string{"productid", "userid"}, vals: []interface{}{int64(1), int64(2)}},
+		spannerData{table: "orders", cols: []string{"id", "productid"}, vals: []interface{}{int64(5), int64(4)}},
+	}, rows)
+	assert.Equal(t, int64(2), conv.Rows())
+	// The foreign key to the excluded product table is dropped and reported.
+	assert.Nil(t, conv.SpSchema["orders"].Fks)
+	assert.Equal(t, []internal.SchemaIssue{internal.ExcludedTableForeignKey}, conv.Issues["orders"]["productid"])
+}
+
+func TestProcessMySQLDump_ColumnFilters(t *testing.T) {
+	input := "CREATE TABLE users (id bigint PRIMARY KEY, name text, ssn text, email text);\n" +
+		"CREATE INDEX idx_ssn ON users (ssn);\n" +
+		"CREATE INDEX idx_name ON users (name);\n" +
+		"INSERT INTO users (id, name, ssn, email) VALUES (1, 'a', 'b', 'c');\n"
+	conv, rows := runProcessMySQLDumpWithFilters(input, internal.Filters{Columns: []string{"users.name", "users.ssn"}, ExcludeColumns: []string{"*.ssn", "*.id"}})
+	noIssues(conv, t, "Column filters")
+	assert.Equal(t, []string{"id", "name"}, conv.SpSchema["users"].ColNames)
+	assert.Equal(t, []spannerData{
+		spannerData{table: "users", cols: []string{"id", "name"}, vals: []interface{}{int64(1), "a"}},
+	}, rows)
+	// The index on the excluded column is dropped.
+	assert.Equal(t, 1, len(conv.SpSchema["users"].Indexes))
+	assert.Equal(t, "idx_name", conv.SpSchema["users"].Indexes[0].Name)
+	assert.Equal(t, []internal.SchemaIssue{internal.ExcludedColumn, internal.ExcludedColumnIndex}, conv.Issues["users"]["ssn"])
+	assert.Equal(t, []internal.SchemaIssue{internal.ExcludedColumn}, conv.Issues["users"]["email"])
+	// Primary key columns are kept, with a note.
+	assert.Equal(t, []internal.SchemaIssue{internal.ExcludedPrimaryKey}, conv.Issues["users"]["id"])
+}
+
+func TestProcessMySQLDump_Hotspots(t *testing.T) {
+	input := "CREATE TABLE orders (id bigint NOT NULL AUTO_INCREMENT, item text, PRIMARY KEY (id));\n" +
+		"CREATE TABLE events (ts datetime NOT NULL, kind varchar(10) NOT NULL, PRIMARY KEY (ts, kind));\n" +
+		"CREATE TABLE users (name varchar(10) NOT NULL, PRIMARY KEY (name));\n"
+	conv, _ := runProcessMySQLDump(input)
+	assert.Equal(t, []internal.SchemaIssue{internal.AutoIncrement, internal.SequentialKey}, conv.Issues["orders"]["id"])
+	assert.Equal(t, []internal.SchemaIssue{internal.Datetime, internal.TimestampKey}, conv.Issues["events"]["ts"])
+	assert.Equal(t, 0, len(conv.Issues["users"]))
+	// No remedy is applied by default.
+	assert.Equal(t, []ddl.IndexKey{{Col: "ts"}, {Col: "kind"}}, conv.SpSchema["events"].Pks)
+}
+
+func TestProcessMySQLDump_MultipleDatabases(t *testing.T) {
+	input := "CREATE DATABASE `shop`;\n" +
+		"USE `shop`;\n" +
+		"CREATE TABLE `users` (`id` bigint NOT NULL, `name` text, PRIMARY KEY (`id`));\n" +
+		"INSERT INTO `users` VALUES (1,'a');\n" +
+		"CREATE DATABASE `billing`;\n" +
+		"USE `billing`;\n" +
+		"CREATE TABLE `users` (`id` bigint NOT NULL, `plan` text, PRIMARY KEY (`id`));\n" +
+		"CREATE TABLE `invoices` (`id` bigint NOT NULL, `user_id` bigint, PRIMARY KEY (`id`),\n" +
+		"  CONSTRAINT `fk_user` FOREIGN KEY (`user_id`) REFERENCES `shop`.`users` (`id`));\n" +
+		"INSERT INTO `users` VALUES (2,'pro');\n" +
+		"INSERT INTO `invoices` VALUES (3,1);\n" +
+		"USE `mysql`;\n" +
+		"CREATE TABLE `db` (`Host` char(60) NOT NULL, PRIMARY KEY (`Host`));\n"
+	conv, rows := runProcessMySQLDump(input)
+	noIssues(conv, t, "Multiple databases")
+	assert.Equal(t, "shop", conv.DefaultSchema)
+	var tables []string
+	for t := range conv.SrcSchema {
+		tables = append(tables, t)
+	}
+	assert.ElementsMatch(t, []string{"users", "billing.users", "billing.invoices"}, tables)
+	assert.Equal(t, []ddl.Foreignkey{{Name: "fk_user", Columns: []string{"user_id"}, ReferTable: "users", ReferColumns: []string{"id"}}}, conv.SpSchema["billing_invoices"].Fks)
+	assert.Equal(t, []spannerData{
+		spannerData{table: "users", cols: []string{"id", "name"}, vals: []interface{}{int64(1), "a"}},
+		spannerData{table: "billing_users", cols: []string{"id", "plan"}, vals: []interface{}{int64(2), "pro"}},
+		spannerData{table: "billing_invoices", cols: []string{"id", "user_id"}, vals: []interface{}{int64(3), int64(1)}},
+	}, rows)
+
+	// Databases can be filtered.
+	conv, rows = runProcessMySQLDumpWithFilters(input, internal.Filters{Schemas: []string{"billing"}})
+	tables = nil
+	for t := range conv.SpSchema {
+		tables = append(tables, t)
+	}
+	assert.ElementsMatch(t, []string{"billing_users", "billing_invoices"}, tables)
+	assert.Nil(t, conv.SpSchema["billing_invoices"].Fks)
+	assert.Equal(t, []internal.SchemaIssue{internal.ExcludedTableForeignKey}, conv.Issues["billing.invoices"]["user_id"])
+	assert.Equal(t, 2, len(rows))
+}
+
 func runProcessMySQLDump(s string) (*internal.Conv, []spannerData) {
+	return runProcessMySQLDumpWithFilters(s, internal.Filters{})
+}
+
+func runProcessMySQLDumpWithFilters(s string, filters internal.Filters) (*internal.Conv, []spannerData) {
 	conv := internal.MakeConv()
+	conv.Filters = filters
 	conv.SetLocation(time.UTC)
 	conv.SetSchemaMode()
 	mysqlDbDump := DbDumpImpl{}
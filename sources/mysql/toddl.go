@@ -15,6 +15,8 @@
 package mysql
 
 import (
+	"fmt"
+
 	"github.com/cloudspannerecosystem/harbourbridge/internal"
 	"github.com/cloudspannerecosystem/harbourbridge/schema"
 	"github.com/cloudspannerecosystem/harbourbridge/spanner/ddl"
@@ -25,6 +27,7 @@ type ToDdlImpl struct {
 }
 
 // Functions below implement the common.ToDdl interface
+
 // toSpannerType maps a scalar source schema type (defined by id and
 // mods) into a Spanner type. This is the core source-to-Spanner type
 // mapping.  toSpannerType returns the Spanner type and a list of type
@@ -39,6 +42,16 @@ func (tdi ToDdlImpl) ToSpannerType(conv *internal.Conv, columnType schema.Type)
 	return ty, issues
 }
 
+// OverrideType maps columnType to Spanner type spType, using the same
+// allowed mappings as the web UI (see ToSpannerTypeWithTarget).
+func (tdi ToDdlImpl) OverrideType(conv *internal.Conv, columnType schema.Type, spType string) (ddl.Type, []internal.SchemaIssue, error) {
+	ty, issues := ToSpannerTypeWithTarget(columnType.Name, spType, columnType.Mods)
+	if ty.Name != spType {
+		return ddl.Type{}, nil, fmt.Errorf("source type %s can't be mapped to Spanner type %s", columnType.Name, spType)
+	}
+	return ty, issues, nil
+}
+
 func toSpannerTypeInternal(conv *internal.Conv, id string, mods []int64) (ddl.Type, []internal.SchemaIssue) {
 	switch id {
 	case "bool", "boolean":
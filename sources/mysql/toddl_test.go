@@ -103,3 +103,82 @@ func dropComments(t *ddl.CreateTable) {
 		t.ColDefs[c] = cd
 	}
 }
+
+func TestToSpannerType_TypeOverrides(t *testing.T) {
+	conv := internal.MakeConv()
+	conv.SetSchemaMode()
+	conv.TypeOverrides = internal.TypeOverrides{
+		Global:  map[string]string{"datetime": ddl.String, "int": ddl.Int64},
+		Tables:  map[string]map[string]string{"test": {"int": ddl.String}},
+		Columns: map[string]map[string]string{"test": {"d": ddl.Bytes, "e": ddl.Date}},
+	}
+	name := "test"
+	conv.SrcSchema[name] = schema.Table{
+		Name:     name,
+		ColNames: []string{"a", "b", "c", "d", "e"},
+		ColDefs: map[string]schema.Column{
+			"a": schema.Column{Name: "a", Type: schema.Type{Name: "bigint"}},
+			"b": schema.Column{Name: "b", Type: schema.Type{Name: "int"}},
+			"c": schema.Column{Name: "c", Type: schema.Type{Name: "datetime"}},
+			"d": schema.Column{Name: "d", Type: schema.Type{Name: "varchar", Mods: []int64{6}}},
+			"e": schema.Column{Name: "e", Type: schema.Type{Name: "text"}},
+		},
+		PrimaryKeys: []schema.Key{schema.Key{Column: "a"}},
+	}
+	assert.Nil(t, common.SchemaToSpannerDDL(conv, ToDdlImpl{}))
+	actual := conv.SpSchema[name]
+	dropComments(&actual) // Don't test comment.
+	expected := ddl.CreateTable{
+		Name:     name,
+		ColNames: []string{"a", "b", "c", "d", "e"},
+		ColDefs: map[string]ddl.ColumnDef{
+			"a": ddl.ColumnDef{Name: "a", T: ddl.Type{Name: ddl.Int64}},
+			// Per-table overrides take precedence over global overrides.
+			"b": ddl.ColumnDef{Name: "b", T: ddl.Type{Name: ddl.String, Len: ddl.MaxLength}},
+			"c": ddl.ColumnDef{Name: "c", T: ddl.Type{Name: ddl.String, Len: ddl.MaxLength}},
+			"d": ddl.ColumnDef{Name: "d", T: ddl.Type{Name: ddl.Bytes, Len: int64(6)}},
+			// text can't be mapped to DATE, so the override is ignored.
+			"e": ddl.ColumnDef{Name: "e", T: ddl.Type{Name: ddl.String, Len: ddl.MaxLength}},
+		},
+		Pks: []ddl.IndexKey{ddl.IndexKey{Col: "a"}},
+	}
+	assert.Equal(t, expected, actual)
+	expectedIssues := map[string][]internal.SchemaIssue{
+		"b": []internal.SchemaIssue{internal.Widened, internal.TypeOverride},
+		"c": []internal.SchemaIssue{internal.Widened, internal.TypeOverride},
+		"d": []internal.SchemaIssue{internal.TypeOverride},
+		"e": []internal.SchemaIssue{internal.InvalidTypeOverride},
+	}
+	assert.Equal(t, expectedIssues, conv.Issues[name])
+}
+
+func TestToSpannerType_RenameReserved(t *testing.T) {
+	conv := internal.MakeConv()
+	conv.SetSchemaMode()
+	conv.NamingPolicy = internal.NamingPolicy{RenameReserved: true}
+	name := "order"
+	conv.SrcSchema[name] = schema.Table{
+		Name:     name,
+		ColNames: []string{"a", "limit"},
+		ColDefs: map[string]schema.Column{
+			"a":     schema.Column{Name: "a", Type: schema.Type{Name: "bigint"}},
+			"limit": schema.Column{Name: "limit", Type: schema.Type{Name: "int"}},
+		},
+		PrimaryKeys: []schema.Key{schema.Key{Column: "a"}},
+	}
+	assert.Nil(t, common.SchemaToSpannerDDL(conv, ToDdlImpl{}))
+	actual := conv.SpSchema["order_"]
+	dropComments(&actual) // Don't test comment.
+	expected := ddl.CreateTable{
+		Name:     "order_",
+		ColNames: []string{"a", "limit_"},
+		ColDefs: map[string]ddl.ColumnDef{
+			"a":      ddl.ColumnDef{Name: "a", T: ddl.Type{Name: ddl.Int64}},
+			"limit_": ddl.ColumnDef{Name: "limit_", T: ddl.Type{Name: ddl.Int64}},
+		},
+		Pks: []ddl.IndexKey{ddl.IndexKey{Col: "a"}},
+	}
+	assert.Equal(t, expected, actual)
+	// The rename is kept along with the type mapping issues.
+	assert.Equal(t, map[string][]internal.SchemaIssue{"limit": []internal.SchemaIssue{internal.ReservedKeyword, internal.Widened}}, conv.Issues[name])
+}
@@ -12,28 +12,29 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-package web
+package mysql
 
 import (
 	"github.com/cloudspannerecosystem/harbourbridge/internal"
 	"github.com/cloudspannerecosystem/harbourbridge/spanner/ddl"
 )
 
-// toSpannerTypeMySQL defines the mapping of source types into Spanner
+// ToSpannerTypeWithTarget defines the mapping of source types into Spanner
 // types. Each source type has a default Spanner type, as well as other potential
-// Spanner types it could map to. When calling toSpannerTypeMySQL, you specify
+// Spanner types it could map to. When calling ToSpannerTypeWithTarget, you specify
 // the source type name (along with any modifiers), and optionally you specify
 // a target Spanner type name (empty string if you don't have one). If the target
 // Spanner type name is specified and is a potential mapping for this source type,
 // then it will be used to build the returned ddl.Type. If not, the default
 // Spanner type for this source type will be used.
-// Note that toSpannerTypeMySQL is extensively tested via tests in web_test.go.
+// ToSpannerTypeWithTarget is used both by the web UI and by type overrides
+// (see ToDdlImpl.OverrideType), so that they share the same set of allowed
+// type mappings. Note that it is extensively tested via tests in web_test.go.
 //
-// TODO: Move the type remapping function to toddl.go (once we've merged
-// dynamodb/toddl.go, mysql/toddl.go and postgres/toddl.go).
-// Consider some refactoring to reduce code duplication (although note
-// that this type remapping has to preserve all previous changes done via the UI!)
-func toSpannerTypeMySQL(srcType string, spType string, mods []int64) (ddl.Type, []internal.SchemaIssue) {
+// TODO: Consider some refactoring to reduce code duplication with
+// toSpannerTypeInternal (although note that this type remapping has to
+// preserve all previous changes done via the UI!)
+func ToSpannerTypeWithTarget(srcType string, spType string, mods []int64) (ddl.Type, []internal.SchemaIssue) {
 	switch srcType {
 	case "bool", "boolean":
 		switch spType {
@@ -17,10 +17,10 @@ package postgres
 import (
 	"database/sql"
 	"fmt"
-	"math/bits"
 	"reflect"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"cloud.google.com/go/civil"
@@ -48,20 +48,81 @@ func (isi InfoSchemaImpl) GetTableName(schema string, tableName string) string {
 	return fmt.Sprintf("%s.%s", schema, tableName)
 }
 
-func (isi InfoSchemaImpl) GetRowsFromTable(conv *internal.Conv, db *sql.DB, table common.SchemaAndName) (*sql.Rows, error) {
+func (isi InfoSchemaImpl) GetRowsFromTable(conv *internal.Conv, q common.Queryer, table common.SchemaAndName, kr common.KeyRange, page common.Page) (*sql.Rows, error) {
 	// PostgreSQL schema and name can be arbitrary strings.
 	// Ideally we would pass schema/name as a query parameter,
 	// but PostgreSQL doesn't support this. So we quote it instead.
-	q := fmt.Sprintf(`SELECT * FROM "%s"."%s";`, table.Schema, table.Name)
-	rows, err := db.Query(q)
+	// We read the given page of key range kr of the table. When the table
+	// is read in pages, or data migration progress is tracked, we read rows
+	// in primary key order (skipping those already read or migrated).
+	srcTable := isi.GetTableName(table.Schema, table.Name)
+	srcSchema := conv.SrcSchema[srcTable]
+	// Only read columns that pass conv's column filters: values of
+	// excluded columns are never read from the source database.
+	srcCols := conv.IncludedColumns(srcTable, srcSchema.ColNames)
+	if len(srcCols) == 0 {
+		conv.Unexpected(fmt.Sprintf("Couldn't get source columns for table %s ", srcTable))
+		return nil, nil
+	}
+	quote := func(s string) string { return `"` + s + `"` }
+	param := func(i int) string { return fmt.Sprintf("$%d", i) }
+	var colNames []string
+	for _, c := range srcCols {
+		colNames = append(colNames, quote(c))
+	}
+	clauses, args := common.ReadClauses(conv, srcTable, kr, page, srcSchema, srcCols, quote, param)
+	query := fmt.Sprintf(`SELECT %s FROM "%s"."%s"%s;`, strings.Join(colNames, ", "), table.Schema, table.Name, clauses)
+	rows, err := q.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
 	return rows, err
 }
 
+// StartSnapshot opens n connections to db that read the same consistent
+// snapshot of the database: the first connection exports its snapshot
+// (using pg_export_snapshot), and the others import it. The position of the
+// snapshot is the write-ahead log location read just before the snapshot is
+// started (empty if it can't be read e.g. on a standby server).
+func (isi InfoSchemaImpl) StartSnapshot(db *sql.DB, n int) ([]*common.SnapshotConn, internal.SourcePosition, error) {
+	var pos internal.SourcePosition
+	if err := db.QueryRow("SELECT pg_current_wal_lsn()::text").Scan(&pos.LSN); err != nil {
+		internal.VerbosePrintf("Can't get write-ahead log location: %s\n", err)
+	}
+	begin := "BEGIN ISOLATION LEVEL REPEATABLE READ, READ ONLY"
+	first, err := common.OpenSnapshotConn(db, begin)
+	if err != nil {
+		return nil, pos, err
+	}
+	conns := []*common.SnapshotConn{first}
+	rows, err := first.Query("SELECT pg_export_snapshot()")
+	var id string
+	if err == nil {
+		if rows.Next() {
+			err = rows.Scan(&id)
+		} else if err = rows.Err(); err == nil {
+			err = fmt.Errorf("no snapshot returned")
+		}
+		rows.Close()
+	}
+	if err != nil {
+		common.CloseSnapshot(conns)
+		return nil, pos, fmt.Errorf("can't export snapshot: %v", err)
+	}
+	for i := 1; i < n; i++ {
+		// Snapshot ids can't be passed as query parameters.
+		c, err := common.OpenSnapshotConn(db, begin, fmt.Sprintf("SET TRANSACTION SNAPSHOT '%s'", strings.ReplaceAll(id, "'", "''")))
+		if err != nil {
+			common.CloseSnapshot(conns)
+			return nil, pos, err
+		}
+		conns = append(conns, c)
+	}
+	return conns, pos, nil
+}
+
 // ProcessSQLData performs data conversion for source database
-// 'db'. For each table, we extract data using a "SELECT *" query,
+// 'db'. For each table, we extract data using a "SELECT" query,
 // convert the data to Spanner data (based on the source and Spanner
 // schemas), and write it to Spanner.  If we can't get/process data
 // for a table, we skip that table and process the remaining tables.
@@ -81,6 +142,8 @@ func (isi InfoSchemaImpl) GetRowsFromTable(conv *internal.Conv, db *sql.DB, tabl
 // *interface{} parameters to row.Scan.
 func (isi InfoSchemaImpl) ProcessDataRows(conv *internal.Conv, srcTable string, srcCols []string, srcSchema schema.Table, spTable string, spCols []string, spSchema ddl.CreateTable, rows *sql.Rows) {
 	v, iv := buildVals(len(srcCols))
+	// Keys are recorded for paginated reads and data migration progress.
+	keys := common.KeyIndexes(srcSchema, srcCols)
 	for rows.Next() {
 		err := rows.Scan(iv...)
 		if err != nil {
@@ -89,6 +152,13 @@ func (isi InfoSchemaImpl) ProcessDataRows(conv *internal.Conv, srcTable string,
 			conv.StatsAddBadRow(srcTable, conv.DataMode())
 			continue
 		}
+		if keys != nil {
+			var key []string
+			for _, i := range keys {
+				key = append(key, keyToString(v[i], srcSchema.ColDefs[srcCols[i]].Type.Name))
+			}
+			conv.SetRowKey(key)
+		}
 		cvtCols, cvtVals, err := convertSQLRow(conv, srcTable, srcCols, srcSchema, spTable, spCols, spSchema, v)
 		if err != nil {
 			conv.Unexpected(fmt.Sprintf("Couldn't process sql data row: %s", err))
@@ -110,6 +180,9 @@ func convertSQLRow(conv *internal.Conv, srcTable string, srcCols []string, srcSc
 	var vs []interface{}
 	var cs []string
 	for i := range srcCols {
+		if !conv.IncludeColumn(srcTable, srcCols[i]) {
+			continue
+		}
 		srcCd, ok1 := srcSchema.ColDefs[srcCols[i]]
 		spCd, ok2 := spSchema.ColDefs[spCols[i]]
 		if !ok1 || !ok2 {
@@ -133,9 +206,7 @@ func convertSQLRow(conv *internal.Conv, srcTable string, srcCols []string, srcSc
 	}
 	if aux, ok := conv.SyntheticPKeys[spTable]; ok {
 		cs = append(cs, aux.Col)
-		vs = append(vs, int64(bits.Reverse64(uint64(aux.Sequence))))
-		aux.Sequence++
-		conv.SyntheticPKeys[spTable] = aux
+		vs = append(vs, conv.NextSyntheticPKey(spTable))
 	}
 	return cs, vs, nil
 }
@@ -159,6 +230,26 @@ func (isi InfoSchemaImpl) GetRowCount(db *sql.DB, table common.SchemaAndName) (i
 	return 0, nil //Check if 0 is ok to return
 }
 
+// GetKeyStats returns the minimum and maximum values of integer column
+// col of table, along with the estimated number of rows in table. col
+// must be the leading primary key column, so that PostgreSQL reads its
+// minimum and maximum from the index, and the number of rows comes from the
+// catalog statistics (pg_class.reltuples): table isn't scanned.
+func (isi InfoSchemaImpl) GetKeyStats(db *sql.DB, table common.SchemaAndName, col string) (int64, int64, int64, error) {
+	// See GetRowCount for why we quote names instead of passing them as
+	// query parameters.
+	q := fmt.Sprintf(`SELECT MIN("%s"), MAX("%s") FROM "%s"."%s";`, col, col, table.Schema, table.Name)
+	var min, max, count sql.NullInt64
+	if err := db.QueryRow(q).Scan(&min, &max); err != nil {
+		return 0, 0, 0, err
+	}
+	q = "SELECT reltuples::bigint FROM pg_class WHERE oid = $1::regclass;"
+	if err := db.QueryRow(q, fmt.Sprintf(`"%s"."%s"`, table.Schema, table.Name)).Scan(&count); err != nil {
+		return 0, 0, 0, err
+	}
+	return min.Int64, max.Int64, count.Int64, nil
+}
+
 // TODO: All of the queries to get tables and table data should be in
 // a single transaction to ensure we obtain a consistent snapshot of
 // schema information and table data (pg_dump does something
@@ -535,6 +626,26 @@ func buildVals(n int) (v []interface{}, iv []interface{}) {
 	return v, iv
 }
 
+// keyToString returns the string form of a primary key value, in a format
+// that PostgreSQL accepts as a query argument for the key column.
+// lib/pq returns []byte for many text-format types (e.g. uuid and
+// numeric), so we use srcType to pick the encoding.
+func keyToString(val interface{}, srcType string) string {
+	if v, ok := val.(*interface{}); ok {
+		val = *v
+	}
+	switch v := val.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case []byte:
+		if srcType == "bytea" {
+			return fmt.Sprintf(`\x%x`, v) // Pass bytea in hex format.
+		}
+		return string(v)
+	}
+	return fmt.Sprintf("%v", val)
+}
+
 func valsToStrings(vals []interface{}) []string {
 	toString := func(val interface{}) string {
 		if val == nil {
@@ -283,6 +283,251 @@ func TestProcessInfoSchema(t *testing.T) {
 	assert.Equal(t, int64(0), conv.Unexpecteds())
 }
 
+func TestStartSnapshot(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err)
+	mock.ExpectQuery(`SELECT pg_current_wal_lsn\(\)::text`).WillReturnRows(sqlmock.NewRows([]string{"lsn"}).AddRow("0/16B6C50"))
+	mock.ExpectExec("BEGIN ISOLATION LEVEL REPEATABLE READ, READ ONLY").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT pg_export_snapshot\(\)`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("00000003-0000001B-1"))
+	// Other connections import the snapshot of the first.
+	for i := 0; i < 2; i++ {
+		mock.ExpectExec("BEGIN ISOLATION LEVEL REPEATABLE READ, READ ONLY").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("SET TRANSACTION SNAPSHOT '00000003-0000001B-1'").WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+	conns, pos, err := InfoSchemaImpl{}.StartSnapshot(db, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(conns))
+	assert.Equal(t, internal.SourcePosition{LSN: "0/16B6C50"}, pos)
+	assert.Nil(t, mock.ExpectationsWereMet())
+
+	for i := 0; i < 3; i++ {
+		mock.ExpectExec("COMMIT").WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+	common.CloseSnapshot(conns)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestProcessSqlData_Pages(t *testing.T) {
+	ms := []mockSpec{
+		{
+			query: "SELECT table_schema, table_name FROM information_schema.tables where table_type = 'BASE TABLE'",
+			cols:  []string{"table_schema", "table_name"},
+			rows:  [][]driver.Value{{"public", "t"}},
+		}, {
+			query: `SELECT "id", "name" FROM "public"."t" ORDER BY "id" LIMIT 2`,
+			cols:  []string{"id", "name"},
+			rows:  [][]driver.Value{{int64(1), "ant"}, {int64(2), "bat"}},
+		}, {
+			// Each page starts after the last row of the previous page.
+			query: `SELECT "id", "name" FROM "public"."t" WHERE \(\("id" > \$1\)\) ORDER BY "id" LIMIT 2`,
+			args:  []driver.Value{"2"},
+			cols:  []string{"id", "name"},
+			rows:  [][]driver.Value{{int64(3), "cat"}},
+		}, {
+			query: `SELECT "id", "name" FROM "public"."t" WHERE \(\("id" > \$1\)\) ORDER BY "id" LIMIT 2`,
+			args:  []driver.Value{"3"},
+			cols:  []string{"id", "name"},
+		},
+	}
+	db := mkMockDB(t, ms)
+	conv := buildConv(
+		ddl.CreateTable{
+			Name:     "t",
+			ColNames: []string{"id", "name"},
+			ColDefs: map[string]ddl.ColumnDef{
+				"id":   ddl.ColumnDef{Name: "id", T: ddl.Type{Name: ddl.Int64}},
+				"name": ddl.ColumnDef{Name: "name", T: ddl.Type{Name: ddl.String, Len: ddl.MaxLength}},
+			},
+			Pks: []ddl.IndexKey{{Col: "id"}}},
+		schema.Table{
+			Name:     "t",
+			ColNames: []string{"id", "name"},
+			ColDefs: map[string]schema.Column{
+				"id":   schema.Column{Name: "id", Type: schema.Type{Name: "int8"}},
+				"name": schema.Column{Name: "name", Type: schema.Type{Name: "text"}},
+			},
+			PrimaryKeys: []schema.Key{{Column: "id"}}})
+	conv.SetDataMode()
+	var rows []spannerData
+	conv.SetDataSink(
+		func(table string, cols []string, vals []interface{}) {
+			rows = append(rows, spannerData{table: table, cols: cols, vals: vals})
+		})
+	common.ProcessSQLData(conv, db, InfoSchemaImpl{}, common.ReadOptions{PageSize: 2})
+	assert.Equal(t,
+		[]spannerData{
+			spannerData{table: "t", cols: []string{"id", "name"}, vals: []interface{}{int64(1), "ant"}},
+			spannerData{table: "t", cols: []string{"id", "name"}, vals: []interface{}{int64(2), "bat"}},
+			spannerData{table: "t", cols: []string{"id", "name"}, vals: []interface{}{int64(3), "cat"}},
+		},
+		rows)
+	assert.Equal(t, int64(0), conv.Unexpecteds())
+}
+
+func TestProcessSqlData_UUIDKeyPages(t *testing.T) {
+	// lib/pq returns uuid values as []byte, but they must be passed back
+	// to PostgreSQL in text form.
+	id1, id2 := "0a6c4e2e-3c1a-4b8e-9c51-1f0d8e6a7b01", "5d2f9a7c-6b3e-4c1d-8e2f-9a0b1c2d3e4f"
+	ms := []mockSpec{
+		{
+			query: "SELECT table_schema, table_name FROM information_schema.tables where table_type = 'BASE TABLE'",
+			cols:  []string{"table_schema", "table_name"},
+			rows:  [][]driver.Value{{"public", "t"}},
+		}, {
+			query: `SELECT "id", "name" FROM "public"."t" ORDER BY "id" LIMIT 1`,
+			cols:  []string{"id", "name"},
+			rows:  [][]driver.Value{{[]byte(id1), "ant"}},
+		}, {
+			query: `SELECT "id", "name" FROM "public"."t" WHERE \(\("id" > \$1\)\) ORDER BY "id" LIMIT 1`,
+			args:  []driver.Value{id1},
+			cols:  []string{"id", "name"},
+			rows:  [][]driver.Value{{[]byte(id2), "bat"}},
+		}, {
+			query: `SELECT "id", "name" FROM "public"."t" WHERE \(\("id" > \$1\)\) ORDER BY "id" LIMIT 1`,
+			args:  []driver.Value{id2},
+			cols:  []string{"id", "name"},
+		},
+	}
+	db := mkMockDB(t, ms)
+	conv := buildConv(
+		ddl.CreateTable{
+			Name:     "t",
+			ColNames: []string{"id", "name"},
+			ColDefs: map[string]ddl.ColumnDef{
+				"id":   ddl.ColumnDef{Name: "id", T: ddl.Type{Name: ddl.String, Len: ddl.MaxLength}},
+				"name": ddl.ColumnDef{Name: "name", T: ddl.Type{Name: ddl.String, Len: ddl.MaxLength}},
+			},
+			Pks: []ddl.IndexKey{{Col: "id"}}},
+		schema.Table{
+			Name:     "t",
+			ColNames: []string{"id", "name"},
+			ColDefs: map[string]schema.Column{
+				"id":   schema.Column{Name: "id", Type: schema.Type{Name: "uuid"}},
+				"name": schema.Column{Name: "name", Type: schema.Type{Name: "text"}},
+			},
+			PrimaryKeys: []schema.Key{{Column: "id"}}})
+	cp := internal.NewCheckpoint("")
+	conv.SetCheckpoint(cp)
+	conv.SetDataMode()
+	var rows []spannerData
+	conv.SetDataSink(
+		func(table string, cols []string, vals []interface{}) {
+			t.Errorf("Rows must be acknowledged when progress is tracked")
+		})
+	conv.SetAckDataSink(
+		func(table string, cols []string, vals []interface{}, ack func()) {
+			rows = append(rows, spannerData{table: table, cols: cols, vals: vals})
+			ack()
+		})
+	common.ProcessSQLData(conv, db, InfoSchemaImpl{}, common.ReadOptions{PageSize: 1})
+	assert.Equal(t,
+		[]spannerData{
+			spannerData{table: "t", cols: []string{"id", "name"}, vals: []interface{}{id1, "ant"}},
+			spannerData{table: "t", cols: []string{"id", "name"}, vals: []interface{}{id2, "bat"}},
+		},
+		rows)
+	assert.Equal(t, int64(0), conv.Unexpecteds())
+	assert.Equal(t, []string{id2}, cp.LastKey("t", ""))
+}
+
+func TestProcessSqlData_NumericKeyResume(t *testing.T) {
+	ms := []mockSpec{
+		{
+			query: "SELECT table_schema, table_name FROM information_schema.tables where table_type = 'BASE TABLE'",
+			cols:  []string{"table_schema", "table_name"},
+			rows:  [][]driver.Value{{"public", "t"}},
+		}, {
+			// The read resumes after the last key done, in text form.
+			query: `SELECT "id", "name" FROM "public"."t" WHERE \(\("id" > \$1\)\) ORDER BY "id";`,
+			args:  []driver.Value{"2.5"},
+			cols:  []string{"id", "name"},
+			rows:  [][]driver.Value{{[]byte("3.25"), "cat"}},
+		},
+	}
+	db := mkMockDB(t, ms)
+	conv := buildConv(
+		ddl.CreateTable{
+			Name:     "t",
+			ColNames: []string{"id", "name"},
+			ColDefs: map[string]ddl.ColumnDef{
+				"id":   ddl.ColumnDef{Name: "id", T: ddl.Type{Name: ddl.Numeric}},
+				"name": ddl.ColumnDef{Name: "name", T: ddl.Type{Name: ddl.String, Len: ddl.MaxLength}},
+			},
+			Pks: []ddl.IndexKey{{Col: "id"}}},
+		schema.Table{
+			Name:     "t",
+			ColNames: []string{"id", "name"},
+			ColDefs: map[string]schema.Column{
+				"id":   schema.Column{Name: "id", Type: schema.Type{Name: "numeric"}},
+				"name": schema.Column{Name: "name", Type: schema.Type{Name: "text"}},
+			},
+			PrimaryKeys: []schema.Key{{Column: "id"}}})
+	cp := internal.NewCheckpoint("")
+	cp.Add(internal.RowPosition{Table: "t", Key: []string{"2.5"}})()
+	conv.SetCheckpoint(cp)
+	conv.SetDataMode()
+	var rows []spannerData
+	conv.SetDataSink(
+		func(table string, cols []string, vals []interface{}) {
+			t.Errorf("Rows must be acknowledged when progress is tracked")
+		})
+	conv.SetAckDataSink(
+		func(table string, cols []string, vals []interface{}, ack func()) {
+			rows = append(rows, spannerData{table: table, cols: cols, vals: vals})
+			ack()
+		})
+	common.ProcessSQLData(conv, db, InfoSchemaImpl{}, common.ReadOptions{})
+	assert.Equal(t, 1, len(rows))
+	assert.Equal(t, int64(0), conv.Unexpecteds())
+	assert.Equal(t, []string{"3.25"}, cp.LastKey("t", ""))
+	assert.True(t, cp.TableComplete("t", ""))
+}
+
+func TestProcessSqlData_ColumnFilters(t *testing.T) {
+	ms := []mockSpec{
+		{
+			query: "SELECT table_schema, table_name FROM information_schema.tables where table_type = 'BASE TABLE'",
+			cols:  []string{"table_schema", "table_name"},
+			rows:  [][]driver.Value{{"public", "users"}},
+		}, {
+			// Excluded columns are never read from the source database.
+			query: `SELECT "id", "name" FROM "public"."users";`,
+			cols:  []string{"id", "name"},
+			rows:  [][]driver.Value{{int64(1), "ann"}},
+		},
+	}
+	db := mkMockDB(t, ms)
+	conv := buildConv(
+		ddl.CreateTable{
+			Name:     "users",
+			ColNames: []string{"id", "name", "ssn"},
+			ColDefs: map[string]ddl.ColumnDef{
+				"id":   ddl.ColumnDef{Name: "id", T: ddl.Type{Name: ddl.Int64}},
+				"name": ddl.ColumnDef{Name: "name", T: ddl.Type{Name: ddl.String, Len: ddl.MaxLength}},
+				"ssn":  ddl.ColumnDef{Name: "ssn", T: ddl.Type{Name: ddl.String, Len: ddl.MaxLength}},
+			},
+			Pks: []ddl.IndexKey{{Col: "id"}}},
+		schema.Table{
+			Name:     "users",
+			ColNames: []string{"id", "name", "ssn"},
+			ColDefs: map[string]schema.Column{
+				"id":   schema.Column{Name: "id", Type: schema.Type{Name: "int8"}},
+				"name": schema.Column{Name: "name", Type: schema.Type{Name: "text"}},
+				"ssn":  schema.Column{Name: "ssn", Type: schema.Type{Name: "text"}},
+			},
+			PrimaryKeys: []schema.Key{{Column: "id"}}})
+	conv.Filters.ExcludeColumns = []string{"*.ssn"}
+	conv.SetDataMode()
+	var rows []spannerData
+	conv.SetDataSink(
+		func(table string, cols []string, vals []interface{}) {
+			rows = append(rows, spannerData{table: table, cols: cols, vals: vals})
+		})
+	common.ProcessSQLData(conv, db, InfoSchemaImpl{}, common.ReadOptions{})
+	assert.Equal(t, []spannerData{{table: "users", cols: []string{"id", "name"}, vals: []interface{}{int64(1), "ann"}}}, rows)
+	assert.Equal(t, int64(0), conv.Unexpecteds())
+}
+
 // TestProcessSqlData is a basic test of ProcessSqlData that checks
 // handling of bad rows and table and column renaming. The core data
 // conversion work of ProcessSqlData is done by ConvertData, which is
@@ -294,7 +539,7 @@ func TestProcessSqlData(t *testing.T) {
 			cols:  []string{"table_schema", "table_name"},
 			rows:  [][]driver.Value{{"public", "te st"}},
 		}, {
-			query: `SELECT [*] FROM "public"."te st"`, // query is a regexp!
+			query: `SELECT "a_a", "_b", "_c_" FROM "public"."te st"`, // query is a regexp!
 			cols:  []string{"a a", " b", " c "},
 			rows: [][]driver.Value{
 				{42.3, 3, "cat"},
@@ -326,7 +571,7 @@ func TestProcessSqlData(t *testing.T) {
 		func(table string, cols []string, vals []interface{}) {
 			rows = append(rows, spannerData{table: table, cols: cols, vals: vals})
 		})
-	common.ProcessSQLData(conv, db, InfoSchemaImpl{})
+	common.ProcessSQLData(conv, db, InfoSchemaImpl{}, common.ReadOptions{})
 
 	assert.Equal(t,
 		[]spannerData{
@@ -455,7 +700,7 @@ func TestConvertSqlRow_MultiCol(t *testing.T) {
 			cols:  []string{"table_schema", "table_name"},
 			rows:  [][]driver.Value{{"public", "test"}},
 		}, {
-			query: `SELECT [*] FROM "public"."test"`, // query is a regexp!
+			query: `SELECT "a", "b", "c" FROM "public"."test"`, // query is a regexp!
 			cols:  []string{"a", "b", "c"},
 			rows: [][]driver.Value{
 				{"cat", 42.3, nil},
@@ -472,7 +717,7 @@ func TestConvertSqlRow_MultiCol(t *testing.T) {
 		func(table string, cols []string, vals []interface{}) {
 			rows = append(rows, spannerData{table: table, cols: cols, vals: vals})
 		})
-	common.ProcessSQLData(conv, db, InfoSchemaImpl{})
+	common.ProcessSQLData(conv, db, InfoSchemaImpl{}, common.ReadOptions{})
 	assert.Equal(t, []spannerData{
 		{table: "test", cols: []string{"a", "b", "synth_id"}, vals: []interface{}{"cat", float64(42.3), int64(0)}},
 		{table: "test", cols: []string{"a", "c", "synth_id"}, vals: []interface{}{"dog", int64(22), int64(-9223372036854775808)}}},
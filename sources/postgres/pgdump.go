@@ -66,6 +66,9 @@ func processPgDump(conv *internal.Conv, r *internal.Reader) error {
 		if err != nil {
 			return err
 		}
+		// Rows are identified by the offset of the statements they
+		// come from, so that data conversion can be resumed.
+		conv.SetDumpOffset(int64(startOffset))
 		ci := processStatements(conv, stmts)
 		internal.VerbosePrintf("Parsed SQL command at line=%d/fpos=%d: %d stmts (%d lines, %d bytes) ci=%v\n", startLine, startOffset, len(stmts), r.LineNumber-startLine, len(b), ci != nil)
 		if ci != nil {
@@ -129,6 +132,9 @@ func readAndParseChunk(conv *internal.Conv, r *internal.Reader) ([]byte, []*pg_q
 
 func processCopyBlock(conv *internal.Conv, srcTable string, srcCols []string, r *internal.Reader) {
 	internal.VerbosePrintf("Parsing COPY-FROM stdin block starting at line=%d/fpos=%d\n", r.LineNumber, r.Offset)
+	// Blocks for excluded tables must still be read (so that we can process
+	// the remaining pg_dump content), but their rows are not counted or converted.
+	excluded := !conv.IncludeTable(srcTable)
 	for {
 		b := r.ReadLine()
 		if string(b) == "\\.\n" || string(b) == "\\.\r\n" {
@@ -139,6 +145,9 @@ func processCopyBlock(conv *internal.Conv, srcTable string, srcCols []string, r
 			conv.Unexpected("Reached eof while parsing copy-block")
 			return
 		}
+		if excluded {
+			continue
+		}
 		conv.StatsAddRow(srcTable, conv.SchemaMode())
 		// We have to read the copy-block data so that we can process the remaining
 		// pg_dump content. However, if we don't want the data, stop here.
@@ -168,6 +177,10 @@ func processStatements(conv *internal.Conv, rawStmts []*pg_query.RawStmt) *copyO
 	// Typically we'll have only one statement, but we handle the general case.
 	for i, rawStmt := range rawStmts {
 		node := rawStmt.Stmt
+		if _, ok := node.GetNode().(*pg_query.Node_CopyStmt); !ok && excludedTable(conv, node) {
+			conv.SkipStatement(printNodeType(node.GetNode()))
+			continue
+		}
 		switch n := node.GetNode().(type) {
 		case *pg_query.Node_AlterTableStmt:
 			if conv.SchemaMode() {
@@ -200,6 +213,28 @@ func processStatements(conv *internal.Conv, rawStmts []*pg_query.RawStmt) *copyO
 	return nil
 }
 
+// excludedTable returns true if node operates on a table that is excluded
+// by conv's table filters. COPY-FROM statements are handled separately
+// because their data blocks must still be consumed.
+func excludedTable(conv *internal.Conv, node *pg_query.Node) bool {
+	var rel *pg_query.RangeVar
+	switch n := node.GetNode().(type) {
+	case *pg_query.Node_AlterTableStmt:
+		rel = n.AlterTableStmt.Relation
+	case *pg_query.Node_CreateStmt:
+		rel = n.CreateStmt.Relation
+	case *pg_query.Node_InsertStmt:
+		rel = n.InsertStmt.Relation
+	case *pg_query.Node_IndexStmt:
+		rel = n.IndexStmt.Relation
+	}
+	if rel == nil {
+		return false
+	}
+	table, err := getTableName(conv, rel)
+	return err == nil && !conv.IncludeTable(table)
+}
+
 func processIndexStmt(conv *internal.Conv, n *pg_query.IndexStmt) {
 	if n.Relation == nil {
 		logStmtError(conv, n, fmt.Errorf("cannot process index statement with nil relation"))
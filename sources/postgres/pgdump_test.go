@@ -801,8 +801,144 @@ func TestProcessPgDump_WithUnparsableContent(t *testing.T) {
 	}
 }
 
+func TestProcessPgDump_Filters(t *testing.T) {
+	input := "CREATE TABLE cart (productid bigint, userid bigint, PRIMARY KEY (productid, userid));\n" +
+		"CREATE TABLE cart_tmp (a bigint PRIMARY KEY);\n" +
+		"CREATE TABLE product (id bigint PRIMARY KEY);\n" +
+		"CREATE TABLE orders (id bigint PRIMARY KEY, productid bigint);\n" +
+		"ALTER TABLE ONLY orders ADD CONSTRAINT fk_product FOREIGN KEY (productid) REFERENCES product (id);\n" +
+		"CREATE INDEX idx ON product (id);\n" +
+		"COPY cart_tmp (a) FROM stdin;\n" +
+		"3\n" +
+		"\\.\n" +
+		"COPY product (id) FROM stdin;\n" +
+		"4\n" +
+		"\\.\n" +
+		"COPY cart (productid, userid) FROM stdin;\n" +
+		"1\t2\n" +
+		"\\.\n" +
+		"INSERT INTO product (id) VALUES (6);\n" +
+		"INSERT INTO orders (id, productid) VALUES (5, 4);\n"
+	conv, rows := runProcessPgDumpWithFilters(input, internal.Filters{Tables: []string{"cart*", "orders"}, ExcludeTables: []string{"*_tmp"}})
+	var tables []string
+	for t := range conv.SrcSchema {
+		tables = append(tables, t)
+	}
+	assert.ElementsMatch(t, []string{"cart", "orders"}, tables)
+	assert.Equal(t, []spannerData{
+		spannerData{table: "cart", cols: []string{"productid", "userid"}, vals: []interface{}{int64(1), int64(2)}},
+		spannerData{table: "orders", cols: []string{"id", "productid"}, vals: []interface{}{int64(5), int64(4)}},
+	}, rows)
+	assert.Equal(t, int64(2), conv.Rows())
+	assert.Zero(t, conv.BadRows())
+	// The foreign key to the excluded product table is dropped and reported.
+	assert.Nil(t, conv.SpSchema["orders"].Fks)
+	assert.Equal(t, []internal.SchemaIssue{internal.ExcludedTableForeignKey}, conv.Issues["orders"]["productid"])
+}
+
+func TestProcessPgDump_ColumnFilters(t *testing.T) {
+	input := "CREATE TABLE users (id bigint PRIMARY KEY, name text, ssn text);\n" +
+		"CREATE TABLE orders (id bigint PRIMARY KEY, ssn text, total bigint);\n" +
+		"ALTER TABLE ONLY orders ADD CONSTRAINT fk_ssn FOREIGN KEY (ssn) REFERENCES users (ssn);\n" +
+		"CREATE INDEX idx_ssn ON users (ssn);\n" +
+		"COPY users (id, name, ssn) FROM stdin;\n" +
+		"1\ta\tb\n" +
+		"\\.\n" +
+		"INSERT INTO orders (id, ssn, total) VALUES (2, 'b', 3);\n"
+	conv, rows := runProcessPgDumpWithFilters(input, internal.Filters{ExcludeColumns: []string{"users.ssn"}})
+	noIssues(conv, t, "Column filters")
+	assert.Equal(t, []string{"id", "name"}, conv.SpSchema["users"].ColNames)
+	assert.Equal(t, []string{"id", "ssn", "total"}, conv.SpSchema["orders"].ColNames)
+	assert.Equal(t, []spannerData{
+		spannerData{table: "users", cols: []string{"id", "name"}, vals: []interface{}{int64(1), "a"}},
+		spannerData{table: "orders", cols: []string{"id", "ssn", "total"}, vals: []interface{}{int64(2), "b", int64(3)}},
+	}, rows)
+	// The index and the foreign key that use the excluded column are dropped.
+	assert.Nil(t, conv.SpSchema["users"].Indexes)
+	assert.Nil(t, conv.SpSchema["orders"].Fks)
+	assert.Equal(t, []internal.SchemaIssue{internal.ExcludedColumn, internal.ExcludedColumnIndex}, conv.Issues["users"]["ssn"])
+	assert.Equal(t, []internal.SchemaIssue{internal.ExcludedColumnForeignKey}, conv.Issues["orders"]["ssn"])
+}
+
+func TestProcessPgDump_Schemas(t *testing.T) {
+	input := "CREATE TABLE sales.orders (id bigint PRIMARY KEY, user_id bigint);\n" +
+		"CREATE TABLE hr.staff (id bigint PRIMARY KEY);\n" +
+		"CREATE TABLE users (id bigint PRIMARY KEY);\n" +
+		"ALTER TABLE ONLY sales.orders ADD CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES public.users (id);\n" +
+		"CREATE INDEX idx_user ON sales.orders (user_id);\n" +
+		"INSERT INTO sales.orders (id, user_id) VALUES (1, 2);\n"
+	conv := internal.MakeConv()
+	conv.Filters = internal.Filters{Schemas: []string{"sales", "public"}}
+	conv.NamingPolicy = internal.NamingPolicy{Schemas: internal.SchemasNamed}
+	conv.SetSchemaMode()
+	err := common.ProcessDbDump(conv, internal.NewReader(bufio.NewReader(strings.NewReader(input)), nil), DbDumpImpl{})
+	assert.Nil(t, err)
+	noIssues(conv, t, "Schemas")
+	var tables []string
+	for t := range conv.SpSchema {
+		tables = append(tables, t)
+	}
+	assert.ElementsMatch(t, []string{"sales.orders", "users"}, tables)
+	orders := conv.SpSchema["sales.orders"]
+	assert.Equal(t, "sales.idx_user", orders.Indexes[0].Name)
+	assert.Equal(t, []ddl.Foreignkey{{Name: "sales.fk_user", Columns: []string{"user_id"}, ReferTable: "users", ReferColumns: []string{"id"}}}, orders.Fks)
+
+	conv.SetDataMode()
+	var rows []spannerData
+	conv.SetDataSink(
+		func(table string, cols []string, vals []interface{}) {
+			rows = append(rows, spannerData{table: table, cols: cols, vals: vals})
+		})
+	common.ProcessDbDump(conv, internal.NewReader(bufio.NewReader(strings.NewReader(input)), nil), DbDumpImpl{})
+	assert.Equal(t, []spannerData{
+		spannerData{table: "sales.orders", cols: []string{"id", "user_id"}, vals: []interface{}{int64(1), int64(2)}},
+	}, rows)
+
+	// Tables from different schemas that map to the same Spanner table
+	// are rejected.
+	input = "CREATE TABLE sales.orders (id bigint PRIMARY KEY);\n" +
+		"CREATE TABLE sales_orders (id bigint PRIMARY KEY);\n"
+	conv = internal.MakeConv()
+	conv.SetSchemaMode()
+	err = common.ProcessDbDump(conv, internal.NewReader(bufio.NewReader(strings.NewReader(input)), nil), DbDumpImpl{})
+	assert.NotNil(t, err)
+}
+
+func TestProcessPgDump_RowPositions(t *testing.T) {
+	input := "CREATE TABLE t (id bigint PRIMARY KEY);\n" +
+		"INSERT INTO t (id) VALUES (1), (2);\n" +
+		"COPY t (id) FROM stdin;\n" +
+		"3\n" +
+		"4\n" +
+		"\\.\n"
+	conv := internal.MakeConv()
+	conv.SetSchemaMode()
+	common.ProcessDbDump(conv, internal.NewReader(bufio.NewReader(strings.NewReader(input)), nil), DbDumpImpl{})
+	conv.SetDataMode()
+	var positions []internal.RowPosition
+	conv.SetDataSink(
+		func(table string, cols []string, vals []interface{}) {
+			positions = append(positions, conv.RowPosition())
+		})
+	common.ProcessDbDump(conv, internal.NewReader(bufio.NewReader(strings.NewReader(input)), nil), DbDumpImpl{})
+	// Rows are identified by the offset of their statement (starting at 1)
+	// and their index among the statement's rows.
+	insert, copy := int64(41), int64(77)
+	assert.Equal(t, []internal.RowPosition{
+		{Table: "t", Dump: true, Offset: insert, Row: 0},
+		{Table: "t", Dump: true, Offset: insert, Row: 1},
+		{Table: "t", Dump: true, Offset: copy, Row: 0},
+		{Table: "t", Dump: true, Offset: copy, Row: 1},
+	}, positions)
+}
+
 func runProcessPgDump(s string) (*internal.Conv, []spannerData) {
+	return runProcessPgDumpWithFilters(s, internal.Filters{})
+}
+
+func runProcessPgDumpWithFilters(s string, filters internal.Filters) (*internal.Conv, []spannerData) {
 	conv := internal.MakeConv()
+	conv.Filters = filters
 	conv.SetLocation(time.UTC)
 	conv.SetSchemaMode()
 	pgDump := DbDumpImpl{}
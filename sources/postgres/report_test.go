@@ -50,6 +50,7 @@ func TestReport(t *testing.T) {
 	conv.SetSchemaMode()
 	common.ProcessDbDump(conv, internal.NewReader(bufio.NewReader(strings.NewReader(s)), nil), DbDumpImpl{})
 	conv.SetDataMode()
+	conv.SetWriteMode("insert_or_update")
 	conv.Stats.Rows = map[string]int64{"bad_schema": 1000, "no_pk": 5000}
 	conv.Stats.GoodRows = map[string]int64{"bad_schema": 990, "no_pk": 3000}
 	conv.Stats.BadRows = map[string]int64{"bad_schema": 10, "no_pk": 2000}
@@ -72,6 +73,8 @@ Summary of Conversion
 Schema conversion: OK (some columns did not map cleanly + some missing primary keys).
 Data conversion: POOR (66% of 6000 rows written to Spanner).
 
+Rows were written to Spanner using write mode insert_or_update.
+
 The remainder of this report provides stats on the pg_dump statements processed,
 followed by a table-by-table listing of schema and data conversion details. For
 background on the schema and data conversion process used, and explanations of
@@ -15,6 +15,8 @@
 package postgres
 
 import (
+	"fmt"
+
 	"github.com/cloudspannerecosystem/harbourbridge/internal"
 	"github.com/cloudspannerecosystem/harbourbridge/schema"
 	"github.com/cloudspannerecosystem/harbourbridge/spanner/ddl"
@@ -30,18 +32,28 @@ type ToDdlImpl struct {
 // conversion issues encountered.
 func (tdi ToDdlImpl) ToSpannerType(conv *internal.Conv, columnType schema.Type) (ddl.Type, []internal.SchemaIssue) {
 	ty, issues := toSpannerTypeInternal(conv, columnType.Name, columnType.Mods)
-	if conv.TargetDb == "experimental_postgres" { //TODO : Use constant instead. Using string to prevent import cycle
-		ty = overrideExperimentalType(columnType, ty)
-	} else {
-		if len(columnType.ArrayBounds) > 1 {
-			ty = ddl.Type{Name: ddl.String, Len: ddl.MaxLength}
-			issues = append(issues, internal.MultiDimensionalArray)
-		}
-		ty.IsArray = len(columnType.ArrayBounds) == 1
+	if len(columnType.ArrayBounds) > 1 {
+		ty = ddl.Type{Name: ddl.String, Len: ddl.MaxLength}
+		issues = append(issues, internal.MultiDimensionalArray)
 	}
+	ty.IsArray = len(columnType.ArrayBounds) == 1
 	return ty, issues
 }
 
+// OverrideType maps columnType to Spanner type spType, using the same
+// allowed mappings as the web UI (see ToSpannerTypeWithTarget).
+func (tdi ToDdlImpl) OverrideType(conv *internal.Conv, columnType schema.Type, spType string) (ddl.Type, []internal.SchemaIssue, error) {
+	if len(columnType.ArrayBounds) > 1 {
+		return ddl.Type{}, nil, fmt.Errorf("can't override the type of multi-dimensional arrays")
+	}
+	ty, issues := ToSpannerTypeWithTarget(columnType.Name, spType, columnType.Mods)
+	if ty.Name != spType {
+		return ddl.Type{}, nil, fmt.Errorf("source type %s can't be mapped to Spanner type %s", columnType.Name, spType)
+	}
+	ty.IsArray = len(columnType.ArrayBounds) == 1
+	return ty, issues, nil
+}
+
 // toSpannerType maps a scalar source schema type (defined by id and
 // mods) into a Spanner type. This is the core source-to-Spanner type
 // mapping.  toSpannerType returns the Spanner type and a list of type
@@ -105,15 +117,3 @@ func toSpannerTypeInternal(conv *internal.Conv, id string, mods []int64) (ddl.Ty
 	}
 	return ddl.Type{Name: ddl.String, Len: ddl.MaxLength}, []internal.SchemaIssue{internal.NoGoodType}
 }
-
-// Override the types to map to experimental postgres types.
-func overrideExperimentalType(columnType schema.Type, originalType ddl.Type) ddl.Type {
-	switch originalType.Name {
-	case ddl.Numeric, ddl.Date:
-		return ddl.Type{Name: ddl.String, Len: ddl.MaxLength}
-	}
-	if len(columnType.ArrayBounds) > 0 {
-		return ddl.Type{Name: ddl.String, Len: ddl.MaxLength}
-	}
-	return originalType
-}
@@ -37,35 +37,70 @@ const (
 	byteThreshold  = 20 * 1 << 20 // Spanner per-operation limit is 100MB.
 )
 
+// Write modes control the mutations used to write rows to Spanner.
+const (
+	WriteModeInsert         = "insert"           // Rows that already exist fail with 'AlreadyExists'.
+	WriteModeInsertOrUpdate = "insert_or_update" // Existing rows are updated (columns not written keep their values).
+	WriteModeReplace        = "replace"          // Existing rows are replaced (columns not written become NULL).
+	WriteModeUpdate         = "update"           // Rows that don't exist fail with 'NotFound'.
+)
+
+// CheckWriteMode returns an error if mode isn't one of the write modes.
+func CheckWriteMode(mode string) error {
+	if _, ok := mutationFuncs[mode]; !ok {
+		return fmt.Errorf("unknown write mode '%s' (want %s, %s, %s or %s)", mode, WriteModeInsert, WriteModeInsertOrUpdate, WriteModeReplace, WriteModeUpdate)
+	}
+	return nil
+}
+
+// mutationFunc builds the mutation that writes a row.
+type mutationFunc func(table string, cols []string, vals []interface{}) *sp.Mutation
+
+var mutationFuncs = map[string]mutationFunc{
+	WriteModeInsert:         sp.Insert,
+	WriteModeInsertOrUpdate: sp.InsertOrUpdate,
+	WriteModeReplace:        sp.Replace,
+	WriteModeUpdate:         sp.Update,
+}
+
 // BatchWriter accumulates rows of data (via AddRow) and assembles them
-// into batches that it asynchronously writes to Spanner.  Rows are
-// written to Spanner using insert semantics i.e. if a row already exists
-// in the database, the row will fail with error 'AlreadyExists'.  If
-// Spanner returns an error for a batch, BatchWriter splits the batch
-// into smaller chunks to retry, as it attempts to isolate which row(s)
-// in a batch is bad.  BatchWriter respects Spanner's limits on byte size
-// and mutation count and has configurable limits on the number of
-// in-progress writes, amount of data buffered and retry behavior.
-// BatchWriter is not threadsafe: only one call to AddRow or Flush should
-// be active at any time.  See ExampleBatchWriter (batchwriter_test.go)
-// for sample usage code.
+// into batches that it asynchronously writes to Spanner.  By default,
+// rows are written to Spanner using insert semantics i.e. if a row already
+// exists in the database, the row will fail with error 'AlreadyExists'.
+// Other write modes (see BatchWriterConfig) make it safe to write rows
+// again e.g. when re-running a migration.  If Spanner returns an error
+// for a batch, BatchWriter splits the batch into smaller chunks to retry,
+// as it attempts to isolate which row(s) in a batch is bad.  BatchWriter
+// respects Spanner's limits on byte size and mutation count and has
+// configurable limits on the number of in-progress writes, amount of data
+// buffered, retry behavior and the rate at which rows are written.
+// BatchWriter is threadsafe: several go routines can add rows concurrently
+// e.g. when reading source tables in parallel.  See ExampleBatchWriter
+// (batchwriter_test.go) for sample usage code.
 type BatchWriter struct {
+	lock       sync.Mutex                 // Serializes calls to AddRow, DeleteRow and Flush, and protects all fields below except async.
 	rows       []*row                     // Buffered rows.
 	rBytes     int64                      // Estimate of bytes for buffered rows.
 	rCount     int64                      // Mutation count for buffered rows.
 	write      func([]*sp.Mutation) error // Typically a closure that calls client.Apply, but structured this way for testing.
+	mutation   mutationFunc               // Builds the mutation for a row, as determined by the write mode.
 	wg         sync.WaitGroup             // Tracks in-progress writes.
 	writeLimit int64                      // Limit on number of in-progress writes.
 	bytesLimit int64                      // Limit on bytes buffered. AddRow blocks if rBytes exceeded this value.
 	retryLimit int64                      // Limit on retries.
 	verbose    bool                       // If true, print out messages about each write batch.
+	rowRate    int64                      // Limit on rows written per second (0 means no limit).
+	rateStart  time.Time                  // Time of the first write, when rowRate is set.
+	rowsSent   int64                      // Count of rows in writes started, when rowRate is set.
 	async      asyncState
 }
 
 type row struct {
-	table string
-	cols  []string
-	vals  []interface{}
+	table  string
+	cols   []string
+	vals   []interface{}
+	ack    func() // Called once the row has been written (may be nil).
+	delete bool   // If true, the row is deleted: cols and vals are its primary key.
 }
 
 // Fields in this struct are modified asynchronously e.g. by go routines writing
@@ -92,16 +127,28 @@ type BatchWriterConfig struct {
 	RetryLimit int64                      // Limit on retries.
 	Write      func([]*sp.Mutation) error // Function to call to write to Spanner (typically a closure that calls client.Apply).
 	Verbose    bool                       // If true, print out messages about each write batch.
+	WriteMode  string                     // One of the write modes (empty means WriteModeInsert).
+	// Limit on the average number of rows written per second, to protect
+	// the Spanner instance (and other users of it). Zero means no limit.
+	MaxRowsPerSecond int64
 }
 
 // NewBatchWriter returns a new BatchWriter with parameters defined by config.
+// Unknown write modes are treated as WriteModeInsert: use CheckWriteMode to
+// validate them.
 func NewBatchWriter(config BatchWriterConfig) *BatchWriter {
+	mutation, ok := mutationFuncs[config.WriteMode]
+	if !ok {
+		mutation = sp.Insert
+	}
 	return &BatchWriter{
 		write:      config.Write,
+		mutation:   mutation,
 		writeLimit: config.WriteLimit,
 		bytesLimit: config.BytesLimit,
 		retryLimit: config.RetryLimit,
 		verbose:    config.Verbose,
+		rowRate:    config.MaxRowsPerSecond,
 		async: asyncState{
 			errors:      make(map[string]int64),
 			droppedRows: make(map[string]int64),
@@ -114,7 +161,28 @@ func NewBatchWriter(config BatchWriterConfig) *BatchWriter {
 // or it may block (waiting for some of the writes already in progress to
 // complete) and then initiate writes.
 func (bw *BatchWriter) AddRow(table string, cols []string, vals []interface{}) {
-	r := &row{table, cols, vals}
+	bw.AddRowWithAck(table, cols, vals, nil)
+}
+
+// AddRowWithAck is like AddRow, but also arranges for ack to be called
+// once the row has been written to Spanner. Rows that are dropped are never
+// acknowledged. Note that ack is called from the go routines writing data,
+// and rows may be acknowledged out of order.
+func (bw *BatchWriter) AddRowWithAck(table string, cols []string, vals []interface{}, ack func()) {
+	bw.addRow(&row{table, cols, vals, ack, false})
+}
+
+// DeleteRow appends the deletion of a row to bw's buffer of rows. keyCols
+// and key are the primary key columns of the row and their values. Rows
+// are written and deleted in the order they are added, provided bw is
+// configured with a WriteLimit of 1.
+func (bw *BatchWriter) DeleteRow(table string, keyCols []string, key []interface{}) {
+	bw.addRow(&row{table, keyCols, key, nil, true})
+}
+
+func (bw *BatchWriter) addRow(r *row) {
+	bw.lock.Lock()
+	defer bw.lock.Unlock()
 	bw.rows = append(bw.rows, r)
 	bw.rBytes += byteSize(r)
 	bw.rCount += int64(len(r.cols))
@@ -124,6 +192,8 @@ func (bw *BatchWriter) AddRow(table string, cols []string, vals []interface{}) {
 // Flush initiates writes to Spanner of all buffered rows of data, and waits
 // for them to complete.
 func (bw *BatchWriter) Flush() {
+	bw.lock.Lock()
+	defer bw.lock.Unlock()
 	for len(bw.rows) > 0 {
 		if atomic.LoadInt64(&bw.async.writes) < bw.writeLimit {
 			m, count, bytes := bw.getBatch()
@@ -248,7 +318,11 @@ func (bw *BatchWriter) errorStats(rows []*row, err error, retry bool) {
 func (bw *BatchWriter) doWriteAndHandleErrors(rows []*row) {
 	var m []*sp.Mutation
 	for _, x := range rows {
-		m = append(m, sp.Insert(x.table, x.cols, x.vals))
+		if x.delete {
+			m = append(m, sp.Delete(x.table, sp.Key(x.vals)))
+		} else {
+			m = append(m, bw.mutation(x.table, x.cols, x.vals))
+		}
 	}
 	if err := bw.write(m); err != nil {
 		hitRetryLimit := atomic.LoadInt64(&bw.async.retries) >= bw.retryLimit
@@ -276,6 +350,17 @@ func (bw *BatchWriter) doWriteAndHandleErrors(rows []*row) {
 			atomic.AddInt64(&bw.async.retries, 1)
 			bw.doWriteAndHandleErrors(rows[i:min(i+k, len(rows))])
 		}
+		return
+	}
+	ackRows(rows)
+}
+
+// ackRows calls the ack functions of rows that have been written.
+func ackRows(rows []*row) {
+	for _, x := range rows {
+		if x.ack != nil {
+			x.ack()
+		}
 	}
 }
 
@@ -289,11 +374,26 @@ func (bw *BatchWriter) backgroundWrite(rows []*row) {
 
 // startWrite initiates an asynchronous write of rows to Spanner.
 func (bw *BatchWriter) startWrite(rows []*row) {
+	bw.throttle(len(rows))
 	bw.wg.Add(1)
 	atomic.AddInt64(&bw.async.writes, 1)
 	go bw.backgroundWrite(rows)
 }
 
+// throttle waits until a write of n more rows keeps bw within its limit
+// on the rate of rows written.
+func (bw *BatchWriter) throttle(n int) {
+	if bw.rowRate <= 0 {
+		return
+	}
+	if bw.rateStart.IsZero() {
+		bw.rateStart = time.Now()
+	}
+	due := bw.rateStart.Add(time.Duration(float64(bw.rowsSent) / float64(bw.rowRate) * float64(time.Second)))
+	time.Sleep(time.Until(due))
+	bw.rowsSent += int64(n)
+}
+
 // writeData initiates writes to Spanner until either:
 // a) we have less than a 'batch' to write, or
 // b) we've hit writeLimit and we're under bytesLimit.
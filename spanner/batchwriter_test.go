@@ -21,11 +21,14 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	sp "cloud.google.com/go/spanner"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudspannerecosystem/harbourbridge/internal"
 )
 
 // TestFlush tests NewBatchWriter, AddRow and Flush.
@@ -93,6 +96,183 @@ func TestFlush(t *testing.T) {
 	}
 }
 
+func TestAddRowWithAck(t *testing.T) {
+	data, _ := generateRows(100, 5)
+	_, badRows := partitionRows(map[int]bool{3: true, 42: true}, data)
+	badMutations := toMutations(badRows)
+	mutex := &sync.Mutex{}
+	acks := make(map[int]int)
+	bw := NewBatchWriter(BatchWriterConfig{
+		WriteLimit: 40,
+		BytesLimit: 100 << 20,
+		RetryLimit: 1000,
+		Write: func(m []*sp.Mutation) error {
+			if intersect(m, badMutations) {
+				return errors.New("bad data")
+			}
+			return nil
+		},
+	})
+	for i, x := range data {
+		i := i
+		bw.AddRowWithAck(x.table, x.cols, x.vals, func() {
+			mutex.Lock()
+			acks[i]++
+			mutex.Unlock()
+		})
+	}
+	bw.Flush()
+	// Every row written is acknowledged exactly once, and the bad rows that
+	// were dropped are never acknowledged.
+	assert.Equal(t, len(data)-2, len(acks))
+	for i := range data {
+		n := 1
+		if i == 3 || i == 42 {
+			n = 0
+		}
+		assert.Equal(t, n, acks[i], fmt.Sprintf("acks for row %d", i))
+	}
+}
+
+func TestAddRowWithAck_Checkpoint(t *testing.T) {
+	data, _ := generateRows(10, 5)
+	_, badRows := partitionRows(map[int]bool{4: true}, data)
+	badMutations := toMutations(badRows)
+	bw := NewBatchWriter(BatchWriterConfig{
+		WriteLimit: 1,
+		BytesLimit: 100 << 20,
+		RetryLimit: 1000,
+		Write: func(m []*sp.Mutation) error {
+			if intersect(m, badMutations) {
+				return errors.New("bad data")
+			}
+			return nil
+		},
+	})
+	cp := internal.NewCheckpoint("")
+	for i, x := range data {
+		bw.AddRowWithAck(x.table, x.cols, x.vals, cp.Add(internal.RowPosition{Table: "t", Key: []string{fmt.Sprint(i)}}))
+	}
+	cp.TableRead("t", "")
+	bw.Flush()
+	// The checkpoint stops before the dropped row, so resuming reads it
+	// (and the rows after it) again.
+	assert.Equal(t, []string{"3"}, cp.LastKey("t", ""))
+	assert.False(t, cp.TableComplete("t", ""))
+}
+
+func TestConcurrentAddRow(t *testing.T) {
+	data, _ := generateRows(20000, 5)
+	mutex := &sync.Mutex{}
+	var rowsWritten []*sp.Mutation
+	bw := NewBatchWriter(BatchWriterConfig{
+		WriteLimit: 40,
+		BytesLimit: 100 << 20,
+		RetryLimit: 1000,
+		Write: func(m []*sp.Mutation) error {
+			mutex.Lock()
+			rowsWritten = append(rowsWritten, m...)
+			mutex.Unlock()
+			return nil
+		},
+	})
+	var wg sync.WaitGroup
+	readers := 4
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := i; j < len(data); j += readers {
+				bw.AddRow(data[j].table, data[j].cols, data[j].vals)
+			}
+		}(i)
+	}
+	wg.Wait()
+	bw.Flush()
+	equalMutations(t, toMutations(data), rowsWritten, "Concurrent writes")
+}
+
+func TestMaxRowsPerSecond(t *testing.T) {
+	data, _ := generateRows(15000, 5)
+	var rows int64
+	bw := NewBatchWriter(BatchWriterConfig{
+		WriteLimit:       40,
+		BytesLimit:       100 << 20,
+		RetryLimit:       1000,
+		MaxRowsPerSecond: 20000,
+		Write: func(m []*sp.Mutation) error {
+			atomic.AddInt64(&rows, int64(len(m)))
+			return nil
+		},
+	})
+	start := time.Now()
+	for _, x := range data {
+		bw.AddRow(x.table, x.cols, x.vals)
+	}
+	bw.Flush()
+	// Rows are written in batches of about 5000 rows (see generateRows), so the
+	// last batch can't start until 10000 rows' worth of time has passed.
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(450*time.Millisecond))
+	assert.Equal(t, int64(len(data)), rows)
+}
+
+func TestWriteMode(t *testing.T) {
+	tests := []struct {
+		mode     string
+		mutation func(table string, cols []string, vals []interface{}) *sp.Mutation
+	}{
+		{"", sp.Insert},
+		{WriteModeInsert, sp.Insert},
+		{WriteModeInsertOrUpdate, sp.InsertOrUpdate},
+		{WriteModeReplace, sp.Replace},
+		{WriteModeUpdate, sp.Update},
+	}
+	cols := []string{"id", "name"}
+	vals := []interface{}{int64(1), "a"}
+	for _, tc := range tests {
+		var written []*sp.Mutation
+		bw := NewBatchWriter(BatchWriterConfig{
+			WriteLimit: 1,
+			BytesLimit: 100 << 20,
+			WriteMode:  tc.mode,
+			Write: func(m []*sp.Mutation) error {
+				written = append(written, m...)
+				return nil
+			},
+		})
+		bw.AddRow("t", cols, vals)
+		bw.Flush()
+		assert.Equal(t, []*sp.Mutation{tc.mutation("t", cols, vals)}, written, tc.mode)
+	}
+	assert.Nil(t, CheckWriteMode(WriteModeReplace))
+	assert.NotNil(t, CheckWriteMode("upsert"))
+	assert.NotNil(t, CheckWriteMode(""))
+}
+
+func TestDeleteRow(t *testing.T) {
+	var written []*sp.Mutation
+	bw := NewBatchWriter(BatchWriterConfig{
+		WriteLimit: 1,
+		BytesLimit: 100 << 20,
+		WriteMode:  WriteModeInsertOrUpdate,
+		Write: func(m []*sp.Mutation) error {
+			written = append(written, m...)
+			return nil
+		},
+	})
+	cols := []string{"id", "name"}
+	bw.AddRow("t", cols, []interface{}{int64(1), "a"})
+	bw.DeleteRow("t", []string{"id"}, []interface{}{int64(1)})
+	bw.AddRow("t", cols, []interface{}{int64(1), "b"})
+	bw.Flush()
+	// Writes and deletes are applied in the order they were added.
+	assert.Equal(t, []*sp.Mutation{
+		sp.InsertOrUpdate("t", cols, []interface{}{int64(1), "a"}),
+		sp.Delete("t", sp.Key{int64(1)}),
+		sp.InsertOrUpdate("t", cols, []interface{}{int64(1), "b"}),
+	}, written)
+}
+
 func TestDroppedRowsByTable(t *testing.T) {
 	bw := NewBatchWriter(BatchWriterConfig{})
 	bw.async.lock.Lock()
@@ -109,8 +289,8 @@ func TestSampleBadRows(t *testing.T) {
 	bw := NewBatchWriter(BatchWriterConfig{})
 	bw.async.lock.Lock()
 	bw.async.sampleBadRows = []*row{
-		&row{"test", []string{"col1", "col2"}, []interface{}{"a", int64(42)}},
-		&row{"test", []string{"col1", "col2"}, []interface{}{"b", int64(6)}},
+		&row{"test", []string{"col1", "col2"}, []interface{}{"a", int64(42)}, nil, false},
+		&row{"test", []string{"col1", "col2"}, []interface{}{"b", int64(6)}, nil, false},
 	}
 	bw.async.lock.Unlock()
 	l := bw.SampleBadRows(1)
@@ -164,7 +344,7 @@ func generateRows(count int, size int) ([]*row, int) {
 	for i := 0; i < count; i++ {
 		// vals[0] serves as a unique id for each row.
 		vals := []interface{}{i, val}
-		r = append(r, &row{"table", cols, vals})
+		r = append(r, &row{"table", cols, vals, nil, false})
 	}
 	// Find the max number of rows in a write for the (fixed sized)
 	// rows generated in this test data.
@@ -51,6 +51,14 @@ const (
 	MaxLength = math.MaxInt64
 )
 
+// Spanner SQL dialects, used by Config.
+const (
+	// GoogleSQL is the dialect of regular Cloud Spanner databases.
+	GoogleSQL string = "googlesql"
+	// PostgreSQL is the dialect of PostgreSQL-dialect Cloud Spanner databases.
+	PostgreSQL string = "postgresql"
+)
+
 // Type represents the type of a column.
 //     type:
 //        { BOOL | INT64 | FLOAT64 | STRING( length ) | BYTES( length ) | DATE | TIMESTAMP | NUMERIC }
@@ -83,6 +91,42 @@ func (ty Type) PrintColumnDefType() string {
 	return str
 }
 
+// PGPrintColumnDefType unparses the type encoded in a ColumnDef using the
+// PostgreSQL dialect's names for Spanner types.
+func (ty Type) PGPrintColumnDefType() string {
+	var str string
+	switch ty.Name {
+	case Bool:
+		str = "boolean"
+	case Bytes:
+		// bytea has no length in the PostgreSQL dialect.
+		str = "bytea"
+	case Date:
+		str = "date"
+	case Float64:
+		str = "double precision"
+	case Int64:
+		str = "bigint"
+	case Json:
+		str = "jsonb"
+	case Numeric:
+		str = "numeric"
+	case String:
+		str = "varchar"
+		if ty.Len != MaxLength {
+			str += "(" + strconv.FormatInt(ty.Len, 10) + ")"
+		}
+	case Timestamp:
+		str = "timestamptz"
+	default:
+		str = strings.ToLower(ty.Name)
+	}
+	if ty.IsArray {
+		str += "[]"
+	}
+	return str
+}
+
 // ColumnDef encodes the following DDL definition:
 //     column_def:
 //       column_name type [NOT NULL] [options_def]
@@ -95,24 +139,43 @@ type ColumnDef struct {
 
 // Config controls how AST nodes are printed (aka unparsed).
 type Config struct {
-	Comments    bool // If true, print comments.
-	ProtectIds  bool // If true, table and col names are quoted using backticks (avoids reserved-word issue).
-	Tables      bool // If true, print tables
-	ForeignKeys bool // If true, print foreign key constraints.
+	Comments    bool   // If true, print comments.
+	ProtectIds  bool   // If true, table and col names are quoted using backticks (double quotes for PostgreSQL) to avoid reserved-word issues.
+	Tables      bool   // If true, print tables
+	ForeignKeys bool   // If true, print foreign key constraints.
+	SkipIndexes bool   // If true, don't print secondary indexes along with tables (see GetIndexDDL).
+	Dialect     string // Dialect of the printed DDL: GoogleSQL (the default, if empty) or PostgreSQL.
 }
 
 func (c Config) quote(s string) string {
 	if c.ProtectIds {
-		return "`" + s + "`"
+		// Names of tables in named schemas have the form "schema.table",
+		// and each part is quoted separately.
+		q := "`"
+		if c.pg() {
+			q = `"`
+		}
+		return q + strings.Join(strings.Split(s, "."), q+"."+q) + q
 	}
 	return s
 }
 
+func (c Config) pg() bool {
+	return c.Dialect == PostgreSQL
+}
+
+func (c Config) printType(ty Type) string {
+	if c.pg() {
+		return ty.PGPrintColumnDefType()
+	}
+	return ty.PrintColumnDefType()
+}
+
 // PrintColumnDef unparses ColumnDef and returns it as well as any ColumnDef
 // comment. These are returned as separate strings to support formatting
 // needs of PrintCreateTable.
 func (cd ColumnDef) PrintColumnDef(c Config) (string, string) {
-	s := fmt.Sprintf("%s %s", c.quote(cd.Name), cd.T.PrintColumnDefType())
+	s := fmt.Sprintf("%s %s", c.quote(cd.Name), c.printType(cd.T))
 	if cd.NotNull {
 		s += " NOT NULL"
 	}
@@ -163,20 +226,41 @@ func (k Foreignkey) PrintForeignKey(c Config) string {
 	return s + fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s (%s)", strings.Join(cols, ", "), c.quote(k.ReferTable), strings.Join(referCols, ", "))
 }
 
+// RowDeletionPolicy encodes the following DDL definition:
+//     row_deletion_policy:
+//       ROW DELETION POLICY ( OLDER_THAN ( timestamp_column, INTERVAL num_days DAY ) )
+type RowDeletionPolicy struct {
+	Col  string
+	Days int64
+}
+
+// PrintRowDeletionPolicy unparses the row deletion policy. The PostgreSQL
+// dialect calls it TTL.
+func (rdp RowDeletionPolicy) PrintRowDeletionPolicy(c Config) string {
+	if c.pg() {
+		return fmt.Sprintf("TTL INTERVAL '%d days' ON %s", rdp.Days, c.quote(rdp.Col))
+	}
+	return fmt.Sprintf("ROW DELETION POLICY (OLDER_THAN(%s, INTERVAL %d DAY))", c.quote(rdp.Col), rdp.Days)
+}
+
 // CreateTable encodes the following DDL definition:
-//     create_table: CREATE TABLE table_name ([column_def, ...] ) primary_key [, cluster]
+//     create_table: CREATE TABLE table_name ([column_def, ...] ) primary_key [, cluster] [, row_deletion_policy]
 type CreateTable struct {
-	Name     string
-	ColNames []string             // Provides names and order of columns
-	ColDefs  map[string]ColumnDef // Provides definition of columns (a map for simpler/faster lookup during type processing)
-	Pks      []IndexKey
-	Fks      []Foreignkey
-	Indexes  []CreateIndex
-	Parent   string //if not empty, this table will be interleaved
-	Comment  string
+	Name              string
+	ColNames          []string             // Provides names and order of columns
+	ColDefs           map[string]ColumnDef // Provides definition of columns (a map for simpler/faster lookup during type processing)
+	Pks               []IndexKey
+	Fks               []Foreignkey
+	Indexes           []CreateIndex
+	Parent            string //if not empty, this table will be interleaved
+	Comment           string
+	RowDeletionPolicy *RowDeletionPolicy // If not nil, rows are deleted once they are older than the policy's interval.
 }
 
-// PrintCreateTable unparses a CREATE TABLE statement.
+// PrintCreateTable unparses a CREATE TABLE statement. In the PostgreSQL
+// dialect, the primary key is part of the table body and the interleave
+// clause follows it without a comma:
+//     CREATE TABLE table_name ([column_def, ...], PRIMARY KEY (...)) [INTERLEAVE IN PARENT parent]
 func (ct CreateTable) PrintCreateTable(config Config) string {
 	var col []string
 	var colComment []string
@@ -184,7 +268,7 @@ func (ct CreateTable) PrintCreateTable(config Config) string {
 	for i, cn := range ct.ColNames {
 		s, c := ct.ColDefs[cn].PrintColumnDef(config)
 		s = "\n    " + s
-		if i < len(ct.ColNames)-1 {
+		if i < len(ct.ColNames)-1 || config.pg() {
 			s += ","
 		} else {
 			s += " "
@@ -208,9 +292,21 @@ func (ct CreateTable) PrintCreateTable(config Config) string {
 		tableComment = "--\n-- " + ct.Comment + "\n--\n"
 	}
 	var interleave string
+	if config.pg() {
+		if ct.Parent != "" {
+			interleave = " INTERLEAVE IN PARENT " + config.quote(ct.Parent)
+		}
+		if ct.RowDeletionPolicy != nil {
+			interleave += " " + ct.RowDeletionPolicy.PrintRowDeletionPolicy(config)
+		}
+		return fmt.Sprintf("%sCREATE TABLE %s (%s\n    PRIMARY KEY (%s)\n)%s", tableComment, config.quote(ct.Name), cols, strings.Join(keys, ", "), interleave)
+	}
 	if ct.Parent != "" {
 		interleave = ",\nINTERLEAVE IN PARENT " + config.quote(ct.Parent)
 	}
+	if ct.RowDeletionPolicy != nil {
+		interleave += ",\n" + ct.RowDeletionPolicy.PrintRowDeletionPolicy(config)
+	}
 	return fmt.Sprintf("%sCREATE TABLE %s (%s\n) PRIMARY KEY (%s)%s", tableComment, config.quote(ct.Name), cols, strings.Join(keys, ", "), interleave)
 }
 
@@ -272,6 +368,14 @@ func (s Schema) GetDDL(c Config) []string {
 	sort.Strings(tableNames)
 
 	if c.Tables {
+		// Named schemas must be created before the tables in them.
+		schemas := make(map[string]bool)
+		for _, t := range tableNames {
+			if i := strings.LastIndex(t, "."); i >= 0 && !schemas[t[:i]] {
+				schemas[t[:i]] = true
+				ddl = append(ddl, "CREATE SCHEMA "+c.quote(t[:i]))
+			}
+		}
 		tableQueue := tableNames
 		printed := make(map[string]bool)
 		for len(tableQueue) > 0 {
@@ -284,8 +388,10 @@ func (s Schema) GetDDL(c Config) []string {
 			// b) t is interleaved in another table and that table has already been printed.
 			if table.Parent == "" || printed[table.Parent] {
 				ddl = append(ddl, table.PrintCreateTable(c))
-				for _, index := range table.Indexes {
-					ddl = append(ddl, index.PrintCreateIndex(c))
+				if !c.SkipIndexes {
+					for _, index := range table.Indexes {
+						ddl = append(ddl, index.PrintCreateIndex(c))
+					}
 				}
 				printed[tableName] = true
 			} else {
@@ -315,6 +421,24 @@ func (s Schema) GetDDL(c Config) []string {
 	return ddl
 }
 
+// GetIndexDDL returns the CREATE INDEX statements of the secondary indexes
+// of all tables, in table order. It is used to build indexes separately from
+// their tables (see Config.SkipIndexes).
+func (s Schema) GetIndexDDL(c Config) []string {
+	var tableNames []string
+	for t := range s {
+		tableNames = append(tableNames, t)
+	}
+	sort.Strings(tableNames)
+	var ddl []string
+	for _, t := range tableNames {
+		for _, index := range s[t].Indexes {
+			ddl = append(ddl, index.PrintCreateIndex(c))
+		}
+	}
+	return ddl
+}
+
 // CheckInterleaved checks if schema contains interleaved tables.
 func (s Schema) CheckInterleaved() bool {
 	for _, table := range s {
@@ -41,6 +41,29 @@ func TestPrintScalarType(t *testing.T) {
 	}
 }
 
+func TestPGPrintScalarType(t *testing.T) {
+	tests := []struct {
+		in       Type
+		expected string
+	}{
+		{Type{Name: Bool}, "boolean"},
+		{Type{Name: Int64}, "bigint"},
+		{Type{Name: Float64}, "double precision"},
+		{Type{Name: String, Len: MaxLength}, "varchar"},
+		{Type{Name: String, Len: int64(42)}, "varchar(42)"},
+		{Type{Name: Bytes, Len: int64(42)}, "bytea"},
+		{Type{Name: Date}, "date"},
+		{Type{Name: Timestamp}, "timestamptz"},
+		{Type{Name: Numeric}, "numeric"},
+		{Type{Name: Json}, "jsonb"},
+		{Type{Name: Int64, IsArray: true}, "bigint[]"},
+		{Type{Name: String, Len: int64(42), IsArray: true}, "varchar(42)[]"},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.expected, tc.in.PGPrintColumnDefType())
+	}
+}
+
 func TestPrintColumnDef(t *testing.T) {
 	tests := []struct {
 		in         ColumnDef
@@ -88,6 +111,7 @@ func TestPrintCreateTable(t *testing.T) {
 		nil,
 		"",
 		"",
+		nil,
 	}
 	t2 := CreateTable{
 		"mytable",
@@ -98,6 +122,7 @@ func TestPrintCreateTable(t *testing.T) {
 		nil,
 		"parent",
 		"",
+		nil,
 	}
 	tests := []struct {
 		name       string
@@ -114,6 +139,28 @@ func TestPrintCreateTable(t *testing.T) {
 	}
 }
 
+func TestPrintCreateTable_PostgreSQL(t *testing.T) {
+	cds := make(map[string]ColumnDef)
+	cds["col1"] = ColumnDef{Name: "col1", T: Type{Name: Int64}, NotNull: true}
+	cds["col2"] = ColumnDef{Name: "col2", T: Type{Name: String, Len: MaxLength}, NotNull: false}
+	cds["col3"] = ColumnDef{Name: "col3", T: Type{Name: Timestamp, IsArray: true}, NotNull: false}
+	ct := CreateTable{Name: "mytable", ColNames: []string{"col1", "col2", "col3"}, ColDefs: cds, Pks: []IndexKey{{Col: "col1"}}}
+	tests := []struct {
+		name       string
+		protectIds bool
+		parent     string
+		expected   string
+	}{
+		{"no quote", false, "", "CREATE TABLE mytable (col1 bigint NOT NULL, col2 varchar, col3 timestamptz[], PRIMARY KEY (col1))"},
+		{"quote", true, "", `CREATE TABLE "mytable" ("col1" bigint NOT NULL, "col2" varchar, "col3" timestamptz[], PRIMARY KEY ("col1"))`},
+		{"interleaved", true, "sales.parent", `CREATE TABLE "mytable" ("col1" bigint NOT NULL, "col2" varchar, "col3" timestamptz[], PRIMARY KEY ("col1")) INTERLEAVE IN PARENT "sales"."parent"`},
+	}
+	for _, tc := range tests {
+		ct.Parent = tc.parent
+		assert.Equal(t, normalizeSpace(tc.expected), normalizeSpace(ct.PrintCreateTable(Config{ProtectIds: tc.protectIds, Dialect: PostgreSQL})), tc.name)
+	}
+}
+
 func TestPrintCreateIndex(t *testing.T) {
 	ci := []CreateIndex{
 		{
@@ -270,6 +317,43 @@ func TestGetDDL(t *testing.T) {
 		"ALTER TABLE table3 ADD CONSTRAINT fk3 FOREIGN KEY (c) REFERENCES ref_table3 (ref_c)",
 	}
 	assert.ElementsMatch(t, e3, tablesAndFks)
+
+	tablesWithoutIndexes := s.GetDDL(Config{Tables: true, SkipIndexes: true})
+	e4 := []string{
+		"CREATE TABLE table1 (\n    a INT64,\n    b INT64 \n) PRIMARY KEY (a)",
+		"CREATE TABLE table2 (\n    a INT64,\n    b INT64,\n    c INT64 \n) PRIMARY KEY (a)",
+		"CREATE TABLE table3 (\n    a INT64,\n    b INT64,\n    c INT64 \n) PRIMARY KEY (a, b),\nINTERLEAVE IN PARENT table1",
+	}
+	assert.ElementsMatch(t, e4, tablesWithoutIndexes)
+
+	e5 := []string{
+		"CREATE INDEX index1 ON table1 (b)",
+		"CREATE UNIQUE INDEX index2 ON table2 (b DESC, c)",
+	}
+	assert.Equal(t, e5, s.GetIndexDDL(Config{}))
+}
+
+func TestGetDDL_NamedSchemas(t *testing.T) {
+	s := NewSchema()
+	for _, name := range []string{"sales.orders", "sales.items", "users"} {
+		s[name] = CreateTable{
+			Name:     name,
+			ColNames: []string{"a"},
+			ColDefs:  map[string]ColumnDef{"a": {Name: "a", T: Type{Name: Int64}}},
+			Pks:      []IndexKey{{Col: "a"}},
+		}
+	}
+	ct := s["sales.orders"]
+	ct.Indexes = []CreateIndex{{Name: "sales.idx", Table: "sales.orders", Keys: []IndexKey{{Col: "a"}}}}
+	s["sales.orders"] = ct
+	e := []string{
+		"CREATE SCHEMA `sales`",
+		"CREATE TABLE `sales`.`items` (\n    `a` INT64 \n) PRIMARY KEY (`a`)",
+		"CREATE TABLE `sales`.`orders` (\n    `a` INT64 \n) PRIMARY KEY (`a`)",
+		"CREATE INDEX `sales`.`idx` ON `sales`.`orders` (`a`)",
+		"CREATE TABLE `users` (\n    `a` INT64 \n) PRIMARY KEY (`a`)",
+	}
+	assert.Equal(t, e, s.GetDDL(Config{Tables: true, ProtectIds: true}))
 }
 
 func normalizeSpace(s string) string {
@@ -0,0 +1,155 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ddl
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Value capture types of change streams.
+const (
+	OldAndNewValues string = "OLD_AND_NEW_VALUES"
+	NewValues       string = "NEW_VALUES"
+	NewRow          string = "NEW_ROW"
+)
+
+// ChangeStreamTable is a table watched by a change stream.
+type ChangeStreamTable struct {
+	Table string
+	Cols  []string // Watched columns. If empty, all columns are watched.
+}
+
+// ChangeStream encodes the following DDL definition:
+//
+//	create_change_stream:
+//	  CREATE CHANGE STREAM change_stream_name
+//	  [ FOR { table_name [ ( column_name [, ...] ) ] [, ...] | ALL } ]
+//	  [ OPTIONS ( retention_period = 'duration', value_capture_type = 'type' ) ]
+type ChangeStream struct {
+	Name             string
+	All              bool                // If true, the change stream watches all tables (Tables is ignored).
+	Tables           []ChangeStreamTable // Watched tables.
+	RetentionPeriod  string              // How long changes are kept e.g. "36h" or "7d". Empty means the Spanner default.
+	ValueCaptureType string              // OldAndNewValues, NewValues or NewRow. Empty means the Spanner default (OldAndNewValues).
+}
+
+// PrintCreateChangeStream unparses a CREATE CHANGE STREAM statement. The
+// PostgreSQL dialect uses WITH rather than OPTIONS.
+func (cs ChangeStream) PrintCreateChangeStream(c Config) string {
+	s := "CREATE CHANGE STREAM " + c.quote(cs.Name)
+	if cs.All {
+		s += " FOR ALL"
+	} else if len(cs.Tables) > 0 {
+		var tables []string
+		for _, t := range cs.Tables {
+			if len(t.Cols) == 0 {
+				tables = append(tables, c.quote(t.Table))
+				continue
+			}
+			var cols []string
+			for _, col := range t.Cols {
+				cols = append(cols, c.quote(col))
+			}
+			tables = append(tables, fmt.Sprintf("%s (%s)", c.quote(t.Table), strings.Join(cols, ", ")))
+		}
+		s += " FOR " + strings.Join(tables, ", ")
+	}
+	var opts []string
+	if cs.RetentionPeriod != "" {
+		opts = append(opts, fmt.Sprintf("retention_period = '%s'", cs.RetentionPeriod))
+	}
+	if cs.ValueCaptureType != "" {
+		opts = append(opts, fmt.Sprintf("value_capture_type = '%s'", cs.ValueCaptureType))
+	}
+	if len(opts) > 0 {
+		if c.pg() {
+			s += " WITH (" + strings.Join(opts, ", ") + ")"
+		} else {
+			s += " OPTIONS (" + strings.Join(opts, ", ") + ")"
+		}
+	}
+	return s
+}
+
+// GetChangeStreamDDL returns the CREATE CHANGE STREAM statements for
+// change streams l. Change streams aren't part of Schema, since they are
+// created separately, once data has been migrated, so that writing the
+// existing data doesn't flood them with changes.
+func GetChangeStreamDDL(l []ChangeStream, c Config) []string {
+	var ddl []string
+	for _, cs := range l {
+		ddl = append(ddl, cs.PrintCreateChangeStream(c))
+	}
+	return ddl
+}
+
+var retentionPeriodRe = regexp.MustCompile(`^[1-9][0-9]*[dhms]$`)
+
+// CheckChangeStreams checks that change streams l can be created for
+// schema s: names must be unique and not used by tables, watched tables and
+// columns must exist (key columns can't be listed, since they are always
+// watched), and options must be well formed.
+func (s Schema) CheckChangeStreams(l []ChangeStream) error {
+	var errs []string
+	names := make(map[string]bool)
+	for t := range s {
+		names[strings.ToLower(t)] = true
+	}
+	for _, cs := range l {
+		add := func(format string, args ...interface{}) {
+			errs = append(errs, fmt.Sprintf("change stream %s: ", cs.Name)+fmt.Sprintf(format, args...))
+		}
+		switch {
+		case cs.Name == "":
+			add("name is empty")
+		case names[strings.ToLower(cs.Name)]:
+			add("name is already used")
+		}
+		names[strings.ToLower(cs.Name)] = true
+		if cs.All && len(cs.Tables) > 0 {
+			add("can't watch all tables and a list of tables")
+		}
+		for _, t := range cs.Tables {
+			ct, ok := s[t.Table]
+			if !ok {
+				add("table %s doesn't exist", t.Table)
+				continue
+			}
+			for _, col := range t.Cols {
+				if _, ok := ct.ColDefs[col]; !ok {
+					add("column %s of table %s doesn't exist", col, t.Table)
+				} else if isKeyCol(ct, col) {
+					add("column %s of table %s is a key column, which is always watched", col, t.Table)
+				}
+			}
+		}
+		if cs.RetentionPeriod != "" && !retentionPeriodRe.MatchString(cs.RetentionPeriod) {
+			add("invalid retention period %q (expected e.g. \"36h\" or \"7d\")", cs.RetentionPeriod)
+		}
+		switch cs.ValueCaptureType {
+		case "", OldAndNewValues, NewValues, NewRow:
+		default:
+			add("invalid value capture type %q (expected %s, %s or %s)", cs.ValueCaptureType, OldAndNewValues, NewValues, NewRow)
+		}
+	}
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("invalid change streams: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
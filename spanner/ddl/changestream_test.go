@@ -0,0 +1,86 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ddl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintCreateChangeStream(t *testing.T) {
+	tests := []struct {
+		name     string
+		cs       ChangeStream
+		c        Config
+		expected string
+	}{
+		{"No tables", ChangeStream{Name: "cs"}, Config{}, "CREATE CHANGE STREAM cs"},
+		{"All tables", ChangeStream{Name: "cs", All: true}, Config{ProtectIds: true}, "CREATE CHANGE STREAM `cs` FOR ALL"},
+		{
+			"Tables and columns",
+			ChangeStream{Name: "cs", Tables: []ChangeStreamTable{{Table: "users"}, {Table: "sales.orders", Cols: []string{"total", "status"}}}},
+			Config{ProtectIds: true},
+			"CREATE CHANGE STREAM `cs` FOR `users`, `sales`.`orders` (`total`, `status`)",
+		},
+		{
+			"Options",
+			ChangeStream{Name: "cs", All: true, RetentionPeriod: "7d", ValueCaptureType: NewRow},
+			Config{},
+			"CREATE CHANGE STREAM cs FOR ALL OPTIONS (retention_period = '7d', value_capture_type = 'NEW_ROW')",
+		},
+		{
+			"PostgreSQL",
+			ChangeStream{Name: "cs", Tables: []ChangeStreamTable{{Table: "users"}}, RetentionPeriod: "36h"},
+			Config{ProtectIds: true, Dialect: PostgreSQL},
+			`CREATE CHANGE STREAM "cs" FOR "users" WITH (retention_period = '36h')`,
+		},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.expected, tc.cs.PrintCreateChangeStream(tc.c), tc.name)
+	}
+	assert.Equal(t, []string{"CREATE CHANGE STREAM a FOR ALL", "CREATE CHANGE STREAM b"},
+		GetChangeStreamDDL([]ChangeStream{{Name: "a", All: true}, {Name: "b"}}, Config{}))
+}
+
+func TestCheckChangeStreams(t *testing.T) {
+	s, err := ParseDDL("CREATE TABLE users (id INT64, name STRING(MAX)) PRIMARY KEY (id)")
+	assert.Nil(t, err)
+	tests := []struct {
+		name      string
+		l         []ChangeStream
+		errorWant bool
+	}{
+		{"No change streams", nil, false},
+		{"Valid", []ChangeStream{{Name: "all_changes", All: true, RetentionPeriod: "7d", ValueCaptureType: NewValues}, {Name: "names", Tables: []ChangeStreamTable{{Table: "users", Cols: []string{"name"}}}}}, false},
+		{"Empty name", []ChangeStream{{All: true}}, true},
+		{"Name used by table", []ChangeStream{{Name: "Users", All: true}}, true},
+		{"Duplicate name", []ChangeStream{{Name: "cs", All: true}, {Name: "cs"}}, true},
+		{"All and tables", []ChangeStream{{Name: "cs", All: true, Tables: []ChangeStreamTable{{Table: "users"}}}}, true},
+		{"Unknown table", []ChangeStream{{Name: "cs", Tables: []ChangeStreamTable{{Table: "orders"}}}}, true},
+		{"Unknown column", []ChangeStream{{Name: "cs", Tables: []ChangeStreamTable{{Table: "users", Cols: []string{"email"}}}}}, true},
+		{"Key column", []ChangeStream{{Name: "cs", Tables: []ChangeStreamTable{{Table: "users", Cols: []string{"id"}}}}}, true},
+		{"Bad retention period", []ChangeStream{{Name: "cs", All: true, RetentionPeriod: "1 week"}}, true},
+		{"Bad value capture type", []ChangeStream{{Name: "cs", All: true, ValueCaptureType: "OLD_VALUES"}}, true},
+	}
+	for _, tc := range tests {
+		err := s.CheckChangeStreams(tc.l)
+		if tc.errorWant {
+			assert.NotNil(t, err, tc.name)
+		} else {
+			assert.Nil(t, err, tc.name)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ddl
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DatabaseOptions encodes the following DDL definition:
+//
+//	alter_database:
+//	  ALTER DATABASE database_id SET OPTIONS (
+//	    version_retention_period = 'duration',
+//	    default_leader = 'region',
+//	    optimizer_version = n )
+//
+// Unset options are omitted.
+type DatabaseOptions struct {
+	Database               string // Name of the database. Options can't be printed without it.
+	VersionRetentionPeriod string // How long old versions of data are kept e.g. "3d". Empty means unset.
+	DefaultLeader          string // Default leader region e.g. "us-east1". Empty means unset.
+	OptimizerVersion       int64  // Query optimizer version. 0 means unset.
+}
+
+// Empty returns true if no option is set.
+func (o DatabaseOptions) Empty() bool {
+	return o.VersionRetentionPeriod == "" && o.DefaultLeader == "" && o.OptimizerVersion == 0
+}
+
+// PrintAlterDatabase unparses the statements that set the options. The
+// GoogleSQL dialect uses a single ALTER DATABASE ... SET OPTIONS statement,
+// while the PostgreSQL dialect sets each option with its own ALTER DATABASE
+// ... SET statement. It returns nil if no option is set, or if the database
+// name is unknown.
+func (o DatabaseOptions) PrintAlterDatabase(c Config) []string {
+	if o.Database == "" {
+		return nil
+	}
+	var opts []string
+	if o.VersionRetentionPeriod != "" {
+		opts = append(opts, fmt.Sprintf("version_retention_period = '%s'", o.VersionRetentionPeriod))
+	}
+	if o.DefaultLeader != "" {
+		opts = append(opts, fmt.Sprintf("default_leader = '%s'", o.DefaultLeader))
+	}
+	if o.OptimizerVersion != 0 {
+		opts = append(opts, fmt.Sprintf("optimizer_version = %d", o.OptimizerVersion))
+	}
+	if len(opts) == 0 {
+		return nil
+	}
+	if c.pg() {
+		var l []string
+		for _, opt := range opts {
+			l = append(l, fmt.Sprintf("ALTER DATABASE %s SET spanner.%s", c.quote(o.Database), opt))
+		}
+		return l
+	}
+	return []string{fmt.Sprintf("ALTER DATABASE %s SET OPTIONS (%s)", c.quote(o.Database), strings.Join(opts, ", "))}
+}
+
+var durationRe = regexp.MustCompile(`^([0-9]+)([dhms])$`)
+
+// Spanner's bounds on version_retention_period.
+const (
+	minVersionRetentionPeriod = time.Hour
+	maxVersionRetentionPeriod = 7 * 24 * time.Hour
+)
+
+// Check checks that the options are well formed, and that the version
+// retention period is within Spanner's bounds (one hour to seven days).
+func (o DatabaseOptions) Check() error {
+	if o.VersionRetentionPeriod != "" {
+		m := durationRe.FindStringSubmatch(o.VersionRetentionPeriod)
+		if m == nil {
+			return fmt.Errorf("invalid version_retention_period %q (expected e.g. \"3d\" or \"36h\")", o.VersionRetentionPeriod)
+		}
+		n, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version_retention_period %q: %v", o.VersionRetentionPeriod, err)
+		}
+		d := map[string]time.Duration{"d": 24 * time.Hour, "h": time.Hour, "m": time.Minute, "s": time.Second}[m[2]]
+		if p := time.Duration(n) * d; n > int64(maxVersionRetentionPeriod/d) || p < minVersionRetentionPeriod || p > maxVersionRetentionPeriod {
+			return fmt.Errorf("version_retention_period %s must be between 1h and 7d", o.VersionRetentionPeriod)
+		}
+	}
+	if strings.ContainsAny(o.DefaultLeader, "'\\") {
+		return fmt.Errorf("invalid default_leader %q", o.DefaultLeader)
+	}
+	if o.OptimizerVersion < 0 {
+		return fmt.Errorf("invalid optimizer_version %d", o.OptimizerVersion)
+	}
+	return nil
+}
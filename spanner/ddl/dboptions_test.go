@@ -0,0 +1,85 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ddl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintAlterDatabase(t *testing.T) {
+	opts := DatabaseOptions{Database: "db", VersionRetentionPeriod: "3d", DefaultLeader: "us-east1", OptimizerVersion: 4}
+	tests := []struct {
+		name     string
+		opts     DatabaseOptions
+		c        Config
+		expected []string
+	}{
+		{"No options", DatabaseOptions{Database: "db"}, Config{}, nil},
+		{"No database name", DatabaseOptions{OptimizerVersion: 4}, Config{}, nil},
+		{
+			"GoogleSQL",
+			opts,
+			Config{ProtectIds: true},
+			[]string{"ALTER DATABASE `db` SET OPTIONS (version_retention_period = '3d', default_leader = 'us-east1', optimizer_version = 4)"},
+		},
+		{
+			"One option",
+			DatabaseOptions{Database: "db", OptimizerVersion: 4},
+			Config{},
+			[]string{"ALTER DATABASE db SET OPTIONS (optimizer_version = 4)"},
+		},
+		{
+			"PostgreSQL",
+			opts,
+			Config{ProtectIds: true, Dialect: PostgreSQL},
+			[]string{
+				`ALTER DATABASE "db" SET spanner.version_retention_period = '3d'`,
+				`ALTER DATABASE "db" SET spanner.default_leader = 'us-east1'`,
+				`ALTER DATABASE "db" SET spanner.optimizer_version = 4`,
+			},
+		},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.expected, tc.opts.PrintAlterDatabase(tc.c), tc.name)
+	}
+}
+
+func TestDatabaseOptionsCheck(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      DatabaseOptions
+		errorWant bool
+	}{
+		{"No options", DatabaseOptions{}, false},
+		{"Valid", DatabaseOptions{VersionRetentionPeriod: "7d", DefaultLeader: "us-east1", OptimizerVersion: 3}, false},
+		{"Retention in minutes", DatabaseOptions{VersionRetentionPeriod: "90m"}, false},
+		{"Bad retention period", DatabaseOptions{VersionRetentionPeriod: "1 week"}, true},
+		{"Retention too short", DatabaseOptions{VersionRetentionPeriod: "59m"}, true},
+		{"Retention too long", DatabaseOptions{VersionRetentionPeriod: "8d"}, true},
+		{"Retention overflow", DatabaseOptions{VersionRetentionPeriod: "99999999999999999d"}, true},
+		{"Quote in leader", DatabaseOptions{DefaultLeader: "us'"}, true},
+		{"Negative optimizer version", DatabaseOptions{OptimizerVersion: -1}, true},
+	}
+	for _, tc := range tests {
+		err := tc.opts.Check()
+		if tc.errorWant {
+			assert.NotNil(t, err, tc.name)
+		} else {
+			assert.Nil(t, err, tc.name)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ddl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SchemaDiff describes how an existing Spanner schema differs from a
+// target schema.
+type SchemaDiff struct {
+	Differences []string // Human readable description of each difference.
+	Statements  []string // DDL statements that update the existing schema towards the target schema.
+}
+
+// Diff compares schema s with existing schema e. Names are compared
+// case-insensitively, as in Spanner. Statements only add to or alter the
+// existing schema: tables and columns that aren't in s are reported but
+// never dropped, and differences that Spanner can't apply in place (primary
+// keys, interleaving and key column types) are only reported. Indexes and
+// foreign keys that differ are dropped and re-created. Row deletion policies
+// are added or replaced, but never dropped.
+func (s Schema) Diff(e Schema) SchemaDiff {
+	c := Config{ProtectIds: true}
+	var d SchemaDiff
+	var drops, columns, policies, indexes, fks []string
+	missing := NewSchema()
+	for _, t := range sortedTables(s) {
+		ct := s[t]
+		et, ok := e.lookup(t)
+		if !ok {
+			d.add("table %s is missing", t)
+			missing[t] = ct
+			for _, fk := range ct.Fks {
+				fks = append(fks, fk.PrintForeignKeyAlterTable(c, t))
+			}
+			continue
+		}
+		for _, col := range ct.ColNames {
+			cd := ct.ColDefs[col]
+			ecd, ok := et.lookupCol(col)
+			if !ok {
+				d.add("column %s.%s is missing", t, col)
+				def, _ := cd.PrintColumnDef(c)
+				columns = append(columns, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", c.quote(t), def))
+				continue
+			}
+			if cd.T == ecd.T && cd.NotNull == ecd.NotNull {
+				continue
+			}
+			if isKeyCol(ct, col) {
+				d.add("key column %s.%s is %s in database, %s in schema (key columns can't be altered)", t, col, printCol(ecd), printCol(cd))
+				continue
+			}
+			d.add("column %s.%s is %s in database, %s in schema", t, col, printCol(ecd), printCol(cd))
+			def, _ := cd.PrintColumnDef(c)
+			columns = append(columns, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s", c.quote(t), def))
+		}
+		for _, col := range et.ColNames {
+			if _, ok := ct.lookupCol(col); !ok {
+				d.add("column %s.%s is not in schema", t, col)
+			}
+		}
+		if !keysEqual(ct.Pks, et.Pks) {
+			d.add("primary key of table %s is (%s) in database, (%s) in schema", t, printKeys(et.Pks), printKeys(ct.Pks))
+		}
+		if !strings.EqualFold(ct.Parent, et.Parent) {
+			d.add("table %s is interleaved in %q in database, %q in schema", t, et.Parent, ct.Parent)
+		}
+		rdp, erdp := ct.RowDeletionPolicy, et.RowDeletionPolicy
+		switch {
+		case rdp == nil && erdp == nil:
+		case rdp == nil:
+			d.add("row deletion policy of table %s is not in schema", t)
+		case erdp == nil:
+			d.add("row deletion policy of table %s is missing", t)
+			policies = append(policies, fmt.Sprintf("ALTER TABLE %s ADD %s", c.quote(t), rdp.PrintRowDeletionPolicy(c)))
+		case !strings.EqualFold(rdp.Col, erdp.Col) || rdp.Days != erdp.Days:
+			d.add("row deletion policy of table %s is %q in database, %q in schema", t, erdp.PrintRowDeletionPolicy(Config{}), rdp.PrintRowDeletionPolicy(Config{}))
+			policies = append(policies, fmt.Sprintf("ALTER TABLE %s REPLACE %s", c.quote(t), rdp.PrintRowDeletionPolicy(c)))
+		}
+		for _, ci := range ct.Indexes {
+			eci, ok := et.lookupIndex(ci.Name)
+			switch {
+			case !ok:
+				d.add("index %s is missing", ci.Name)
+			case ci.Unique != eci.Unique || !keysEqual(ci.Keys, eci.Keys):
+				d.add("index %s is %q in database, %q in schema", ci.Name, eci.PrintCreateIndex(Config{}), ci.PrintCreateIndex(Config{}))
+				drops = append(drops, "DROP INDEX "+c.quote(eci.Name))
+			default:
+				continue
+			}
+			indexes = append(indexes, ci.PrintCreateIndex(c))
+		}
+		for _, eci := range et.Indexes {
+			if _, ok := ct.lookupIndex(eci.Name); !ok {
+				d.add("index %s is not in schema", eci.Name)
+			}
+		}
+		for _, fk := range ct.Fks {
+			efk, ok := et.lookupFk(fk)
+			switch {
+			case !ok:
+				d.add("foreign key %s of table %s is missing", printFk(fk), t)
+			case !fksEqual(fk, efk):
+				d.add("foreign key %s of table %s is %q in database, %q in schema", fk.Name, t, efk.PrintForeignKey(Config{}), fk.PrintForeignKey(Config{}))
+				drops = append(drops, fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s", c.quote(t), c.quote(efk.Name)))
+			default:
+				continue
+			}
+			fks = append(fks, fk.PrintForeignKeyAlterTable(c, t))
+		}
+		for _, efk := range et.Fks {
+			if _, ok := ct.lookupFk(efk); !ok {
+				d.add("foreign key %s of table %s is not in schema", printFk(efk), t)
+			}
+		}
+	}
+	for _, t := range sortedTables(e) {
+		if _, ok := s.lookup(t); !ok {
+			d.add("table %s is not in schema", t)
+		}
+	}
+	d.Statements = append(d.Statements, drops...)
+	// Named schemas that already exist must not be created again.
+	schemas := make(map[string]bool)
+	for t := range e {
+		if i := strings.LastIndex(t, "."); i >= 0 {
+			schemas[c.quote(t[:i])] = true
+		}
+	}
+	for _, stmt := range missing.GetDDL(Config{ProtectIds: true, Tables: true}) {
+		if !schemas[strings.TrimPrefix(stmt, "CREATE SCHEMA ")] {
+			d.Statements = append(d.Statements, stmt)
+		}
+	}
+	d.Statements = append(d.Statements, columns...)
+	d.Statements = append(d.Statements, policies...)
+	d.Statements = append(d.Statements, indexes...)
+	d.Statements = append(d.Statements, fks...)
+	return d
+}
+
+// Empty returns true if there are no differences.
+func (d SchemaDiff) Empty() bool {
+	return len(d.Differences) == 0
+}
+
+func (d *SchemaDiff) add(format string, args ...interface{}) {
+	d.Differences = append(d.Differences, fmt.Sprintf(format, args...))
+}
+
+func sortedTables(s Schema) []string {
+	var l []string
+	for t := range s {
+		l = append(l, t)
+	}
+	sort.Strings(l)
+	return l
+}
+
+func (s Schema) lookup(t string) (CreateTable, bool) {
+	if ct, ok := s[t]; ok {
+		return ct, true
+	}
+	for name, ct := range s {
+		if strings.EqualFold(name, t) {
+			return ct, true
+		}
+	}
+	return CreateTable{}, false
+}
+
+func (ct CreateTable) lookupCol(col string) (ColumnDef, bool) {
+	for name, cd := range ct.ColDefs {
+		if strings.EqualFold(name, col) {
+			return cd, true
+		}
+	}
+	return ColumnDef{}, false
+}
+
+func (ct CreateTable) lookupIndex(name string) (CreateIndex, bool) {
+	for _, ci := range ct.Indexes {
+		if strings.EqualFold(ci.Name, name) {
+			return ci, true
+		}
+	}
+	return CreateIndex{}, false
+}
+
+// lookupFk finds the foreign key of ct that matches fk: by name if both
+// are named, and otherwise by their columns and referenced table and columns.
+func (ct CreateTable) lookupFk(fk Foreignkey) (Foreignkey, bool) {
+	for _, efk := range ct.Fks {
+		if fk.Name != "" && efk.Name != "" {
+			if strings.EqualFold(fk.Name, efk.Name) {
+				return efk, true
+			}
+		} else if fksEqual(fk, efk) {
+			return efk, true
+		}
+	}
+	return Foreignkey{}, false
+}
+
+func isKeyCol(ct CreateTable, col string) bool {
+	for _, k := range ct.Pks {
+		if strings.EqualFold(k.Col, col) {
+			return true
+		}
+	}
+	return false
+}
+
+func keysEqual(a, b []IndexKey) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !strings.EqualFold(a[i].Col, b[i].Col) || a[i].Desc != b[i].Desc {
+			return false
+		}
+	}
+	return true
+}
+
+func fksEqual(a, b Foreignkey) bool {
+	return strings.EqualFold(a.ReferTable, b.ReferTable) && namesEqual(a.Columns, b.Columns) && namesEqual(a.ReferColumns, b.ReferColumns)
+}
+
+func namesEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !strings.EqualFold(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func printCol(cd ColumnDef) string {
+	s := cd.T.PrintColumnDefType()
+	if cd.NotNull {
+		s += " NOT NULL"
+	}
+	return s
+}
+
+func printKeys(keys []IndexKey) string {
+	var l []string
+	for _, k := range keys {
+		l = append(l, k.PrintIndexKey(Config{}))
+	}
+	return strings.Join(l, ", ")
+}
+
+func printFk(fk Foreignkey) string {
+	if fk.Name != "" {
+		return fk.Name
+	}
+	return fmt.Sprintf("(%s) REFERENCES %s (%s)", strings.Join(fk.Columns, ", "), fk.ReferTable, strings.Join(fk.ReferColumns, ", "))
+}
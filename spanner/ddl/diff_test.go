@@ -0,0 +1,111 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ddl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	parse := func(s string) Schema {
+		schema, err := ParseDDL(s)
+		assert.Nil(t, err)
+		return schema
+	}
+	target := parse(`
+CREATE TABLE users (
+    id INT64 NOT NULL,
+    name STRING(100) NOT NULL,
+    email STRING(MAX),
+    age INT64,
+) PRIMARY KEY (id);
+CREATE UNIQUE INDEX idx_name ON users (name);
+CREATE INDEX idx_email ON users (email);
+CREATE INDEX idx_age ON users (age);
+CREATE TABLE orders (
+    user_id INT64 NOT NULL,
+    id STRING(36) NOT NULL,
+) PRIMARY KEY (user_id, id),
+INTERLEAVE IN PARENT users;
+CREATE SCHEMA sales;
+CREATE TABLE sales.items (id INT64, order_id STRING(36), created TIMESTAMP) PRIMARY KEY (id),
+ROW DELETION POLICY (OLDER_THAN(created, INTERVAL 30 DAY));
+CREATE TABLE sales.returns (id INT64) PRIMARY KEY (id);
+ALTER TABLE orders ADD CONSTRAINT fk_users FOREIGN KEY (user_id) REFERENCES users (id);
+ALTER TABLE sales.items ADD CONSTRAINT fk_orders FOREIGN KEY (order_id) REFERENCES orders (id);
+ALTER TABLE sales.items ADD FOREIGN KEY (id) REFERENCES users (id);
+`)
+	existing := parse(`
+CREATE TABLE Users (
+    id INT64 NOT NULL,
+    name STRING(50),
+    legacy BOOL,
+) PRIMARY KEY (id);
+CREATE INDEX idx_name ON Users (name);
+CREATE INDEX idx_email ON Users (email);
+CREATE INDEX idx_old ON Users (legacy);
+CREATE TABLE orders (
+    user_id STRING(MAX) NOT NULL,
+    id STRING(36) NOT NULL,
+    CONSTRAINT fk_users FOREIGN KEY (user_id) REFERENCES users (name),
+) PRIMARY KEY (id);
+CREATE TABLE sales.items (id INT64, order_id STRING(36), created TIMESTAMP) PRIMARY KEY (id),
+ROW DELETION POLICY (OLDER_THAN(created, INTERVAL 90 DAY));
+CREATE TABLE audit (id INT64) PRIMARY KEY (id);
+ALTER TABLE sales.items ADD CONSTRAINT FK_auto FOREIGN KEY (id) REFERENCES users (id);
+`)
+	d := target.Diff(existing)
+	assert.Equal(t, []string{
+		"key column orders.user_id is STRING(MAX) NOT NULL in database, INT64 NOT NULL in schema (key columns can't be altered)",
+		"primary key of table orders is (id) in database, (user_id, id) in schema",
+		`table orders is interleaved in "" in database, "users" in schema`,
+		`foreign key fk_users of table orders is "CONSTRAINT fk_users FOREIGN KEY (user_id) REFERENCES users (name)" in database, "CONSTRAINT fk_users FOREIGN KEY (user_id) REFERENCES users (id)" in schema`,
+		`row deletion policy of table sales.items is "ROW DELETION POLICY (OLDER_THAN(created, INTERVAL 90 DAY))" in database, "ROW DELETION POLICY (OLDER_THAN(created, INTERVAL 30 DAY))" in schema`,
+		"foreign key fk_orders of table sales.items is missing",
+		"table sales.returns is missing",
+		"column users.name is STRING(50) in database, STRING(100) NOT NULL in schema",
+		"column users.email is missing",
+		"column users.age is missing",
+		"column users.legacy is not in schema",
+		`index idx_name is "CREATE INDEX idx_name ON Users (name)" in database, "CREATE UNIQUE INDEX idx_name ON users (name)" in schema`,
+		"index idx_age is missing",
+		"index idx_old is not in schema",
+		"table audit is not in schema",
+	}, d.Differences)
+	assert.Equal(t, []string{
+		"ALTER TABLE `orders` DROP CONSTRAINT `fk_users`",
+		"DROP INDEX `idx_name`",
+		"CREATE TABLE `sales`.`returns` (\n    `id` INT64 \n) PRIMARY KEY (`id`)",
+		"ALTER TABLE `users` ALTER COLUMN `name` STRING(100) NOT NULL",
+		"ALTER TABLE `users` ADD COLUMN `email` STRING(MAX)",
+		"ALTER TABLE `users` ADD COLUMN `age` INT64",
+		"ALTER TABLE `sales`.`items` REPLACE ROW DELETION POLICY (OLDER_THAN(`created`, INTERVAL 30 DAY))",
+		"CREATE UNIQUE INDEX `idx_name` ON `users` (`name`)",
+		"CREATE INDEX `idx_age` ON `users` (`age`)",
+		"ALTER TABLE `orders` ADD CONSTRAINT `fk_users` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`)",
+		"ALTER TABLE `sales`.`items` ADD CONSTRAINT `fk_orders` FOREIGN KEY (`order_id`) REFERENCES `orders` (`id`)",
+	}, d.Statements)
+	assert.False(t, d.Empty())
+
+	d = target.Diff(target)
+	assert.True(t, d.Empty())
+	assert.Empty(t, d.Statements)
+
+	d = target.Diff(NewSchema())
+	assert.Equal(t, []string{"table orders is missing", "table sales.items is missing", "table sales.returns is missing", "table users is missing"}, d.Differences)
+	assert.Equal(t, len(target.GetDDL(Config{Tables: true, ForeignKeys: true})), len(d.Statements))
+}
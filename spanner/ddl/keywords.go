@@ -0,0 +1,66 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ddl
+
+import "strings"
+
+// reservedKeywords are the reserved keywords of Spanner's GoogleSQL
+// dialect. Identifiers that are reserved keywords must be quoted with
+// backticks.
+var reservedKeywords = map[string]bool{}
+
+// pgReservedKeywords are the reserved keywords of Spanner's PostgreSQL
+// dialect. Identifiers that are reserved keywords must be quoted with
+// double quotes.
+var pgReservedKeywords = map[string]bool{}
+
+func init() {
+	for _, k := range strings.Fields(`ALL AND ANY ARRAY AS ASC ASSERT_ROWS_MODIFIED AT
+		BETWEEN BY CASE CAST COLLATE CONTAINS CREATE CROSS CUBE CURRENT DEFAULT
+		DEFINE DESC DISTINCT ELSE END ENUM ESCAPE EXCEPT EXCLUDE EXISTS EXTRACT
+		FALSE FETCH FOLLOWING FOR FROM FULL GROUP GROUPING GROUPS HASH HAVING IF
+		IGNORE IN INNER INTERSECT INTERVAL INTO IS JOIN LATERAL LEFT LIKE LIMIT
+		LOOKUP MERGE NATURAL NEW NO NOT NULL NULLS OF ON OR ORDER OUTER OVER
+		PARTITION PRECEDING PROTO RANGE RECURSIVE RESPECT RIGHT ROLLUP ROWS SELECT
+		SET SOME STRUCT TABLESAMPLE THEN TO TREAT TRUE UNBOUNDED UNION UNNEST USING
+		WHEN WHERE WINDOW WITH WITHIN`) {
+		reservedKeywords[k] = true
+	}
+	for _, k := range strings.Fields(`ALL ANALYSE ANALYZE AND ANY ARRAY AS ASC
+		ASYMMETRIC AUTHORIZATION BINARY BOTH CASE CAST CHECK COLLATE COLLATION
+		COLUMN CONCURRENTLY CONSTRAINT CREATE CROSS CURRENT_CATALOG CURRENT_DATE
+		CURRENT_ROLE CURRENT_SCHEMA CURRENT_TIME CURRENT_TIMESTAMP CURRENT_USER
+		DEFAULT DEFERRABLE DESC DISTINCT DO ELSE END EXCEPT FALSE FETCH FOR
+		FOREIGN FREEZE FROM FULL GRANT GROUP HAVING ILIKE IN INITIALLY INNER
+		INTERSECT INTO IS ISNULL JOIN LATERAL LEADING LEFT LIKE LIMIT LOCALTIME
+		LOCALTIMESTAMP NATURAL NOT NOTNULL NULL OFFSET ON ONLY OR ORDER OUTER
+		OVERLAPS PLACING PRIMARY REFERENCES RETURNING RIGHT SELECT SESSION_USER
+		SIMILAR SOME SYMMETRIC TABLE TABLESAMPLE THEN TO TRAILING TRUE UNION
+		UNIQUE USER USING VARIADIC VERBOSE WHEN WHERE WINDOW WITH`) {
+		pgReservedKeywords[k] = true
+	}
+}
+
+// IsReservedKeyword returns true if identifier s is a reserved keyword of
+// Spanner's GoogleSQL dialect (reserved keywords are case insensitive).
+func IsReservedKeyword(s string) bool {
+	return reservedKeywords[strings.ToUpper(s)]
+}
+
+// IsPGReservedKeyword returns true if identifier s is a reserved keyword of
+// Spanner's PostgreSQL dialect (reserved keywords are case insensitive).
+func IsPGReservedKeyword(s string) bool {
+	return pgReservedKeywords[strings.ToUpper(s)]
+}
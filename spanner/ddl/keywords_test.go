@@ -0,0 +1,35 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ddl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsReservedKeyword(t *testing.T) {
+	assert.True(t, IsReservedKeyword("select"))
+	assert.True(t, IsReservedKeyword("Order"))
+	assert.False(t, IsReservedKeyword("orders"))
+	assert.False(t, IsReservedKeyword("user"))
+}
+
+func TestIsPGReservedKeyword(t *testing.T) {
+	assert.True(t, IsPGReservedKeyword("select"))
+	assert.True(t, IsPGReservedKeyword("User"))
+	assert.False(t, IsPGReservedKeyword("struct"))
+	assert.False(t, IsPGReservedKeyword("users"))
+}
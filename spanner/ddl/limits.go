@@ -0,0 +1,168 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ddl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Spanner schema limits. See https://cloud.google.com/spanner/quotas.
+const (
+	MaxIdentifierLength = 128
+	MaxColumnsPerTable  = 1024
+	MaxKeyColumns       = 16
+	MaxKeySize          = 8192 // Bytes.
+	MaxIndexesPerTable  = 128
+	MaxInterleaveDepth  = 7
+	MaxStringLength     = 2621440  // Characters.
+	MaxBytesLength      = 10485760 // Bytes.
+)
+
+// keySizes gives the size in bytes of key columns of fixed size types.
+var keySizes = map[string]int64{Bool: 1, Date: 4, Float64: 8, Int64: 8, Numeric: 22, Timestamp: 12}
+
+// LimitViolation describes part of a schema that violates a Spanner limit.
+type LimitViolation struct {
+	Table string // Spanner table.
+	Col   string // Spanner column, if the violation is specific to one column.
+	Msg   string
+}
+
+// CheckLimits checks schema s against Spanner's schema limits, so that
+// problems are found before the schema is sent to Spanner. Reserved
+// keywords are reported even though HarbourBridge quotes identifiers,
+// because they must also be quoted in every query. The size of keys is
+// estimated using the declared length of STRING and BYTES columns (a lower
+// bound for non-ASCII strings); keys with STRING(MAX) or BYTES(MAX) columns
+// can't be checked.
+func (s Schema) CheckLimits() []LimitViolation {
+	var l []LimitViolation
+	for _, t := range sortedTables(s) {
+		ct := s[t]
+		add := func(col, format string, args ...interface{}) {
+			l = append(l, LimitViolation{Table: t, Col: col, Msg: fmt.Sprintf(format, args...)})
+		}
+		for _, part := range strings.Split(t, ".") {
+			checkIdentifier(part, "table name", func(format string, args ...interface{}) { add("", format, args...) })
+		}
+		if len(ct.ColNames) > MaxColumnsPerTable {
+			add("", "table has %d columns, more than the limit of %d", len(ct.ColNames), MaxColumnsPerTable)
+		}
+		for _, col := range ct.ColNames {
+			cd := ct.ColDefs[col]
+			checkIdentifier(col, "column name", func(format string, args ...interface{}) { add(col, format, args...) })
+			switch {
+			case cd.T.Name == String && cd.T.Len != MaxLength && cd.T.Len > MaxStringLength:
+				add(col, "STRING length %d is more than the limit of %d", cd.T.Len, MaxStringLength)
+			case cd.T.Name == Bytes && cd.T.Len != MaxLength && cd.T.Len > MaxBytesLength:
+				add(col, "BYTES length %d is more than the limit of %d", cd.T.Len, MaxBytesLength)
+			}
+		}
+		checkKey(ct, ct.Pks, "primary key", func(format string, args ...interface{}) { add("", format, args...) })
+		if len(ct.Indexes) > MaxIndexesPerTable {
+			add("", "table has %d indexes, more than the limit of %d", len(ct.Indexes), MaxIndexesPerTable)
+		}
+		for _, ci := range ct.Indexes {
+			_, name := splitName(ci.Name)
+			checkIdentifier(name, "index name", func(format string, args ...interface{}) { add("", format, args...) })
+			checkKey(ct, ci.Keys, "index "+ci.Name, func(format string, args ...interface{}) { add("", format, args...) })
+		}
+		if depth := s.interleaveDepth(t); depth > MaxInterleaveDepth {
+			add("", "table is interleaved %d levels deep, more than the limit of %d", depth, MaxInterleaveDepth)
+		}
+		for _, fk := range ct.Fks {
+			if fk.Name != "" {
+				_, name := splitName(fk.Name)
+				checkIdentifier(name, "foreign key name", func(format string, args ...interface{}) { add("", format, args...) })
+			}
+			s.checkForeignKey(ct, fk, add)
+		}
+	}
+	return l
+}
+
+func splitName(s string) (string, string) {
+	i := strings.LastIndex(s, ".")
+	if i < 0 {
+		return "", s
+	}
+	return s[:i], s[i+1:]
+}
+
+func checkIdentifier(id, what string, add func(format string, args ...interface{})) {
+	if len(id) > MaxIdentifierLength {
+		add("%s %s is %d characters long, more than the limit of %d", what, id, len(id), MaxIdentifierLength)
+	}
+	if IsReservedKeyword(id) {
+		add("%s %s is a reserved keyword, so it must be quoted with backticks in queries", what, id)
+	}
+}
+
+// checkKey checks the primary key or index key keys of table ct.
+func checkKey(ct CreateTable, keys []IndexKey, what string, add func(format string, args ...interface{})) {
+	if len(keys) > MaxKeyColumns {
+		add("%s has %d columns, more than the limit of %d", what, len(keys), MaxKeyColumns)
+	}
+	size := int64(0)
+	for _, k := range keys {
+		t := ct.ColDefs[k.Col].T
+		switch {
+		case t.Name == String || t.Name == Bytes:
+			if t.Len == MaxLength {
+				return
+			}
+			size += t.Len
+		default:
+			size += keySizes[t.Name]
+		}
+	}
+	if size > MaxKeySize {
+		add("%s is at least %d bytes, more than the limit of %d", what, size, MaxKeySize)
+	}
+}
+
+// interleaveDepth returns the number of tables in the chain of parents of
+// table t, including t itself.
+func (s Schema) interleaveDepth(t string) int {
+	depth := 1
+	for p := s[t].Parent; p != "" && depth <= len(s); p = s[p].Parent {
+		depth++
+	}
+	return depth
+}
+
+func (s Schema) checkForeignKey(ct CreateTable, fk Foreignkey, add func(col, format string, args ...interface{})) {
+	name := printFk(fk)
+	refer, ok := s[fk.ReferTable]
+	if !ok {
+		add("", "foreign key %s references table %s, which doesn't exist", name, fk.ReferTable)
+		return
+	}
+	for i, col := range fk.Columns {
+		if i >= len(fk.ReferColumns) {
+			break
+		}
+		cd, ok1 := ct.ColDefs[col]
+		rcd, ok2 := refer.ColDefs[fk.ReferColumns[i]]
+		if !ok1 || !ok2 {
+			add("", "foreign key %s uses column %s or %s.%s, which doesn't exist", name, col, fk.ReferTable, fk.ReferColumns[i])
+			continue
+		}
+		if cd.T.Name != rcd.T.Name || cd.T.IsArray != rcd.T.IsArray {
+			add(col, "foreign key %s column has type %s, but referenced column %s.%s has type %s", name, cd.T.PrintColumnDefType(), fk.ReferTable, rcd.Name, rcd.T.PrintColumnDefType())
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ddl
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckLimits(t *testing.T) {
+	long := strings.Repeat("x", MaxIdentifierLength+1)
+	s, err := ParseDDL(`
+CREATE TABLE t0 (a INT64, b STRING(10), c STRING(9000), d STRING(MAX), e BYTES(20000000), ` + "`select`" + ` INT64, ` + long + ` INT64)
+PRIMARY KEY (a, c);
+CREATE INDEX idx_d ON t0 (c, d);
+CREATE INDEX ` + long + ` ON t0 (b);
+CREATE TABLE t1 (a INT64, b INT64, c STRING(10)) PRIMARY KEY (a, b), INTERLEAVE IN PARENT t0;
+ALTER TABLE t1 ADD CONSTRAINT fk_ok FOREIGN KEY (a) REFERENCES t0 (a);
+ALTER TABLE t1 ADD CONSTRAINT fk_type FOREIGN KEY (c) REFERENCES t0 (a);
+ALTER TABLE t1 ADD CONSTRAINT fk_table FOREIGN KEY (a) REFERENCES t9 (a);
+ALTER TABLE t1 ADD CONSTRAINT fk_col FOREIGN KEY (a) REFERENCES t0 (z);
+`)
+	assert.Nil(t, err)
+	// Interleave tables 8 levels deep.
+	for i := 2; i < 8; i++ {
+		name := fmt.Sprintf("t%d", i)
+		s[name] = CreateTable{
+			Name:     name,
+			ColNames: []string{"a"},
+			ColDefs:  map[string]ColumnDef{"a": {Name: "a", T: Type{Name: Int64}}},
+			Pks:      []IndexKey{{Col: "a"}},
+			Parent:   fmt.Sprintf("t%d", i-1),
+		}
+	}
+	// Too many columns, key columns and indexes.
+	wide := CreateTable{Name: "wide", ColDefs: make(map[string]ColumnDef)}
+	for i := 0; i <= MaxColumnsPerTable; i++ {
+		col := fmt.Sprintf("c%d", i)
+		wide.ColNames = append(wide.ColNames, col)
+		wide.ColDefs[col] = ColumnDef{Name: col, T: Type{Name: Int64}}
+		if i <= MaxKeyColumns {
+			wide.Pks = append(wide.Pks, IndexKey{Col: col})
+		}
+		if i <= MaxIndexesPerTable {
+			wide.Indexes = append(wide.Indexes, CreateIndex{Name: "idx_" + col, Table: "wide", Keys: []IndexKey{{Col: col}}})
+		}
+	}
+	s["wide"] = wide
+
+	assert.Equal(t, []LimitViolation{
+		{Table: "t0", Col: "e", Msg: "BYTES length 20000000 is more than the limit of 10485760"},
+		{Table: "t0", Col: "select", Msg: "column name select is a reserved keyword, so it must be quoted with backticks in queries"},
+		{Table: "t0", Col: long, Msg: "column name " + long + " is 129 characters long, more than the limit of 128"},
+		{Table: "t0", Msg: "primary key is at least 9008 bytes, more than the limit of 8192"},
+		{Table: "t0", Msg: "index name " + long + " is 129 characters long, more than the limit of 128"},
+		{Table: "t1", Col: "c", Msg: "foreign key fk_type column has type STRING(10), but referenced column t0.a has type INT64"},
+		{Table: "t1", Msg: "foreign key fk_table references table t9, which doesn't exist"},
+		{Table: "t1", Msg: "foreign key fk_col uses column a or t0.z, which doesn't exist"},
+		{Table: "t7", Msg: "table is interleaved 8 levels deep, more than the limit of 7"},
+		{Table: "wide", Msg: "table has 1025 columns, more than the limit of 1024"},
+		{Table: "wide", Msg: "primary key has 17 columns, more than the limit of 16"},
+		{Table: "wide", Msg: "table has 129 indexes, more than the limit of 128"},
+	}, s.CheckLimits())
+}
@@ -0,0 +1,838 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ddl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ParseDDL parses Spanner DDL statements separated by semicolons, as
+// printed by GetDDL (with or without comments and backticks), into a
+// Schema. It supports the subset of Spanner DDL that Schema can represent:
+//
+//	CREATE TABLE (including inline FOREIGN KEY constraints, INTERLEAVE IN PARENT
+//	and ROW DELETION POLICY)
+//	CREATE [UNIQUE] INDEX
+//	ALTER TABLE ... ADD [CONSTRAINT ...] FOREIGN KEY
+//	CREATE SCHEMA (ignored: named schemas are implied by table names)
+//	CREATE CHANGE STREAM (ignored: see ParseAllDDL)
+//	ALTER DATABASE ... SET OPTIONS (ignored: see ParseAllDDL)
+//
+// Other statements and options (e.g. STORING clauses or column OPTIONS)
+// return an error rather than being silently dropped (see ParseDatabaseDDL).
+// Comments are skipped, so they are not recorded in the Comment fields of
+// the schema.
+func ParseDDL(s string) (Schema, error) {
+	d, err := ParseAllDDL(s)
+	return d.Schema, err
+}
+
+// ParseDatabaseDDL is like ParseDDL, but is meant for the DDL of existing
+// databases (as returned by GetDatabaseDdl), which may use any Spanner DDL.
+// Statements, clauses and options that Schema can't represent (e.g. views,
+// STORING clauses, NULL_FILTERED indexes, column OPTIONS, DEFAULT values,
+// generated columns, CHECK constraints and ON DELETE CASCADE) are skipped
+// rather than returning an error, and columns of unsupported types keep
+// the name of their type.
+func ParseDatabaseDDL(s string) (Schema, error) {
+	d, err := parseDDL(s, true)
+	return d.Schema, err
+}
+
+// ParsedDDL is the result of ParseAllDDL.
+type ParsedDDL struct {
+	Schema        Schema
+	ChangeStreams []ChangeStream  // Change streams, in order.
+	DbOptions     DatabaseOptions // Options set by ALTER DATABASE statements.
+}
+
+// ParseAllDDL is like ParseDDL, but also returns the change streams and
+// database options, which are not part of Schema.
+func ParseAllDDL(s string) (ParsedDDL, error) {
+	return parseDDL(s, false)
+}
+
+// parseDDL parses DDL statements. If lenient is true, statements, clauses
+// and options that can't be represented are skipped.
+func parseDDL(s string, lenient bool) (ParsedDDL, error) {
+	toks, err := tokenize(s, lenient)
+	if err != nil {
+		return ParsedDDL{}, err
+	}
+	p := &parser{toks: toks, schema: NewSchema(), lenient: lenient}
+	for !p.done() {
+		if p.acceptPunct(";") {
+			continue
+		}
+		if err := p.statement(); err != nil {
+			return ParsedDDL{}, err
+		}
+		if !p.done() {
+			if err := p.expectPunct(";"); err != nil {
+				return ParsedDDL{}, err
+			}
+		}
+	}
+	return ParsedDDL{Schema: p.schema, ChangeStreams: p.streams, DbOptions: p.dbOptions}, nil
+}
+
+type tokenKind int
+
+const (
+	identToken  tokenKind = iota // Unquoted identifier or keyword.
+	quotedToken                  // Identifier quoted using backticks.
+	numberToken
+	stringToken // String literal quoted using single quotes.
+	punctToken
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	line int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case quotedToken:
+		return "`" + t.text + "`"
+	case stringToken:
+		return "'" + t.text + "'"
+	}
+	return t.text
+}
+
+// tokenize splits s into tokens. If lenient is true, strings may also be
+// quoted using double quotes and contain backslash escapes, and unexpected
+// characters are returned as punctuation.
+func tokenize(s string, lenient bool) ([]token, error) {
+	var toks []token
+	r := []rune(s)
+	line := 1
+	for i := 0; i < len(r); {
+		c := r[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case unicode.IsSpace(c):
+			i++
+		case c == '-' && i+1 < len(r) && r[i+1] == '-', c == '#':
+			for i < len(r) && r[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(r) && r[i+1] == '*':
+			j := i + 2
+			for j+1 < len(r) && !(r[j] == '*' && r[j+1] == '/') {
+				if r[j] == '\n' {
+					line++
+				}
+				j++
+			}
+			if j+1 >= len(r) {
+				return nil, fmt.Errorf("line %d: unterminated comment", line)
+			}
+			i = j + 2
+		case c == '`':
+			j := i + 1
+			for j < len(r) && r[j] != '`' && r[j] != '\n' {
+				j++
+			}
+			if j == len(r) || r[j] != '`' {
+				return nil, fmt.Errorf("line %d: unterminated quoted identifier", line)
+			}
+			toks = append(toks, token{kind: quotedToken, text: string(r[i+1 : j]), line: line})
+			i = j + 1
+		case c == '\'', lenient && c == '"':
+			j := i + 1
+			for j < len(r) && r[j] != c && r[j] != '\n' {
+				if lenient && r[j] == '\\' && j+1 < len(r) {
+					j++
+				}
+				j++
+			}
+			if j >= len(r) || r[j] != c {
+				return nil, fmt.Errorf("line %d: unterminated string literal", line)
+			}
+			toks = append(toks, token{kind: stringToken, text: string(r[i+1 : j]), line: line})
+			i = j + 1
+		case unicode.IsLetter(c) || c == '_':
+			j := i
+			for j < len(r) && (unicode.IsLetter(r[j]) || unicode.IsDigit(r[j]) || r[j] == '_') {
+				j++
+			}
+			toks = append(toks, token{kind: identToken, text: string(r[i:j]), line: line})
+			i = j
+		case unicode.IsDigit(c):
+			j := i
+			for j < len(r) && unicode.IsDigit(r[j]) {
+				j++
+			}
+			toks = append(toks, token{kind: numberToken, text: string(r[i:j]), line: line})
+			i = j
+		case strings.ContainsRune("(),;.<>=", c), lenient:
+			toks = append(toks, token{kind: punctToken, text: string(c), line: line})
+			i++
+		default:
+			return nil, fmt.Errorf("line %d: unexpected character %q", line, c)
+		}
+	}
+	return toks, nil
+}
+
+type parser struct {
+	toks      []token
+	pos       int
+	schema    Schema
+	streams   []ChangeStream
+	dbOptions DatabaseOptions
+	lenient   bool // If true, skip what can't be represented rather than returning an error.
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.toks)
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	if p.done() {
+		return fmt.Errorf("unexpected end of input: "+format, args...)
+	}
+	return fmt.Errorf("line %d: "+format, append([]interface{}{p.toks[p.pos].line}, args...)...)
+}
+
+// isKeyword returns true if the next token is keyword kw.
+func (p *parser) isKeyword(kw string) bool {
+	return p.isKeywordAt(p.pos, kw)
+}
+
+// isKeywordAt returns true if the token at position i is keyword kw.
+func (p *parser) isKeywordAt(i int, kw string) bool {
+	return i < len(p.toks) && p.toks[i].kind == identToken && strings.EqualFold(p.toks[i].text, kw)
+}
+
+// skip skips tokens up to the next punctuation mark in stops that isn't
+// nested in parentheses or brackets.
+func (p *parser) skip(stops string) {
+	depth := 0
+	for ; !p.done(); p.pos++ {
+		t := p.toks[p.pos]
+		if t.kind != punctToken {
+			continue
+		}
+		if depth <= 0 && strings.Contains(stops, t.text) {
+			return
+		}
+		switch t.text {
+		case "(", "[":
+			depth++
+		case ")", "]":
+			depth--
+		}
+	}
+}
+
+func (p *parser) acceptKeyword(kw string) bool {
+	if p.isKeyword(kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectKeyword(kws ...string) error {
+	for _, kw := range kws {
+		if !p.acceptKeyword(kw) {
+			return p.errorf("expected %s, got %s", kw, p.peek())
+		}
+	}
+	return nil
+}
+
+func (p *parser) acceptPunct(s string) bool {
+	if !p.done() && p.toks[p.pos].kind == punctToken && p.toks[p.pos].text == s {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectPunct(s string) error {
+	if !p.acceptPunct(s) {
+		return p.errorf("expected %q, got %s", s, p.peek())
+	}
+	return nil
+}
+
+func (p *parser) peek() string {
+	if p.done() {
+		return "end of input"
+	}
+	return fmt.Sprintf("%q", p.toks[p.pos].String())
+}
+
+// ident parses an identifier.
+func (p *parser) ident() (string, error) {
+	if p.done() || (p.toks[p.pos].kind != identToken && p.toks[p.pos].kind != quotedToken) {
+		return "", p.errorf("expected identifier, got %s", p.peek())
+	}
+	p.pos++
+	return p.toks[p.pos-1].text, nil
+}
+
+// name parses a table, index or constraint name, which may be qualified
+// by a named schema e.g. "sales.orders".
+func (p *parser) name() (string, error) {
+	var l []string
+	for {
+		id, err := p.ident()
+		if err != nil {
+			return "", err
+		}
+		l = append(l, id)
+		if !p.acceptPunct(".") {
+			return strings.Join(l, "."), nil
+		}
+	}
+}
+
+// identList parses a parenthesized, comma separated list of identifiers.
+func (p *parser) identList() ([]string, error) {
+	if err := p.expectPunct("("); err != nil {
+		return nil, err
+	}
+	var l []string
+	for {
+		id, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		l = append(l, id)
+		if p.acceptPunct(")") {
+			return l, nil
+		}
+		if err := p.expectPunct(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) statement() error {
+	switch {
+	case p.acceptKeyword("CREATE"):
+		switch {
+		case p.acceptKeyword("TABLE"):
+			return p.createTable()
+		case p.acceptKeyword("SCHEMA"):
+			_, err := p.name()
+			return err
+		case p.isKeyword("UNIQUE"), p.isKeyword("INDEX"), p.lenient && p.isKeyword("NULL_FILTERED"):
+			return p.createIndex()
+		case p.acceptKeyword("CHANGE"):
+			return p.createChangeStream()
+		}
+	case p.acceptKeyword("ALTER"):
+		if p.acceptKeyword("DATABASE") {
+			return p.alterDatabase()
+		}
+		return p.alterTable()
+	}
+	if p.lenient {
+		p.skip(";")
+		return nil
+	}
+	return p.errorf("unsupported statement starting with %s", p.peek())
+}
+
+func (p *parser) createTable() error {
+	name, err := p.name()
+	if err != nil {
+		return err
+	}
+	if _, ok := p.schema[name]; ok {
+		return p.errorf("table %s is already defined", name)
+	}
+	ct := CreateTable{Name: name, ColDefs: make(map[string]ColumnDef)}
+	if err := p.expectPunct("("); err != nil {
+		return err
+	}
+	for !p.acceptPunct(")") {
+		if p.lenient && (p.isKeyword("CHECK") || p.isKeyword("CONSTRAINT") && p.isKeywordAt(p.pos+2, "CHECK")) {
+			// Skipped below.
+		} else if p.isKeyword("CONSTRAINT") || p.isKeyword("FOREIGN") {
+			fk, err := p.foreignKey()
+			if err != nil {
+				return err
+			}
+			ct.Fks = append(ct.Fks, fk)
+		} else {
+			cd, err := p.columnDef()
+			if err != nil {
+				return err
+			}
+			if _, ok := ct.ColDefs[cd.Name]; ok {
+				return p.errorf("column %s of table %s is already defined", cd.Name, name)
+			}
+			ct.ColNames = append(ct.ColNames, cd.Name)
+			ct.ColDefs[cd.Name] = cd
+		}
+		if p.lenient {
+			p.skip(",)")
+		}
+		if !p.acceptPunct(",") && !p.isPunct(")") {
+			return p.errorf("expected \",\" or \")\", got %s", p.peek())
+		}
+	}
+	if err := p.expectKeyword("PRIMARY", "KEY"); err != nil {
+		return err
+	}
+	if ct.Pks, err = p.keys(); err != nil {
+		return err
+	}
+	for p.acceptPunct(",") {
+		switch {
+		case ct.Parent == "" && ct.RowDeletionPolicy == nil && p.acceptKeyword("INTERLEAVE"):
+			if err := p.expectKeyword("IN", "PARENT"); err != nil {
+				return err
+			}
+			if ct.Parent, err = p.name(); err != nil {
+				return err
+			}
+			if p.lenient {
+				p.skip(",;")
+			} else if p.acceptKeyword("ON") {
+				// CreateTable can't represent ON DELETE CASCADE, so we only
+				// accept the default.
+				if err := p.expectKeyword("DELETE", "NO", "ACTION"); err != nil {
+					return err
+				}
+			}
+		case ct.RowDeletionPolicy == nil && p.acceptKeyword("ROW"):
+			if ct.RowDeletionPolicy, err = p.rowDeletionPolicy(); err != nil {
+				return err
+			}
+		case p.lenient:
+			p.skip(",;")
+		default:
+			return p.errorf("expected INTERLEAVE or ROW DELETION POLICY, got %s", p.peek())
+		}
+	}
+	p.schema[name] = ct
+	return nil
+}
+
+// rowDeletionPolicy parses the rest of a row deletion policy clause:
+//
+//	DELETION POLICY ( OLDER_THAN ( column_name, INTERVAL num_days DAY ) )
+func (p *parser) rowDeletionPolicy() (*RowDeletionPolicy, error) {
+	if err := p.expectKeyword("DELETION", "POLICY"); err != nil {
+		return nil, err
+	}
+	if err := p.expectPunct("("); err != nil {
+		return nil, err
+	}
+	if err := p.expectKeyword("OLDER_THAN"); err != nil {
+		return nil, err
+	}
+	if err := p.expectPunct("("); err != nil {
+		return nil, err
+	}
+	col, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectPunct(","); err != nil {
+		return nil, err
+	}
+	if err := p.expectKeyword("INTERVAL"); err != nil {
+		return nil, err
+	}
+	if p.done() || p.toks[p.pos].kind != numberToken {
+		return nil, p.errorf("expected number of days, got %s", p.peek())
+	}
+	days, err := strconv.ParseInt(p.toks[p.pos].text, 10, 64)
+	if err != nil {
+		return nil, p.errorf("invalid number of days %s", p.peek())
+	}
+	p.pos++
+	if err := p.expectKeyword("DAY"); err != nil {
+		return nil, err
+	}
+	if err := p.expectPunct(")"); err != nil {
+		return nil, err
+	}
+	if err := p.expectPunct(")"); err != nil {
+		return nil, err
+	}
+	return &RowDeletionPolicy{Col: col, Days: days}, nil
+}
+
+func (p *parser) createChangeStream() error {
+	if err := p.expectKeyword("STREAM"); err != nil {
+		return err
+	}
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	cs := ChangeStream{Name: name}
+	if p.acceptKeyword("FOR") {
+		if p.acceptKeyword("ALL") {
+			cs.All = true
+		} else {
+			for {
+				table, err := p.name()
+				if err != nil {
+					return err
+				}
+				cst := ChangeStreamTable{Table: table}
+				if p.isPunct("(") {
+					if cst.Cols, err = p.identList(); err != nil {
+						return err
+					}
+				}
+				cs.Tables = append(cs.Tables, cst)
+				if !p.acceptPunct(",") {
+					break
+				}
+			}
+		}
+	}
+	if p.acceptKeyword("OPTIONS") {
+		if err := p.expectPunct("("); err != nil {
+			return err
+		}
+		for {
+			opt, err := p.ident()
+			if err != nil {
+				return err
+			}
+			if err := p.expectPunct("="); err != nil {
+				return err
+			}
+			opt = strings.ToLower(opt)
+			if p.lenient && opt != "retention_period" && opt != "value_capture_type" {
+				p.skip(",)")
+			} else {
+				if p.done() || p.toks[p.pos].kind != stringToken {
+					return p.errorf("expected string value of option %s, got %s", opt, p.peek())
+				}
+				val := p.toks[p.pos].text
+				p.pos++
+				switch opt {
+				case "retention_period":
+					cs.RetentionPeriod = val
+				case "value_capture_type":
+					cs.ValueCaptureType = val
+				default:
+					return p.errorf("unsupported change stream option %s", opt)
+				}
+			}
+			if p.acceptPunct(")") {
+				break
+			}
+			if err := p.expectPunct(","); err != nil {
+				return err
+			}
+		}
+	}
+	for _, other := range p.streams {
+		if other.Name == name {
+			return p.errorf("change stream %s is already defined", name)
+		}
+	}
+	p.streams = append(p.streams, cs)
+	return nil
+}
+
+func (p *parser) isPunct(s string) bool {
+	return !p.done() && p.toks[p.pos].kind == punctToken && p.toks[p.pos].text == s
+}
+
+func (p *parser) columnDef() (ColumnDef, error) {
+	name, err := p.ident()
+	if err != nil {
+		return ColumnDef{}, err
+	}
+	ty, err := p.columnType()
+	if err != nil {
+		return ColumnDef{}, err
+	}
+	cd := ColumnDef{Name: name, T: ty}
+	if p.acceptKeyword("NOT") {
+		if err := p.expectKeyword("NULL"); err != nil {
+			return ColumnDef{}, err
+		}
+		cd.NotNull = true
+	}
+	return cd, nil
+}
+
+func (p *parser) columnType() (Type, error) {
+	if p.acceptKeyword("ARRAY") {
+		if err := p.expectPunct("<"); err != nil {
+			return Type{}, err
+		}
+		if p.isKeyword("ARRAY") {
+			return Type{}, p.errorf("nested arrays are not supported")
+		}
+		ty, err := p.columnType()
+		if err != nil {
+			return Type{}, err
+		}
+		if err := p.expectPunct(">"); err != nil {
+			return Type{}, err
+		}
+		ty.IsArray = true
+		return ty, nil
+	}
+	if p.done() || p.toks[p.pos].kind != identToken {
+		return Type{}, p.errorf("expected column type, got %s", p.peek())
+	}
+	ty := Type{Name: strings.ToUpper(p.toks[p.pos].text)}
+	p.pos++
+	switch ty.Name {
+	case Bool, Date, Float64, Int64, Json, Numeric, Timestamp:
+		return ty, nil
+	case String, Bytes:
+		if err := p.expectPunct("("); err != nil {
+			return Type{}, err
+		}
+		switch {
+		case p.acceptKeyword("MAX"):
+			ty.Len = MaxLength
+		case !p.done() && p.toks[p.pos].kind == numberToken:
+			n, err := strconv.ParseInt(p.toks[p.pos].text, 10, 64)
+			if err != nil || n <= 0 {
+				return Type{}, p.errorf("invalid length %s", p.peek())
+			}
+			ty.Len = n
+			p.pos++
+		default:
+			return Type{}, p.errorf("expected length, got %s", p.peek())
+		}
+		return ty, p.expectPunct(")")
+	}
+	if p.lenient {
+		// Skip type parameters e.g. of STRUCT<...> or proto type names
+		// e.g. package.Message.
+		for p.acceptPunct(".") {
+			if _, err := p.ident(); err != nil {
+				return Type{}, err
+			}
+		}
+		if p.acceptPunct("<") {
+			for depth := 1; depth > 0 && !p.done(); p.pos++ {
+				if p.isPunct("<") {
+					depth++
+				} else if p.isPunct(">") {
+					depth--
+				}
+			}
+		}
+		if p.isPunct("(") {
+			p.pos++
+			p.skip(")")
+			return ty, p.expectPunct(")")
+		}
+		return ty, nil
+	}
+	p.pos--
+	return Type{}, p.errorf("unsupported column type %s", p.peek())
+}
+
+// keys parses a parenthesized list of key parts.
+func (p *parser) keys() ([]IndexKey, error) {
+	if err := p.expectPunct("("); err != nil {
+		return nil, err
+	}
+	var keys []IndexKey
+	for !p.acceptPunct(")") {
+		col, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		k := IndexKey{Col: col}
+		if p.acceptKeyword("DESC") {
+			k.Desc = true
+		} else {
+			p.acceptKeyword("ASC")
+		}
+		keys = append(keys, k)
+		if !p.acceptPunct(",") && !p.isPunct(")") {
+			return nil, p.errorf("expected \",\" or \")\", got %s", p.peek())
+		}
+	}
+	return keys, nil
+}
+
+func (p *parser) foreignKey() (Foreignkey, error) {
+	var fk Foreignkey
+	var err error
+	if p.acceptKeyword("CONSTRAINT") {
+		if fk.Name, err = p.name(); err != nil {
+			return fk, err
+		}
+	}
+	if err := p.expectKeyword("FOREIGN", "KEY"); err != nil {
+		return fk, err
+	}
+	if fk.Columns, err = p.identList(); err != nil {
+		return fk, err
+	}
+	if err := p.expectKeyword("REFERENCES"); err != nil {
+		return fk, err
+	}
+	if fk.ReferTable, err = p.name(); err != nil {
+		return fk, err
+	}
+	if fk.ReferColumns, err = p.identList(); err != nil {
+		return fk, err
+	}
+	if len(fk.Columns) != len(fk.ReferColumns) {
+		return fk, p.errorf("foreign key %s has %d columns but references %d columns", fk.Name, len(fk.Columns), len(fk.ReferColumns))
+	}
+	return fk, nil
+}
+
+func (p *parser) createIndex() error {
+	ci := CreateIndex{Unique: p.acceptKeyword("UNIQUE")}
+	if p.lenient {
+		p.acceptKeyword("NULL_FILTERED")
+	}
+	if err := p.expectKeyword("INDEX"); err != nil {
+		return err
+	}
+	var err error
+	if ci.Name, err = p.name(); err != nil {
+		return err
+	}
+	if err := p.expectKeyword("ON"); err != nil {
+		return err
+	}
+	if ci.Table, err = p.name(); err != nil {
+		return err
+	}
+	if ci.Keys, err = p.keys(); err != nil {
+		return err
+	}
+	if p.lenient {
+		p.skip(";")
+	}
+	ct, ok := p.schema[ci.Table]
+	if !ok {
+		return p.errorf("index %s is on table %s, which isn't defined", ci.Name, ci.Table)
+	}
+	ct.Indexes = append(ct.Indexes, ci)
+	p.schema[ci.Table] = ct
+	return nil
+}
+
+func (p *parser) alterDatabase() error {
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	if p.dbOptions.Database != "" && p.dbOptions.Database != name {
+		return p.errorf("options are set for databases %s and %s", p.dbOptions.Database, name)
+	}
+	p.dbOptions.Database = name
+	if err := p.expectKeyword("SET", "OPTIONS"); err != nil {
+		return err
+	}
+	if err := p.expectPunct("("); err != nil {
+		return err
+	}
+	for {
+		opt, err := p.ident()
+		if err != nil {
+			return err
+		}
+		if err := p.expectPunct("="); err != nil {
+			return err
+		}
+		if p.done() {
+			return p.errorf("expected value of option %s, got %s", opt, p.peek())
+		}
+		tok := p.toks[p.pos]
+		switch strings.ToLower(opt) {
+		case "version_retention_period":
+			if tok.kind != stringToken {
+				return p.errorf("expected string value of option %s, got %s", opt, p.peek())
+			}
+			p.dbOptions.VersionRetentionPeriod = tok.text
+			p.pos++
+		case "default_leader":
+			if tok.kind != stringToken {
+				return p.errorf("expected string value of option %s, got %s", opt, p.peek())
+			}
+			p.dbOptions.DefaultLeader = tok.text
+			p.pos++
+		case "optimizer_version":
+			if tok.kind != numberToken {
+				return p.errorf("expected number value of option %s, got %s", opt, p.peek())
+			}
+			if p.dbOptions.OptimizerVersion, err = strconv.ParseInt(tok.text, 10, 64); err != nil {
+				return p.errorf("invalid optimizer_version %s", p.peek())
+			}
+			p.pos++
+		default:
+			if !p.lenient {
+				return p.errorf("unsupported database option %s", opt)
+			}
+			p.skip(",)")
+		}
+		if p.acceptPunct(")") {
+			return nil
+		}
+		if err := p.expectPunct(","); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *parser) alterTable() error {
+	if err := p.expectKeyword("TABLE"); err != nil {
+		return err
+	}
+	table, err := p.name()
+	if err != nil {
+		return err
+	}
+	if p.lenient && !(p.isKeyword("ADD") && (p.isKeywordAt(p.pos+1, "FOREIGN") || p.isKeywordAt(p.pos+1, "CONSTRAINT") && p.isKeywordAt(p.pos+3, "FOREIGN"))) {
+		// Not an ADD FOREIGN KEY statement.
+		p.skip(";")
+		return nil
+	}
+	if err := p.expectKeyword("ADD"); err != nil {
+		return err
+	}
+	fk, err := p.foreignKey()
+	if err != nil {
+		return err
+	}
+	if p.lenient {
+		p.skip(";")
+	}
+	ct, ok := p.schema[table]
+	if !ok {
+		return p.errorf("foreign key %s is on table %s, which isn't defined", fk.Name, table)
+	}
+	ct.Fks = append(ct.Fks, fk)
+	p.schema[table] = ct
+	return nil
+}
@@ -0,0 +1,286 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ddl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDDL(t *testing.T) {
+	s, err := ParseDDL(`
+-- Schema generated 2021-01-02
+CREATE TABLE users (
+    id INT64 NOT NULL,  -- From: id bigint
+    name STRING(MAX),
+    photo bytes(1024),
+    tags ARRAY<STRING(50)>,
+    /* Multi-line
+       comment. */
+    created TIMESTAMP NOT NULL,
+) PRIMARY KEY (id DESC);
+
+create table ` + "`orders`" + ` (
+    user_id INT64 NOT NULL,
+    ` + "`order`" + ` INT64 NOT NULL,
+    total NUMERIC,
+    info JSON,
+    shipped BOOL,
+    day DATE,
+    weight FLOAT64,
+    CONSTRAINT fk_users FOREIGN KEY (user_id) REFERENCES users (id)
+) PRIMARY KEY (user_id ASC, ` + "`order`" + `),
+INTERLEAVE IN PARENT users ON DELETE NO ACTION;
+
+CREATE UNIQUE INDEX idx_name ON users (name, created DESC);
+CREATE SCHEMA sales;
+CREATE TABLE sales.items (a INT64, t TIMESTAMP) PRIMARY KEY (a),
+ROW DELETION POLICY (OLDER_THAN(t, INTERVAL 30 DAY));
+ALTER TABLE sales.items ADD FOREIGN KEY (a) REFERENCES orders (user_id)
+`)
+	assert.Nil(t, err)
+	assert.Equal(t, Schema{
+		"users": {
+			Name:     "users",
+			ColNames: []string{"id", "name", "photo", "tags", "created"},
+			ColDefs: map[string]ColumnDef{
+				"id":      {Name: "id", T: Type{Name: Int64}, NotNull: true},
+				"name":    {Name: "name", T: Type{Name: String, Len: MaxLength}},
+				"photo":   {Name: "photo", T: Type{Name: Bytes, Len: 1024}},
+				"tags":    {Name: "tags", T: Type{Name: String, Len: 50, IsArray: true}},
+				"created": {Name: "created", T: Type{Name: Timestamp}, NotNull: true},
+			},
+			Pks:     []IndexKey{{Col: "id", Desc: true}},
+			Indexes: []CreateIndex{{Name: "idx_name", Table: "users", Unique: true, Keys: []IndexKey{{Col: "name"}, {Col: "created", Desc: true}}}},
+		},
+		"orders": {
+			Name:     "orders",
+			ColNames: []string{"user_id", "order", "total", "info", "shipped", "day", "weight"},
+			ColDefs: map[string]ColumnDef{
+				"user_id": {Name: "user_id", T: Type{Name: Int64}, NotNull: true},
+				"order":   {Name: "order", T: Type{Name: Int64}, NotNull: true},
+				"total":   {Name: "total", T: Type{Name: Numeric}},
+				"info":    {Name: "info", T: Type{Name: Json}},
+				"shipped": {Name: "shipped", T: Type{Name: Bool}},
+				"day":     {Name: "day", T: Type{Name: Date}},
+				"weight":  {Name: "weight", T: Type{Name: Float64}},
+			},
+			Pks:    []IndexKey{{Col: "user_id"}, {Col: "order"}},
+			Fks:    []Foreignkey{{Name: "fk_users", Columns: []string{"user_id"}, ReferTable: "users", ReferColumns: []string{"id"}}},
+			Parent: "users",
+		},
+		"sales.items": {
+			Name:              "sales.items",
+			ColNames:          []string{"a", "t"},
+			ColDefs:           map[string]ColumnDef{"a": {Name: "a", T: Type{Name: Int64}}, "t": {Name: "t", T: Type{Name: Timestamp}}},
+			Pks:               []IndexKey{{Col: "a"}},
+			Fks:               []Foreignkey{{Columns: []string{"a"}, ReferTable: "orders", ReferColumns: []string{"user_id"}}},
+			RowDeletionPolicy: &RowDeletionPolicy{Col: "t", Days: 30},
+		},
+	}, s)
+}
+
+func TestParseDDL_RoundTrip(t *testing.T) {
+	s := NewSchema()
+	s["table1"] = CreateTable{
+		Name:     "table1",
+		ColNames: []string{"a", "b", "c"},
+		ColDefs: map[string]ColumnDef{
+			"a": {Name: "a", T: Type{Name: Int64}, NotNull: true, Comment: "From: a bigint"},
+			"b": {Name: "b", T: Type{Name: String, Len: 42}},
+			"c": {Name: "c", T: Type{Name: Bytes, Len: MaxLength, IsArray: true}},
+		},
+		Pks:     []IndexKey{{Col: "a"}},
+		Fks:     []Foreignkey{{Name: "fk1", Columns: []string{"b"}, ReferTable: "sales.table2", ReferColumns: []string{"b"}}},
+		Indexes: []CreateIndex{{Name: "index1", Table: "table1", Keys: []IndexKey{{Col: "b", Desc: true}}}},
+		Comment: "Spanner schema for source table table1",
+	}
+	s["sales.table2"] = CreateTable{
+		Name:     "sales.table2",
+		ColNames: []string{"a", "b", "c"},
+		ColDefs: map[string]ColumnDef{
+			"a": {Name: "a", T: Type{Name: Int64}},
+			"b": {Name: "b", T: Type{Name: String, Len: 42}},
+			"c": {Name: "c", T: Type{Name: Timestamp}},
+		},
+		Pks:               []IndexKey{{Col: "a"}, {Col: "b"}},
+		Indexes:           []CreateIndex{{Name: "sales.index2", Table: "sales.table2", Unique: true, Keys: []IndexKey{{Col: "b"}}}},
+		Parent:            "table1",
+		RowDeletionPolicy: &RowDeletionPolicy{Col: "c", Days: 7},
+	}
+	for _, c := range []Config{
+		{Comments: true, Tables: true, ForeignKeys: true},
+		{ProtectIds: true, Tables: true, ForeignKeys: true},
+	} {
+		got, err := ParseDDL(strings.Join(s.GetDDL(c), ";\n\n"))
+		assert.Nil(t, err)
+		// Comments are not parsed.
+		want := NewSchema()
+		for k, ct := range s {
+			ct.Comment = ""
+			for col, cd := range ct.ColDefs {
+				cd.Comment = ""
+				ct.ColDefs[col] = cd
+			}
+			want[k] = ct
+		}
+		assert.Equal(t, want, got)
+	}
+}
+
+func TestParseAllDDL(t *testing.T) {
+	streams := []ChangeStream{
+		{Name: "all_changes", All: true, RetentionPeriod: "7d", ValueCaptureType: NewRow},
+		{Name: "table_changes", Tables: []ChangeStreamTable{{Table: "t"}, {Table: "sales.u", Cols: []string{"b", "c"}}}},
+		{Name: "no_tables", ValueCaptureType: NewValues},
+	}
+	opts := DatabaseOptions{Database: "db", VersionRetentionPeriod: "3d", DefaultLeader: "us-east1", OptimizerVersion: 4}
+	for _, c := range []Config{{}, {ProtectIds: true}} {
+		ddl := []string{"CREATE TABLE t (a INT64) PRIMARY KEY (a)", "CREATE TABLE sales.u (a INT64, b INT64, c INT64) PRIMARY KEY (a)"}
+		ddl = append(ddl, GetChangeStreamDDL(streams, c)...)
+		ddl = append(ddl, opts.PrintAlterDatabase(c)...)
+		got, err := ParseAllDDL(strings.Join(ddl, ";\n"))
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(got.Schema))
+		assert.Equal(t, streams, got.ChangeStreams)
+		assert.Equal(t, opts, got.DbOptions)
+		// ParseDDL ignores change streams and database options.
+		s, err := ParseDDL(strings.Join(ddl, ";\n"))
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(s))
+	}
+}
+
+func TestParseDatabaseDDL(t *testing.T) {
+	// DDL as returned by GetDatabaseDdl, using features Schema can't represent.
+	statements := []string{
+		"CREATE TABLE Singers (\n  SingerId INT64 NOT NULL,\n  Name STRING(1024) OPTIONS (description=\"singer's name\"),\n" +
+			"  Updated TIMESTAMP NOT NULL OPTIONS (\n    allow_commit_timestamp = true\n  ),\n" +
+			"  Score FLOAT32 DEFAULT (1.5),\n  Info STRUCT<a INT64, b ARRAY<STRING(MAX)>>,\n" +
+			"  Upper STRING(MAX) AS (UPPER(Name)) STORED,\n  Tokens TOKENLIST AS (TOKENIZE_FULLTEXT(Name)) HIDDEN,\n" +
+			"  CONSTRAINT positive_id CHECK(SingerId > 0),\n) PRIMARY KEY(SingerId),\nROW DELETION POLICY (OLDER_THAN(Updated, INTERVAL 30 DAY))",
+		"CREATE TABLE Albums (\n  SingerId INT64 NOT NULL,\n  AlbumId INT64 NOT NULL,\n  Title STRING(MAX),\n" +
+			"  CONSTRAINT FK_Other FOREIGN KEY(AlbumId) REFERENCES Singers(SingerId) ON DELETE CASCADE,\n" +
+			") PRIMARY KEY(SingerId, AlbumId),\n  INTERLEAVE IN PARENT Singers ON DELETE CASCADE",
+		"CREATE INDEX AlbumsByTitle ON Albums(Title) STORING (AlbumId)",
+		"CREATE UNIQUE NULL_FILTERED INDEX SingersByName ON Singers(Name DESC), INTERLEAVE IN Singers",
+		"ALTER TABLE Albums ADD CONSTRAINT FK_Singers FOREIGN KEY(SingerId) REFERENCES Singers(SingerId) ON DELETE CASCADE",
+		"ALTER TABLE Albums ADD CONSTRAINT title_not_empty CHECK(Title != '')",
+		"CREATE VIEW SingerNames SQL SECURITY INVOKER AS SELECT Singers.Name FROM Singers",
+		"CREATE CHANGE STREAM cs FOR Singers OPTIONS ( retention_period = '36h', exclude_ttl_deletes = true )",
+		"CREATE ROLE reader",
+		"GRANT SELECT ON TABLE Singers TO ROLE reader",
+		"ALTER DATABASE db SET OPTIONS (\n  enable_key_visualizer = true,\n  optimizer_version = 4\n)",
+	}
+	s, err := ParseDatabaseDDL(strings.Join(statements, ";\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, Schema{
+		"Singers": {
+			Name:     "Singers",
+			ColNames: []string{"SingerId", "Name", "Updated", "Score", "Info", "Upper", "Tokens"},
+			ColDefs: map[string]ColumnDef{
+				"SingerId": {Name: "SingerId", T: Type{Name: Int64}, NotNull: true},
+				"Name":     {Name: "Name", T: Type{Name: String, Len: 1024}},
+				"Updated":  {Name: "Updated", T: Type{Name: Timestamp}, NotNull: true},
+				"Score":    {Name: "Score", T: Type{Name: "FLOAT32"}},
+				"Info":     {Name: "Info", T: Type{Name: "STRUCT"}},
+				"Upper":    {Name: "Upper", T: Type{Name: String, Len: MaxLength}},
+				"Tokens":   {Name: "Tokens", T: Type{Name: "TOKENLIST"}},
+			},
+			Pks:               []IndexKey{{Col: "SingerId"}},
+			RowDeletionPolicy: &RowDeletionPolicy{Col: "Updated", Days: 30},
+			Indexes:           []CreateIndex{{Name: "SingersByName", Table: "Singers", Unique: true, Keys: []IndexKey{{Col: "Name", Desc: true}}}},
+		},
+		"Albums": {
+			Name:     "Albums",
+			ColNames: []string{"SingerId", "AlbumId", "Title"},
+			ColDefs: map[string]ColumnDef{
+				"SingerId": {Name: "SingerId", T: Type{Name: Int64}, NotNull: true},
+				"AlbumId":  {Name: "AlbumId", T: Type{Name: Int64}, NotNull: true},
+				"Title":    {Name: "Title", T: Type{Name: String, Len: MaxLength}},
+			},
+			Pks:    []IndexKey{{Col: "SingerId"}, {Col: "AlbumId"}},
+			Parent: "Singers",
+			Fks: []Foreignkey{
+				{Name: "FK_Other", Columns: []string{"AlbumId"}, ReferTable: "Singers", ReferColumns: []string{"SingerId"}},
+				{Name: "FK_Singers", Columns: []string{"SingerId"}, ReferTable: "Singers", ReferColumns: []string{"SingerId"}},
+			},
+			Indexes: []CreateIndex{{Name: "AlbumsByTitle", Table: "Albums", Keys: []IndexKey{{Col: "Title"}}}},
+		},
+	}, s)
+
+	// ParseDDL doesn't accept any of these statements.
+	tables := "CREATE TABLE Singers (SingerId INT64 NOT NULL, Name STRING(MAX), Updated TIMESTAMP) PRIMARY KEY (SingerId);\n" +
+		"CREATE TABLE Albums (SingerId INT64, AlbumId INT64, Title STRING(MAX)) PRIMARY KEY (SingerId, AlbumId);\n"
+	for i, stmt := range statements {
+		if i >= 2 {
+			stmt = tables + stmt
+		}
+		_, err := ParseDDL(stmt)
+		assert.NotNil(t, err, stmt)
+	}
+	_, err = ParseDDL(tables)
+	assert.Nil(t, err)
+
+	// Syntax errors are still reported.
+	for _, tc := range []string{
+		"CREATE TABLE t (a INT64 OPTIONS (x = 1) PRIMARY KEY (a)",
+		"CREATE TABLE t (a INT64) PRIMARY KEY (a); CREATE INDEX i ON u (a) STORING (b)",
+		"CREATE TABLE t (a STRING(MAX) DEFAULT (\"x)) PRIMARY KEY (a)",
+	} {
+		_, err := ParseDatabaseDDL(tc)
+		assert.NotNil(t, err, tc)
+	}
+}
+
+func TestParseDDL_Errors(t *testing.T) {
+	errorCases := []string{
+		"CREATE TABLE t (a INT64) PRIMARY KEY (a) CREATE TABLE u (a INT64) PRIMARY KEY (a)",
+		"CREATE TABLE t (a INT64) PRIMARY KEY (a); CREATE TABLE t (a INT64) PRIMARY KEY (a)",
+		"CREATE TABLE t (a INT64, a INT64) PRIMARY KEY (a)",
+		"CREATE TABLE t (a INT32) PRIMARY KEY (a)",
+		"CREATE TABLE t (a STRING) PRIMARY KEY (a)",
+		"CREATE TABLE t (a STRING(0)) PRIMARY KEY (a)",
+		"CREATE TABLE t (a ARRAY<ARRAY<INT64>>) PRIMARY KEY (a)",
+		"CREATE TABLE t (a INT64 OPTIONS (allow_commit_timestamp=true)) PRIMARY KEY (a)",
+		"CREATE TABLE t (a INT64) PRIMARY KEY (a), INTERLEAVE IN PARENT p ON DELETE CASCADE",
+		"CREATE TABLE t (a INT64)",
+		"CREATE TABLE t (a TIMESTAMP) PRIMARY KEY (a), ROW DELETION POLICY (OLDER_THAN(a, INTERVAL 1 HOUR))",
+		"CREATE TABLE t (a TIMESTAMP) PRIMARY KEY (a), ROW DELETION POLICY (OLDER_THAN(a, INTERVAL 1 DAY)), ROW DELETION POLICY (OLDER_THAN(a, INTERVAL 2 DAY))",
+		"CREATE TABLE `t (a INT64) PRIMARY KEY (a)",
+		"CREATE TABLE t (a INT64) PRIMARY KEY (a) /* unterminated",
+		"CREATE INDEX i ON t (a)",
+		"CREATE TABLE t (a INT64) PRIMARY KEY (a); CREATE NULL_FILTERED INDEX i ON t (a)",
+		"CREATE TABLE t (a INT64) PRIMARY KEY (a); CREATE INDEX i ON t (a) STORING (b)",
+		"ALTER TABLE t ADD FOREIGN KEY (a) REFERENCES u (b)",
+		"CREATE TABLE t (a INT64, b INT64, FOREIGN KEY (a, b) REFERENCES u (c)) PRIMARY KEY (a)",
+		"DROP TABLE t",
+		"CREATE TABLE t (a INT64) PRIMARY KEY (a); SELECT 1",
+		"CREATE CHANGE STREAM cs FOR ALL OPTIONS (retention_period = 7)",
+		"CREATE CHANGE STREAM cs FOR ALL OPTIONS (retention_period = '7d",
+		"CREATE CHANGE STREAM cs FOR ALL OPTIONS (exclude_ttl_deletes = 'true')",
+		"CREATE CHANGE STREAM cs FOR ALL; CREATE CHANGE STREAM cs FOR ALL",
+		"ALTER DATABASE db SET OPTIONS (optimizer_version = '4')",
+		"ALTER DATABASE db SET OPTIONS (version_retention_period = 3)",
+		"ALTER DATABASE db SET OPTIONS (enable_key_visualizer = true)",
+		"ALTER DATABASE db SET OPTIONS (optimizer_version = 4); ALTER DATABASE other SET OPTIONS (optimizer_version = 4)",
+	}
+	for _, tc := range errorCases {
+		_, err := ParseDDL(tc)
+		assert.NotNil(t, err, tc)
+	}
+}
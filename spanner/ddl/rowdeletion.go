@@ -0,0 +1,86 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ddl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SetRowDeletionPolicy sets the row deletion policy of table t to rdp, or
+// removes the policy if rdp is nil. If the resulting schema has invalid
+// policies (see CheckRowDeletionPolicies), it returns an error and leaves
+// s unchanged.
+func (s Schema) SetRowDeletionPolicy(t string, rdp *RowDeletionPolicy) error {
+	ct, ok := s[t]
+	if !ok {
+		return fmt.Errorf("table %s not found", t)
+	}
+	old := ct.RowDeletionPolicy
+	ct.RowDeletionPolicy = rdp
+	s[t] = ct
+	if err := s.CheckRowDeletionPolicies(); err != nil {
+		ct.RowDeletionPolicy = old
+		s[t] = ct
+		return err
+	}
+	return nil
+}
+
+// CheckRowDeletionPolicies checks the row deletion policies of schema s.
+// A policy must use a TIMESTAMP column of its table and a non-negative
+// number of days. Since HarbourBridge interleaves tables with ON DELETE NO
+// ACTION, parent rows can't be deleted while they have child rows: so the
+// policy of an interleaved table can't keep rows for longer than the
+// policy of its nearest ancestor table that has one.
+func (s Schema) CheckRowDeletionPolicies() error {
+	var l []string
+	for _, t := range sortedTables(s) {
+		rdp := s[t].RowDeletionPolicy
+		if rdp == nil {
+			continue
+		}
+		cd, ok := s[t].ColDefs[rdp.Col]
+		switch {
+		case !ok:
+			l = append(l, fmt.Sprintf("row deletion policy of table %s uses column %s, which doesn't exist", t, rdp.Col))
+		case cd.T.Name != Timestamp || cd.T.IsArray:
+			l = append(l, fmt.Sprintf("row deletion policy of table %s uses column %s of type %s, but it must be a TIMESTAMP column", t, rdp.Col, cd.T.PrintColumnDefType()))
+		}
+		if rdp.Days < 0 {
+			l = append(l, fmt.Sprintf("row deletion policy of table %s has a negative interval of %d days", t, rdp.Days))
+		}
+		if p, prdp := s.parentPolicy(t); prdp != nil && rdp.Days > prdp.Days {
+			l = append(l, fmt.Sprintf("row deletion policy of table %s keeps rows for %d days, but rows of its parent table %s are deleted after %d days", t, rdp.Days, p, prdp.Days))
+		}
+	}
+	if len(l) > 0 {
+		return fmt.Errorf("invalid row deletion policies: %s", strings.Join(l, "; "))
+	}
+	return nil
+}
+
+// parentPolicy returns the nearest ancestor of table t that has a row
+// deletion policy, along with its policy.
+func (s Schema) parentPolicy(t string) (string, *RowDeletionPolicy) {
+	depth := 0
+	for p := s[t].Parent; p != "" && depth < len(s); p = s[p].Parent {
+		if rdp := s[p].RowDeletionPolicy; rdp != nil {
+			return p, rdp
+		}
+		depth++
+	}
+	return "", nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ddl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetRowDeletionPolicy(t *testing.T) {
+	s, err := ParseDDL(`
+CREATE TABLE users (id INT64, created TIMESTAMP, name STRING(MAX)) PRIMARY KEY (id);
+CREATE TABLE orders (id INT64, oid INT64, created TIMESTAMP, tags ARRAY<TIMESTAMP>) PRIMARY KEY (id, oid),
+INTERLEAVE IN PARENT users;
+CREATE TABLE items (id INT64, oid INT64, iid INT64, created TIMESTAMP) PRIMARY KEY (id, oid, iid),
+INTERLEAVE IN PARENT orders;
+`)
+	assert.Nil(t, err)
+	tests := []struct {
+		name      string
+		table     string
+		rdp       *RowDeletionPolicy
+		errorWant bool
+	}{
+		{"Valid", "users", &RowDeletionPolicy{Col: "created", Days: 30}, false},
+		{"Unknown table", "payments", &RowDeletionPolicy{Col: "created", Days: 30}, true},
+		{"Unknown column", "orders", &RowDeletionPolicy{Col: "shipped", Days: 7}, true},
+		{"Not a timestamp", "users", &RowDeletionPolicy{Col: "name", Days: 7}, true},
+		{"Array of timestamps", "orders", &RowDeletionPolicy{Col: "tags", Days: 7}, true},
+		{"Negative interval", "users", &RowDeletionPolicy{Col: "created", Days: -1}, true},
+		{"Child outlives parent", "orders", &RowDeletionPolicy{Col: "created", Days: 60}, true},
+		{"Grandchild outlives grandparent", "items", &RowDeletionPolicy{Col: "created", Days: 31}, true},
+		{"Child within parent", "items", &RowDeletionPolicy{Col: "created", Days: 30}, false},
+		{"Parent shorter than child", "users", &RowDeletionPolicy{Col: "created", Days: 10}, true},
+		{"Remove", "items", nil, false},
+		{"Parent after removal", "users", &RowDeletionPolicy{Col: "created", Days: 10}, false},
+	}
+	for _, tc := range tests {
+		old := s[tc.table].RowDeletionPolicy
+		err := s.SetRowDeletionPolicy(tc.table, tc.rdp)
+		if tc.errorWant {
+			assert.NotNil(t, err, tc.name)
+			assert.Equal(t, old, s[tc.table].RowDeletionPolicy, tc.name)
+			continue
+		}
+		assert.Nil(t, err, tc.name)
+		assert.Equal(t, tc.rdp, s[tc.table].RowDeletionPolicy, tc.name)
+	}
+	assert.Nil(t, s.CheckRowDeletionPolicies())
+}
+
+func TestPrintRowDeletionPolicy(t *testing.T) {
+	ct := CreateTable{
+		Name:              "events",
+		ColNames:          []string{"id", "ts"},
+		ColDefs:           map[string]ColumnDef{"id": {Name: "id", T: Type{Name: Int64}}, "ts": {Name: "ts", T: Type{Name: Timestamp}}},
+		Pks:               []IndexKey{{Col: "id"}},
+		Parent:            "users",
+		RowDeletionPolicy: &RowDeletionPolicy{Col: "ts", Days: 30},
+	}
+	assert.Equal(t, normalizeSpace("CREATE TABLE `events` (`id` INT64, `ts` TIMESTAMP) PRIMARY KEY (`id`),\nINTERLEAVE IN PARENT `users`,\nROW DELETION POLICY (OLDER_THAN(`ts`, INTERVAL 30 DAY))"),
+		normalizeSpace(ct.PrintCreateTable(Config{ProtectIds: true})))
+	assert.Equal(t, normalizeSpace(`CREATE TABLE "events" ("id" bigint, "ts" timestamptz, PRIMARY KEY ("id")) INTERLEAVE IN PARENT "users" TTL INTERVAL '30 days' ON "ts"`),
+		normalizeSpace(ct.PrintCreateTable(Config{ProtectIds: true, Dialect: PostgreSQL})))
+}
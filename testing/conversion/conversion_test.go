@@ -31,6 +31,7 @@ import (
 	"github.com/cloudspannerecosystem/harbourbridge/conversion"
 	"github.com/cloudspannerecosystem/harbourbridge/internal"
 	"github.com/cloudspannerecosystem/harbourbridge/spanner/ddl"
+	"github.com/googleapis/gax-go/v2"
 	"github.com/stretchr/testify/assert"
 
 	database "cloud.google.com/go/spanner/admin/database/apiv1"
@@ -191,7 +192,7 @@ func TestUpdateDDLForeignKeys(t *testing.T) {
 			t.Fatal(err)
 		}
 		conversion.MaxWorkers = tc.numWorkers
-		if err = conversion.UpdateDDLForeignKeys(ctx, databaseAdmin, dbURI, conv, os.Stdout); err != nil {
+		if err = conversion.UpdateDDLForeignKeys(ctx, databaseAdmin, dbURI, conv, conversion.ForeignKeyOptions{}, os.Stdout); err != nil {
 			t.Fatalf("\nCan't perform update operation on db %s with foreign keys: %v\n", tc.dbName, err)
 		}
 
@@ -293,3 +294,53 @@ func onlyRunForEmulatorTest(t *testing.T) {
 		t.Skip("Skipping tests only running against the emulator.")
 	}
 }
+
+// fakeDdlReader returns the DDL statements of a database, in the format
+// used by Spanner.
+type fakeDdlReader struct {
+	statements []string
+}
+
+func (f fakeDdlReader) GetDatabaseDdl(ctx context.Context, req *databasepb.GetDatabaseDdlRequest, opts ...gax.CallOption) (*databasepb.GetDatabaseDdlResponse, error) {
+	return &databasepb.GetDatabaseDdlResponse{Statements: f.statements}, nil
+}
+
+func TestDiffDatabase(t *testing.T) {
+	conv := BuildConv(t, 3, 1, false)
+	fake := fakeDdlReader{statements: []string{
+		"CREATE TABLE table_a (\n  col1 STRING(10),\n  col2 STRING(MAX),\n  CONSTRAINT fk_1 FOREIGN KEY(col1) REFERENCES table_b(col1),\n) PRIMARY KEY(col1)",
+		"CREATE TABLE table_b (\n  col1 STRING(10),\n  col2 STRING(10),\n  col3 STRING(10),\n) PRIMARY KEY(col1)",
+	}}
+	d, err := conversion.DiffDatabase(ctx, fake, "projects/p/instances/i/databases/d", conv)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"column table_a.col2 is STRING(MAX) in database, STRING(10) in schema",
+		"column table_a.col3 is missing",
+	}, d.Differences)
+	assert.Equal(t, []string{
+		"ALTER TABLE `table_a` ALTER COLUMN `col2` STRING(10)",
+		"ALTER TABLE `table_a` ADD COLUMN `col3` STRING(10)",
+	}, d.Statements)
+}
+
+func TestDiffDatabase_Emulator(t *testing.T) {
+	onlyRunForEmulatorTest(t)
+	dbURI := fmt.Sprintf("projects/%s/instances/%s/databases/%s", projectID, instanceID, "diff-database")
+	conv := BuildConv(t, 2, 0, false)
+	err := conversion.CreateDatabase(ctx, databaseAdmin, dbURI, conv, os.Stdout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dropDatabase(t, dbURI)
+	d, err := conversion.DiffDatabase(ctx, databaseAdmin, dbURI, conv)
+	assert.Nil(t, err)
+	assert.True(t, d.Empty())
+
+	d, err = conversion.DiffDatabase(ctx, databaseAdmin, dbURI, BuildConv(t, 3, 1, false))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"foreign key fk_1 of table table_a is missing",
+		"column table_a.col3 is missing",
+		"column table_b.col3 is missing",
+	}, d.Differences)
+}
@@ -48,6 +48,8 @@ func getRoutes() *mux.Router {
 	router.HandleFunc("/rename/fks", renameForeignKeys).Methods("POST")
 	router.HandleFunc("/rename/indexes", renameIndexes).Methods("POST")
 	router.HandleFunc("/add/indexes", addIndexes).Methods("POST")
+	router.HandleFunc("/rowdeletionpolicy", setRowDeletionPolicy).Methods("POST")
+	router.HandleFunc("/changestreams", setChangeStreams).Methods("POST")
 
 	router.PathPrefix("/").Handler(http.FileServer(staticFileDirectory))
 	return router
@@ -2323,7 +2323,7 @@ func buildConvMySQL(conv *internal.Conv) {
 			"a": []internal.SchemaIssue{internal.Widened},
 		},
 	}
-	conv.SyntheticPKeys["t2"] = internal.SyntheticPKey{"synth_id", 0}
+	conv.SyntheticPKeys["t2"] = internal.SyntheticPKey{Col: "synth_id", Sequence: 0}
 }
 
 func buildConvPostgres(conv *internal.Conv) {
@@ -2431,5 +2431,145 @@ func buildConvPostgres(conv *internal.Conv) {
 			"b": []internal.SchemaIssue{internal.Widened},
 		},
 	}
-	conv.SyntheticPKeys["t2"] = internal.SyntheticPKey{"synth_id", 0}
+	conv.SyntheticPKeys["t2"] = internal.SyntheticPKey{Col: "synth_id", Sequence: 0}
+}
+
+func TestSetRowDeletionPolicy(t *testing.T) {
+	tc := []struct {
+		name         string
+		table        string
+		body         string
+		statusCode   int64
+		expectedRdp  *ddl.RowDeletionPolicy
+		parentPolicy *ddl.RowDeletionPolicy
+	}{
+		{
+			name:        "Test set policy success",
+			table:       "t1",
+			body:        `{"Col": "ts", "Days": 30}`,
+			statusCode:  http.StatusOK,
+			expectedRdp: &ddl.RowDeletionPolicy{Col: "ts", Days: 30},
+		},
+		{
+			name:       "Test set policy on non-timestamp column",
+			table:      "t1",
+			body:       `{"Col": "a", "Days": 30}`,
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Test set policy longer than parent policy",
+			table:        "t1",
+			body:         `{"Col": "ts", "Days": 30}`,
+			statusCode:   http.StatusBadRequest,
+			parentPolicy: &ddl.RowDeletionPolicy{Col: "ts", Days: 7},
+		},
+		{
+			name:        "Test remove policy",
+			table:       "t1",
+			body:        `{}`,
+			statusCode:  http.StatusOK,
+			expectedRdp: nil,
+		},
+		{
+			name:       "Test unknown table",
+			table:      "t9",
+			body:       `{"Col": "ts", "Days": 30}`,
+			statusCode: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range tc {
+		colDefs := map[string]ddl.ColumnDef{
+			"a":  ddl.ColumnDef{Name: "a", T: ddl.Type{Name: ddl.Int64}},
+			"ts": ddl.ColumnDef{Name: "ts", T: ddl.Type{Name: ddl.Timestamp}},
+		}
+		sessionState.driver = "mysql"
+		sessionState.conv = &internal.Conv{
+			SpSchema: map[string]ddl.CreateTable{
+				"t0": ddl.CreateTable{Name: "t0", ColNames: []string{"a", "ts"}, ColDefs: colDefs, RowDeletionPolicy: tc.parentPolicy},
+				"t1": ddl.CreateTable{Name: "t1", ColNames: []string{"a", "ts"}, ColDefs: colDefs, Parent: "t0"},
+			},
+		}
+		req, err := http.NewRequest("POST", "/rowdeletionpolicy?table="+tc.table, strings.NewReader(tc.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(setRowDeletionPolicy)
+		handler.ServeHTTP(rr, req)
+		var res *internal.Conv
+		json.Unmarshal(rr.Body.Bytes(), &res)
+		if status := rr.Code; int64(status) != tc.statusCode {
+			t.Errorf("%s: handler returned wrong status code: got %v want %v",
+				tc.name, status, tc.statusCode)
+		}
+		if tc.statusCode == http.StatusOK {
+			assert.Equal(t, tc.expectedRdp, res.SpSchema[tc.table].RowDeletionPolicy, tc.name)
+		}
+	}
+}
+
+func TestSetChangeStreams(t *testing.T) {
+	tc := []struct {
+		name            string
+		body            string
+		statusCode      int64
+		expectedStreams []ddl.ChangeStream
+	}{
+		{
+			name:            "Test set change streams success",
+			body:            `[{"Name": "cs", "Tables": [{"Table": "t1", "Cols": ["b"]}], "RetentionPeriod": "7d"}]`,
+			statusCode:      http.StatusOK,
+			expectedStreams: []ddl.ChangeStream{{Name: "cs", Tables: []ddl.ChangeStreamTable{{Table: "t1", Cols: []string{"b"}}}, RetentionPeriod: "7d"}},
+		},
+		{
+			name:       "Test unknown table",
+			body:       `[{"Name": "cs", "Tables": [{"Table": "t9"}]}]`,
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Test invalid value capture type",
+			body:       `[{"Name": "cs", "All": true, "ValueCaptureType": "OLD_VALUES"}]`,
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:            "Test remove change streams",
+			body:            `[]`,
+			statusCode:      http.StatusOK,
+			expectedStreams: []ddl.ChangeStream{},
+		},
+	}
+	for _, tc := range tc {
+		sessionState.driver = "mysql"
+		sessionState.conv = &internal.Conv{
+			SpSchema: map[string]ddl.CreateTable{
+				"t1": ddl.CreateTable{
+					Name:     "t1",
+					ColNames: []string{"a", "b"},
+					ColDefs: map[string]ddl.ColumnDef{
+						"a": ddl.ColumnDef{Name: "a", T: ddl.Type{Name: ddl.Int64}},
+						"b": ddl.ColumnDef{Name: "b", T: ddl.Type{Name: ddl.String, Len: ddl.MaxLength}},
+					},
+					Pks: []ddl.IndexKey{ddl.IndexKey{Col: "a"}},
+				},
+			},
+		}
+		req, err := http.NewRequest("POST", "/changestreams", strings.NewReader(tc.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(setChangeStreams)
+		handler.ServeHTTP(rr, req)
+		var res *internal.Conv
+		json.Unmarshal(rr.Body.Bytes(), &res)
+		if status := rr.Code; int64(status) != tc.statusCode {
+			t.Errorf("%s: handler returned wrong status code: got %v want %v",
+				tc.name, status, tc.statusCode)
+		}
+		if tc.statusCode == http.StatusOK {
+			assert.Equal(t, tc.expectedStreams, res.ChangeStreams, tc.name)
+		}
+	}
 }